@@ -11,3 +11,20 @@ type Kalan struct {
 	Gloss string
 	Notes string
 }
+
+type KalanRelation struct {
+	ID        int32
+	KalanID   int32
+	RelatedID int32
+	Kind      string
+}
+
+type KalanSense struct {
+	ID         int32
+	KalanID    int32
+	Position   int32
+	Gloss      string
+	Definition string
+	UsageLabel string
+	Register   string
+}
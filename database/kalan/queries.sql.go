@@ -8,6 +8,7 @@ package kalan
 import (
 	"context"
 	"database/sql"
+	"strings"
 )
 
 const createKalan = `-- name: CreateKalan :execresult
@@ -30,6 +31,77 @@ func (q *Queries) CreateKalan(ctx context.Context, arg CreateKalanParams) (sql.R
 	)
 }
 
+const createKalanWithID = `-- name: CreateKalanWithID :execresult
+INSERT INTO kalan (id, entry, pos, gloss, notes) VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateKalanWithIDParams struct {
+	ID    int32
+	Entry string
+	Pos   string
+	Gloss string
+	Notes string
+}
+
+func (q *Queries) CreateKalanWithID(ctx context.Context, arg CreateKalanWithIDParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createKalanWithID,
+		arg.ID,
+		arg.Entry,
+		arg.Pos,
+		arg.Gloss,
+		arg.Notes,
+	)
+}
+
+const createRelation = `-- name: CreateRelation :execresult
+INSERT INTO
+    kalan_relations (kalan_id, related_id, kind)
+VALUES (?, ?, ?)
+`
+
+type CreateRelationParams struct {
+	KalanID   int32
+	RelatedID int32
+	Kind      string
+}
+
+func (q *Queries) CreateRelation(ctx context.Context, arg CreateRelationParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createRelation, arg.KalanID, arg.RelatedID, arg.Kind)
+}
+
+const createSense = `-- name: CreateSense :execresult
+INSERT INTO
+    kalan_senses (
+        kalan_id,
+        position,
+        gloss,
+        definition,
+        usage_label,
+        register
+    )
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateSenseParams struct {
+	KalanID    int32
+	Position   int32
+	Gloss      string
+	Definition string
+	UsageLabel string
+	Register   string
+}
+
+func (q *Queries) CreateSense(ctx context.Context, arg CreateSenseParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createSense,
+		arg.KalanID,
+		arg.Position,
+		arg.Gloss,
+		arg.Definition,
+		arg.UsageLabel,
+		arg.Register,
+	)
+}
+
 const deleteKalan = `-- name: DeleteKalan :execresult
 DELETE FROM kalan WHERE id = ?
 `
@@ -38,6 +110,22 @@ func (q *Queries) DeleteKalan(ctx context.Context, id int32) (sql.Result, error)
 	return q.db.ExecContext(ctx, deleteKalan, id)
 }
 
+const deleteRelation = `-- name: DeleteRelation :execresult
+DELETE FROM kalan_relations WHERE id = ?
+`
+
+func (q *Queries) DeleteRelation(ctx context.Context, id int32) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteRelation, id)
+}
+
+const deleteSensesByKalanID = `-- name: DeleteSensesByKalanID :execresult
+DELETE FROM kalan_senses WHERE kalan_id = ?
+`
+
+func (q *Queries) DeleteSensesByKalanID(ctx context.Context, kalanID int32) (sql.Result, error) {
+	return q.db.ExecContext(ctx, deleteSensesByKalanID, kalanID)
+}
+
 const readKalan = `-- name: ReadKalan :many
 SELECT id, entry, pos, gloss, notes FROM kalan ORDER BY id
 `
@@ -105,64 +193,22 @@ func (q *Queries) ReadKalanById(ctx context.Context, id int32) (Kalan, error) {
 	return i, err
 }
 
-const readKalanBySearch = `-- name: ReadKalanBySearch :many
-SELECT id, entry, pos, gloss, notes
-FROM kalan
-WHERE (
-        ? = True
-        AND entry LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND pos LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND gloss LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND notes LIKE CONCAT('%', ?, '%')
-    )
-ORDER BY
-    CASE ?
-        WHEN 'entry' THEN entry
-        WHEN 'pos' THEN pos
-        WHEN 'gloss' THEN gloss
-        WHEN 'notes' THEN notes
-        ELSE NULL
-    END,
-    id
-LIMIT ?
-OFFSET
-    ?
+const readKalanByIDs = `-- name: ReadKalanByIDs :many
+SELECT id, entry, pos, gloss, notes FROM kalan WHERE id IN (/*SLICE:ids*/?) ORDER BY id
 `
 
-type ReadKalanBySearchParams struct {
-	Isentry interface{}
-	Search  interface{}
-	Ispos   interface{}
-	Isgloss interface{}
-	Isnotes interface{}
-	Sort    interface{}
-	Limit   int32
-	Offset  int32
-}
-
-func (q *Queries) ReadKalanBySearch(ctx context.Context, arg ReadKalanBySearchParams) ([]Kalan, error) {
-	rows, err := q.db.QueryContext(ctx, readKalanBySearch,
-		arg.Isentry,
-		arg.Search,
-		arg.Ispos,
-		arg.Search,
-		arg.Isgloss,
-		arg.Search,
-		arg.Isnotes,
-		arg.Search,
-		arg.Sort,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) ReadKalanByIDs(ctx context.Context, ids []int32) ([]Kalan, error) {
+	query := readKalanByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -201,49 +247,259 @@ func (q *Queries) ReadKalanCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const readKalanSearchCount = `-- name: ReadKalanSearchCount :one
-SELECT COUNT(*)
-FROM kalan
-WHERE (
-        ? = True
-        AND entry LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND pos LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND gloss LIKE CONCAT('%', ?, '%')
-    )
-    OR (
-        ? = True
-        AND notes LIKE CONCAT('%', ?, '%')
-    )
+const readRelationByID = `-- name: ReadRelationByID :one
+SELECT id, kalan_id, related_id, kind FROM kalan_relations WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) ReadRelationByID(ctx context.Context, id int32) (KalanRelation, error) {
+	row := q.db.QueryRowContext(ctx, readRelationByID, id)
+	var i KalanRelation
+	err := row.Scan(
+		&i.ID,
+		&i.KalanID,
+		&i.RelatedID,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const readRelationByPair = `-- name: ReadRelationByPair :one
+SELECT id, kalan_id, related_id, kind
+FROM kalan_relations
+WHERE
+    kalan_id = ?
+    AND related_id = ?
+    AND kind = ?
+LIMIT 1
 `
 
-type ReadKalanSearchCountParams struct {
-	Isentry interface{}
-	Search  interface{}
-	Ispos   interface{}
-	Isgloss interface{}
-	Isnotes interface{}
+type ReadRelationByPairParams struct {
+	KalanID   int32
+	RelatedID int32
+	Kind      string
 }
 
-func (q *Queries) ReadKalanSearchCount(ctx context.Context, arg ReadKalanSearchCountParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, readKalanSearchCount,
-		arg.Isentry,
-		arg.Search,
-		arg.Ispos,
-		arg.Search,
-		arg.Isgloss,
-		arg.Search,
-		arg.Isnotes,
-		arg.Search,
+func (q *Queries) ReadRelationByPair(ctx context.Context, arg ReadRelationByPairParams) (KalanRelation, error) {
+	row := q.db.QueryRowContext(ctx, readRelationByPair, arg.KalanID, arg.RelatedID, arg.Kind)
+	var i KalanRelation
+	err := row.Scan(
+		&i.ID,
+		&i.KalanID,
+		&i.RelatedID,
+		&i.Kind,
 	)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+	return i, err
+}
+
+const readRelations = `-- name: ReadRelations :many
+SELECT id, kalan_id, related_id, kind FROM kalan_relations ORDER BY id
+`
+
+func (q *Queries) ReadRelations(ctx context.Context) ([]KalanRelation, error) {
+	rows, err := q.db.QueryContext(ctx, readRelations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KalanRelation
+	for rows.Next() {
+		var i KalanRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.KalanID,
+			&i.RelatedID,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readRelationsByKalanIDs = `-- name: ReadRelationsByKalanIDs :many
+SELECT id, kalan_id, related_id, kind
+FROM kalan_relations
+WHERE
+    kalan_id IN (/*SLICE:kalan_ids*/?)
+    OR related_id IN (/*SLICE:related_ids*/?)
+ORDER BY id
+`
+
+type ReadRelationsByKalanIDsParams struct {
+	KalanIds   []int32
+	RelatedIds []int32
+}
+
+func (q *Queries) ReadRelationsByKalanIDs(ctx context.Context, arg ReadRelationsByKalanIDsParams) ([]KalanRelation, error) {
+	query := readRelationsByKalanIDs
+	var queryParams []interface{}
+	if len(arg.KalanIds) > 0 {
+		for _, v := range arg.KalanIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:kalan_ids*/?", strings.Repeat(",?", len(arg.KalanIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:kalan_ids*/?", "NULL", 1)
+	}
+	if len(arg.RelatedIds) > 0 {
+		for _, v := range arg.RelatedIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:related_ids*/?", strings.Repeat(",?", len(arg.RelatedIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:related_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KalanRelation
+	for rows.Next() {
+		var i KalanRelation
+		if err := rows.Scan(
+			&i.ID,
+			&i.KalanID,
+			&i.RelatedID,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readSenses = `-- name: ReadSenses :many
+SELECT id, kalan_id, position, gloss, definition, usage_label, register FROM kalan_senses ORDER BY kalan_id, position
+`
+
+func (q *Queries) ReadSenses(ctx context.Context) ([]KalanSense, error) {
+	rows, err := q.db.QueryContext(ctx, readSenses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KalanSense
+	for rows.Next() {
+		var i KalanSense
+		if err := rows.Scan(
+			&i.ID,
+			&i.KalanID,
+			&i.Position,
+			&i.Gloss,
+			&i.Definition,
+			&i.UsageLabel,
+			&i.Register,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readSensesByKalanID = `-- name: ReadSensesByKalanID :many
+SELECT id, kalan_id, position, gloss, definition, usage_label, register FROM kalan_senses WHERE kalan_id = ? ORDER BY position
+`
+
+func (q *Queries) ReadSensesByKalanID(ctx context.Context, kalanID int32) ([]KalanSense, error) {
+	rows, err := q.db.QueryContext(ctx, readSensesByKalanID, kalanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KalanSense
+	for rows.Next() {
+		var i KalanSense
+		if err := rows.Scan(
+			&i.ID,
+			&i.KalanID,
+			&i.Position,
+			&i.Gloss,
+			&i.Definition,
+			&i.UsageLabel,
+			&i.Register,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readSensesByKalanIDs = `-- name: ReadSensesByKalanIDs :many
+SELECT id, kalan_id, position, gloss, definition, usage_label, register
+FROM kalan_senses
+WHERE
+    kalan_id IN (/*SLICE:kalan_ids*/?)
+ORDER BY kalan_id, position
+`
+
+func (q *Queries) ReadSensesByKalanIDs(ctx context.Context, kalanIds []int32) ([]KalanSense, error) {
+	query := readSensesByKalanIDs
+	var queryParams []interface{}
+	if len(kalanIds) > 0 {
+		for _, v := range kalanIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:kalan_ids*/?", strings.Repeat(",?", len(kalanIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:kalan_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KalanSense
+	for rows.Next() {
+		var i KalanSense
+		if err := rows.Scan(
+			&i.ID,
+			&i.KalanID,
+			&i.Position,
+			&i.Gloss,
+			&i.Definition,
+			&i.UsageLabel,
+			&i.Register,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const updateKalan = `-- name: UpdateKalan :execresult
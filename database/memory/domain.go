@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+
+	"wilin.info/api/database/domain"
+)
+
+type domainQueries struct {
+	*data
+}
+
+func (q domainQueries) CreateDomain(ctx context.Context, arg domain.CreateDomainParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	t.lastDomainID++
+	t.domains[t.lastDomainID] = domain.Domain{
+		ID:       t.lastDomainID,
+		ParentID: arg.ParentID,
+		Code:     arg.Code,
+		Name:     arg.Name,
+	}
+	return result{lastInsertID: int64(t.lastDomainID), rowsAffected: 1}, nil
+}
+
+func (q domainQueries) CreateKalanDomain(ctx context.Context, arg domain.CreateKalanDomainParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	link := domain.KalanDomain{KalanID: arg.KalanID, DomainID: arg.DomainID}
+	if t.kalanDomains[link] {
+		return nil, ErrDuplicateKey
+	}
+
+	t.kalanDomains[link] = true
+	return result{rowsAffected: 1}, nil
+}
+
+func (q domainQueries) DeleteDomain(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.domains[id]
+	if !ok {
+		return result{}, nil
+	}
+	delete(t.domains, id)
+
+	// kalan_domains.domain_id is ON DELETE CASCADE
+	for link := range t.kalanDomains {
+		if link.DomainID == id {
+			delete(t.kalanDomains, link)
+		}
+	}
+
+	return result{rowsAffected: 1}, nil
+}
+
+func (q domainQueries) DeleteKalanDomain(ctx context.Context, arg domain.DeleteKalanDomainParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	link := domain.KalanDomain{KalanID: arg.KalanID, DomainID: arg.DomainID}
+	if !t.kalanDomains[link] {
+		return result{}, nil
+	}
+
+	delete(t.kalanDomains, link)
+	return result{rowsAffected: 1}, nil
+}
+
+func (q domainQueries) ReadDomainByID(ctx context.Context, id int32) (domain.Domain, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return domain.Domain{}, err
+	}
+	defer q.unlock()
+
+	d, ok := t.domains[id]
+	if !ok {
+		return domain.Domain{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+func (q domainQueries) ReadDomains(ctx context.Context) ([]domain.Domain, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	return sortedValues(t.domains, compareDomainID), nil
+}
+
+func (q domainQueries) ReadDomainsByKalanID(ctx context.Context, kalanID int32) ([]domain.Domain, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []domain.Domain
+	for _, d := range sortedValues(t.domains, compareDomainID) {
+		if t.kalanDomains[domain.KalanDomain{KalanID: kalanID, DomainID: d.ID}] {
+			items = append(items, d)
+		}
+	}
+	return items, nil
+}
+
+func (q domainQueries) ReadKalanDomains(ctx context.Context) ([]domain.KalanDomain, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	links := slices.Collect(maps.Keys(t.kalanDomains))
+	slices.SortFunc(links, compareKalanDomain)
+	return links, nil
+}
+
+func (q domainQueries) UpdateDomain(ctx context.Context, arg domain.UpdateDomainParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.domains[arg.ID]
+	if !ok {
+		return result{}, nil
+	}
+
+	t.domains[arg.ID] = domain.Domain{
+		ID:       arg.ID,
+		ParentID: arg.ParentID,
+		Code:     arg.Code,
+		Name:     arg.Name,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func compareDomainID(a domain.Domain, b domain.Domain) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// compareKalanDomain orders links by domain and then by kalan
+func compareKalanDomain(a domain.KalanDomain, b domain.KalanDomain) int {
+	return cmp.Or(
+		cmp.Compare(a.DomainID, b.DomainID),
+		cmp.Compare(a.KalanID, b.KalanID),
+	)
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+
+	"wilin.info/api/database/example"
+)
+
+type exampleQueries struct {
+	*data
+}
+
+func (q exampleQueries) CreateExample(ctx context.Context, arg example.CreateExampleParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	t.lastExampleID++
+	t.examples[t.lastExampleID] = example.Example{
+		ID:          t.lastExampleID,
+		Sentence:    arg.Sentence,
+		Gloss:       arg.Gloss,
+		Translation: arg.Translation,
+	}
+	return result{lastInsertID: int64(t.lastExampleID), rowsAffected: 1}, nil
+}
+
+func (q exampleQueries) CreateKalanExample(ctx context.Context, arg example.CreateKalanExampleParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	link := example.KalanExample{KalanID: arg.KalanID, ExampleID: arg.ExampleID}
+	if t.kalanExamples[link] {
+		return nil, ErrDuplicateKey
+	}
+
+	t.kalanExamples[link] = true
+	return result{rowsAffected: 1}, nil
+}
+
+func (q exampleQueries) DeleteExample(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.examples[id]
+	if !ok {
+		return result{}, nil
+	}
+	delete(t.examples, id)
+
+	// kalan_examples.example_id is ON DELETE CASCADE
+	for link := range t.kalanExamples {
+		if link.ExampleID == id {
+			delete(t.kalanExamples, link)
+		}
+	}
+
+	return result{rowsAffected: 1}, nil
+}
+
+func (q exampleQueries) DeleteKalanExamplesByExampleID(ctx context.Context, exampleID int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var count int64
+	for link := range t.kalanExamples {
+		if link.ExampleID == exampleID {
+			delete(t.kalanExamples, link)
+			count++
+		}
+	}
+	return result{rowsAffected: count}, nil
+}
+
+func (q exampleQueries) ReadExampleByID(ctx context.Context, id int32) (example.Example, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return example.Example{}, err
+	}
+	defer q.unlock()
+
+	e, ok := t.examples[id]
+	if !ok {
+		return example.Example{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (q exampleQueries) ReadExamples(ctx context.Context) ([]example.Example, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	return sortedValues(t.examples, compareExampleID), nil
+}
+
+func (q exampleQueries) ReadExamplesByKalanID(ctx context.Context, kalanID int32) ([]example.Example, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []example.Example
+	for _, e := range sortedValues(t.examples, compareExampleID) {
+		if t.kalanExamples[example.KalanExample{KalanID: kalanID, ExampleID: e.ID}] {
+			items = append(items, e)
+		}
+	}
+	return items, nil
+}
+
+func (q exampleQueries) ReadKalanExamples(ctx context.Context) ([]example.KalanExample, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	links := slices.Collect(maps.Keys(t.kalanExamples))
+	slices.SortFunc(links, compareKalanExample)
+	return links, nil
+}
+
+func (q exampleQueries) ReadKalanIDsByExampleID(ctx context.Context, exampleID int32) ([]int32, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []int32
+	for link := range t.kalanExamples {
+		if link.ExampleID == exampleID {
+			items = append(items, link.KalanID)
+		}
+	}
+	slices.Sort(items)
+	return items, nil
+}
+
+func (q exampleQueries) UpdateExample(ctx context.Context, arg example.UpdateExampleParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.examples[arg.ID]
+	if !ok {
+		return result{}, nil
+	}
+
+	t.examples[arg.ID] = example.Example{
+		ID:          arg.ID,
+		Sentence:    arg.Sentence,
+		Gloss:       arg.Gloss,
+		Translation: arg.Translation,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func compareExampleID(a example.Example, b example.Example) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// compareKalanExample orders links by example and then by kalan
+func compareKalanExample(a example.KalanExample, b example.KalanExample) int {
+	return cmp.Or(
+		cmp.Compare(a.ExampleID, b.ExampleID),
+		cmp.Compare(a.KalanID, b.KalanID),
+	)
+}
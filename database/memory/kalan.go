@@ -0,0 +1,386 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	"wilin.info/api/database/kalan"
+)
+
+type kalanQueries struct {
+	*data
+}
+
+func (q kalanQueries) CreateKalan(ctx context.Context, arg kalan.CreateKalanParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	t.lastKalanID++
+	t.kalan[t.lastKalanID] = kalan.Kalan{
+		ID:    t.lastKalanID,
+		Entry: arg.Entry,
+		Pos:   arg.Pos,
+		Gloss: arg.Gloss,
+		Notes: arg.Notes,
+	}
+	return result{lastInsertID: int64(t.lastKalanID), rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) CreateKalanWithID(ctx context.Context, arg kalan.CreateKalanWithIDParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.kalan[arg.ID]
+	if ok {
+		return nil, ErrDuplicateKey
+	}
+
+	t.kalan[arg.ID] = kalan.Kalan{
+		ID:    arg.ID,
+		Entry: arg.Entry,
+		Pos:   arg.Pos,
+		Gloss: arg.Gloss,
+		Notes: arg.Notes,
+	}
+	t.lastKalanID = max(t.lastKalanID, arg.ID)
+	return result{lastInsertID: int64(arg.ID), rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) DeleteKalan(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.kalan[id]
+	if !ok {
+		return result{}, nil
+	}
+	delete(t.kalan, id)
+
+	// kalan_senses.kalan_id is ON DELETE CASCADE
+	for senseID, sense := range t.senses {
+		if sense.KalanID == id {
+			delete(t.senses, senseID)
+		}
+	}
+
+	// kalan_relations.kalan_id and related_id are ON DELETE CASCADE
+	for relationID, relation := range t.relations {
+		if relation.KalanID == id || relation.RelatedID == id {
+			delete(t.relations, relationID)
+		}
+	}
+
+	// kalan_examples.kalan_id is ON DELETE CASCADE
+	for link := range t.kalanExamples {
+		if link.KalanID == id {
+			delete(t.kalanExamples, link)
+		}
+	}
+
+	// kalan_domains.kalan_id is ON DELETE CASCADE
+	for link := range t.kalanDomains {
+		if link.KalanID == id {
+			delete(t.kalanDomains, link)
+		}
+	}
+
+	// proposals.kalan_id is ON DELETE SET NULL
+	for propID, p := range t.proposals {
+		if p.KalanID.Valid && p.KalanID.Int32 == id {
+			p.KalanID = sql.NullInt32{}
+			t.proposals[propID] = p
+		}
+	}
+
+	return result{rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) ReadKalan(ctx context.Context) ([]kalan.Kalan, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	return sortedValues(t.kalan, compareKalanID), nil
+}
+
+func (q kalanQueries) ReadKalanByEntry(ctx context.Context, entry string) (kalan.Kalan, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return kalan.Kalan{}, err
+	}
+	defer q.unlock()
+
+	for _, k := range sortedValues(t.kalan, compareKalanID) {
+		if compareFold(k.Entry, entry) == 0 {
+			return k, nil
+		}
+	}
+	return kalan.Kalan{}, sql.ErrNoRows
+}
+
+func (q kalanQueries) ReadKalanById(ctx context.Context, id int32) (kalan.Kalan, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return kalan.Kalan{}, err
+	}
+	defer q.unlock()
+
+	k, ok := t.kalan[id]
+	if !ok {
+		return kalan.Kalan{}, sql.ErrNoRows
+	}
+	return k, nil
+}
+
+func (q kalanQueries) ReadKalanByIDs(ctx context.Context, ids []int32) ([]kalan.Kalan, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	kalans := []kalan.Kalan{}
+	for _, k := range sortedValues(t.kalan, compareKalanID) {
+		if slices.Contains(ids, k.ID) {
+			kalans = append(kalans, k)
+		}
+	}
+	return kalans, nil
+}
+
+func (q kalanQueries) ReadKalanCount(ctx context.Context) (int64, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer q.unlock()
+
+	return int64(len(t.kalan)), nil
+}
+
+func (q kalanQueries) UpdateKalan(ctx context.Context, arg kalan.UpdateKalanParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.kalan[arg.ID]
+	if !ok {
+		return result{}, nil
+	}
+
+	t.kalan[arg.ID] = kalan.Kalan{
+		ID:    arg.ID,
+		Entry: arg.Entry,
+		Pos:   arg.Pos,
+		Gloss: arg.Gloss,
+		Notes: arg.Notes,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) CreateSense(ctx context.Context, arg kalan.CreateSenseParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	// kalan_id and position are unique together
+	for _, sense := range t.senses {
+		if sense.KalanID == arg.KalanID && sense.Position == arg.Position {
+			return nil, ErrDuplicateKey
+		}
+	}
+
+	t.lastSenseID++
+	t.senses[t.lastSenseID] = kalan.KalanSense{
+		ID:         t.lastSenseID,
+		KalanID:    arg.KalanID,
+		Position:   arg.Position,
+		Gloss:      arg.Gloss,
+		Definition: arg.Definition,
+		UsageLabel: arg.UsageLabel,
+		Register:   arg.Register,
+	}
+	return result{lastInsertID: int64(t.lastSenseID), rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) ReadSenses(ctx context.Context) ([]kalan.KalanSense, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	return sortedValues(t.senses, compareSensePosition), nil
+}
+
+func (q kalanQueries) ReadSensesByKalanID(ctx context.Context, kalanID int32) ([]kalan.KalanSense, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	senses := []kalan.KalanSense{}
+	for _, sense := range sortedValues(t.senses, compareSensePosition) {
+		if sense.KalanID == kalanID {
+			senses = append(senses, sense)
+		}
+	}
+	return senses, nil
+}
+
+func (q kalanQueries) ReadSensesByKalanIDs(ctx context.Context, kalanIds []int32) ([]kalan.KalanSense, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	senses := []kalan.KalanSense{}
+	for _, sense := range sortedValues(t.senses, compareSensePosition) {
+		if slices.Contains(kalanIds, sense.KalanID) {
+			senses = append(senses, sense)
+		}
+	}
+	return senses, nil
+}
+
+func (q kalanQueries) DeleteSensesByKalanID(ctx context.Context, kalanID int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var rowsAffected int64
+	for senseID, sense := range t.senses {
+		if sense.KalanID == kalanID {
+			delete(t.senses, senseID)
+			rowsAffected++
+		}
+	}
+	return result{rowsAffected: rowsAffected}, nil
+}
+
+func (q kalanQueries) CreateRelation(ctx context.Context, arg kalan.CreateRelationParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	// kalan_id, related_id and kind are unique together
+	for _, relation := range t.relations {
+		if relation.KalanID == arg.KalanID && relation.RelatedID == arg.RelatedID && relation.Kind == arg.Kind {
+			return nil, ErrDuplicateKey
+		}
+	}
+
+	t.lastRelationID++
+	t.relations[t.lastRelationID] = kalan.KalanRelation{
+		ID:        t.lastRelationID,
+		KalanID:   arg.KalanID,
+		RelatedID: arg.RelatedID,
+		Kind:      arg.Kind,
+	}
+	return result{lastInsertID: int64(t.lastRelationID), rowsAffected: 1}, nil
+}
+
+func (q kalanQueries) ReadRelations(ctx context.Context) ([]kalan.KalanRelation, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	return sortedValues(t.relations, compareRelationID), nil
+}
+
+func (q kalanQueries) ReadRelationsByKalanIDs(ctx context.Context, arg kalan.ReadRelationsByKalanIDsParams) ([]kalan.KalanRelation, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	relations := []kalan.KalanRelation{}
+	for _, relation := range sortedValues(t.relations, compareRelationID) {
+		if slices.Contains(arg.KalanIds, relation.KalanID) || slices.Contains(arg.RelatedIds, relation.RelatedID) {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+func (q kalanQueries) ReadRelationByID(ctx context.Context, id int32) (kalan.KalanRelation, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return kalan.KalanRelation{}, err
+	}
+	defer q.unlock()
+
+	relation, ok := t.relations[id]
+	if !ok {
+		return kalan.KalanRelation{}, sql.ErrNoRows
+	}
+	return relation, nil
+}
+
+func (q kalanQueries) ReadRelationByPair(ctx context.Context, arg kalan.ReadRelationByPairParams) (kalan.KalanRelation, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return kalan.KalanRelation{}, err
+	}
+	defer q.unlock()
+
+	for _, relation := range t.relations {
+		if relation.KalanID == arg.KalanID && relation.RelatedID == arg.RelatedID && relation.Kind == arg.Kind {
+			return relation, nil
+		}
+	}
+	return kalan.KalanRelation{}, sql.ErrNoRows
+}
+
+func (q kalanQueries) DeleteRelation(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.relations[id]
+	if !ok {
+		return result{}, nil
+	}
+	delete(t.relations, id)
+	return result{rowsAffected: 1}, nil
+}
+
+func compareKalanID(a kalan.Kalan, b kalan.Kalan) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func compareSensePosition(a kalan.KalanSense, b kalan.KalanSense) int {
+	return cmp.Or(cmp.Compare(a.KalanID, b.KalanID), cmp.Compare(a.Position, b.Position))
+}
+
+func compareRelationID(a kalan.KalanRelation, b kalan.KalanRelation) int {
+	return cmp.Compare(a.ID, b.ID)
+}
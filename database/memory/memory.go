@@ -0,0 +1,231 @@
+// Package memory implements database.Store without a database server.
+// It follows the behaviour of the MySQL queries closely enough to run
+// the api against in tests
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/domain"
+	"wilin.info/api/database/example"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/database/proposal"
+	"wilin.info/api/database/recovery"
+	"wilin.info/api/database/revision"
+	"wilin.info/api/database/session"
+	"wilin.info/api/database/users"
+	"wilin.info/api/database/verification"
+)
+
+var ErrDuplicateKey = database.ErrDuplicateKey
+
+type tables struct {
+	kalan         map[int32]kalan.Kalan
+	senses        map[int32]kalan.KalanSense
+	relations     map[int32]kalan.KalanRelation
+	users         map[int32]users.User
+	proposals     map[int32]proposal.Proposal
+	recoveries    map[string]recovery.Recovery
+	revisions     map[int32]revision.KalanRevision
+	sessions      map[string]session.Session
+	refreshTokens map[string]session.RefreshToken
+	verifications map[string]verification.Verification
+	examples      map[int32]example.Example
+	kalanExamples map[example.KalanExample]bool
+	domains       map[int32]domain.Domain
+	kalanDomains  map[domain.KalanDomain]bool
+
+	lastKalanID    int32
+	lastSenseID    int32
+	lastRelationID int32
+	lastUserID     int32
+	lastProposalID int32
+	lastRevisionID int32
+	lastExampleID  int32
+	lastDomainID   int32
+}
+
+func newTables() tables {
+	return tables{
+		kalan:         map[int32]kalan.Kalan{},
+		senses:        map[int32]kalan.KalanSense{},
+		relations:     map[int32]kalan.KalanRelation{},
+		users:         map[int32]users.User{},
+		proposals:     map[int32]proposal.Proposal{},
+		recoveries:    map[string]recovery.Recovery{},
+		revisions:     map[int32]revision.KalanRevision{},
+		sessions:      map[string]session.Session{},
+		refreshTokens: map[string]session.RefreshToken{},
+		verifications: map[string]verification.Verification{},
+		examples:      map[int32]example.Example{},
+		kalanExamples: map[example.KalanExample]bool{},
+		domains:       map[int32]domain.Domain{},
+		kalanDomains:  map[domain.KalanDomain]bool{},
+	}
+}
+
+func (t *tables) clone() tables {
+	c := *t
+	c.kalan = maps.Clone(t.kalan)
+	c.senses = maps.Clone(t.senses)
+	c.relations = maps.Clone(t.relations)
+	c.users = maps.Clone(t.users)
+	c.proposals = maps.Clone(t.proposals)
+	c.recoveries = maps.Clone(t.recoveries)
+	c.revisions = maps.Clone(t.revisions)
+	c.sessions = maps.Clone(t.sessions)
+	c.refreshTokens = maps.Clone(t.refreshTokens)
+	c.verifications = maps.Clone(t.verifications)
+	c.examples = maps.Clone(t.examples)
+	c.kalanExamples = maps.Clone(t.kalanExamples)
+	c.domains = maps.Clone(t.domains)
+	c.kalanDomains = maps.Clone(t.kalanDomains)
+	return c
+}
+
+type data struct {
+	mu     sync.Mutex
+	txMu   sync.Mutex
+	tables tables
+}
+
+// lock waits for the tables to be free. It fails instead if
+// ctx is done, the same way a query on a dropped connection does
+func (d *data) lock(ctx context.Context) (*tables, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	return &d.tables, nil
+}
+
+func (d *data) unlock() {
+	d.mu.Unlock()
+}
+
+// Store keeps every table in memory. Transactions are run one at a
+// time and are rolled back by restoring the tables as they were when
+// the transaction began
+type Store struct {
+	data *data
+	inTx bool
+}
+
+var _ database.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{data: &data{tables: newTables()}}
+}
+
+func (s *Store) Kalan() kalan.Querier {
+	return kalanQueries{s.data}
+}
+
+func (s *Store) Users() users.Querier {
+	return userQueries{s.data}
+}
+
+func (s *Store) Proposal() proposal.Querier {
+	return proposalQueries{s.data}
+}
+
+func (s *Store) Recovery() recovery.Querier {
+	return recoveryQueries{s.data}
+}
+
+func (s *Store) Revision() revision.Querier {
+	return revisionQueries{s.data}
+}
+
+func (s *Store) Session() session.Querier {
+	return sessionQueries{s.data}
+}
+
+func (s *Store) Verification() verification.Querier {
+	return verificationQueries{s.data}
+}
+
+func (s *Store) Example() example.Querier {
+	return exampleQueries{s.data}
+}
+
+func (s *Store) Domain() domain.Querier {
+	return domainQueries{s.data}
+}
+
+func (s *Store) ReadKalanIDsByFilter(ctx context.Context, filter database.KalanFilter) ([]int32, error) {
+	t, err := s.data.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.data.unlock()
+
+	var ids []int32
+	for _, k := range sortedValues(t.kalan, compareKalanID) {
+		if filter.Match(k) {
+			ids = append(ids, k.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Store) InTx(ctx context.Context, fn func(tx database.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.data.txMu.Lock()
+	defer s.data.txMu.Unlock()
+
+	t, err := s.data.lock(ctx)
+	if err != nil {
+		return err
+	}
+	snapshot := t.clone()
+	s.data.unlock()
+
+	err = fn(&Store{data: s.data, inTx: true})
+	if err != nil {
+		s.data.mu.Lock()
+		s.data.tables = snapshot
+		s.data.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// result is the sql.Result of an insert, update or delete
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// sortedValues returns the rows of a table ordered by compare
+func sortedValues[K comparable, V any](table map[K]V, compare func(a V, b V) int) []V {
+	values := slices.Collect(maps.Values(table))
+	slices.SortFunc(values, compare)
+	return values
+}
+
+// compareFold orders strings the way the case insensitive
+// collation of the database does
+func compareFold(a string, b string) int {
+	return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+
+	"wilin.info/api/database/proposal"
+)
+
+type proposalQueries struct {
+	*data
+}
+
+// withUsername joins a proposal with the user who made it.
+// Proposals of deleted users are left out like in the JOIN
+func withUsername(t *tables, p proposal.Proposal) (proposal.ReadProposalByIDWithUsernameRow, bool) {
+	u, ok := t.users[p.UserID.Int32]
+	if !p.UserID.Valid || !ok {
+		return proposal.ReadProposalByIDWithUsernameRow{}, false
+	}
+
+	return proposal.ReadProposalByIDWithUsernameRow{
+		ID:       p.ID,
+		UserID:   p.UserID,
+		Username: u.Username,
+		Entry:    p.Entry,
+		Pos:      p.Pos,
+		Gloss:    p.Gloss,
+		Notes:    p.Notes,
+		Status:   p.Status,
+		Reason:   p.Reason,
+		Kind:     p.Kind,
+		KalanID:  p.KalanID,
+	}, true
+}
+
+func compareProposalID(a proposal.Proposal, b proposal.Proposal) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func (q proposalQueries) CreateProposal(ctx context.Context, arg proposal.CreateProposalParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	t.lastProposalID++
+	t.proposals[t.lastProposalID] = proposal.Proposal{
+		ID:      t.lastProposalID,
+		UserID:  arg.UserID,
+		Entry:   arg.Entry,
+		Pos:     arg.Pos,
+		Gloss:   arg.Gloss,
+		Notes:   arg.Notes,
+		Status:  "pending",
+		Kind:    arg.Kind,
+		KalanID: arg.KalanID,
+	}
+	return result{lastInsertID: int64(t.lastProposalID), rowsAffected: 1}, nil
+}
+
+func (q proposalQueries) Delete(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.proposals[id]
+	if !ok {
+		return result{}, nil
+	}
+
+	delete(t.proposals, id)
+	return result{rowsAffected: 1}, nil
+}
+
+func (q proposalQueries) ReadAllProposalsWithUsername(ctx context.Context) ([]proposal.ReadAllProposalsWithUsernameRow, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []proposal.ReadAllProposalsWithUsernameRow
+	for _, p := range sortedValues(t.proposals, compareProposalID) {
+		row, ok := withUsername(t, p)
+		if ok {
+			items = append(items, proposal.ReadAllProposalsWithUsernameRow(row))
+		}
+	}
+	return items, nil
+}
+
+func (q proposalQueries) ReadProposalByIDWithUsername(ctx context.Context, id int32) (proposal.ReadProposalByIDWithUsernameRow, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return proposal.ReadProposalByIDWithUsernameRow{}, err
+	}
+	defer q.unlock()
+
+	row, ok := withUsername(t, t.proposals[id])
+	if !ok {
+		return proposal.ReadProposalByIDWithUsernameRow{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (q proposalQueries) ReadProposalsByUserIDWithUsername(ctx context.Context, id int32) ([]proposal.ReadProposalsByUserIDWithUsernameRow, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []proposal.ReadProposalsByUserIDWithUsernameRow
+	for _, p := range sortedValues(t.proposals, compareProposalID) {
+		row, ok := withUsername(t, p)
+		if ok && row.UserID.Int32 == id {
+			items = append(items, proposal.ReadProposalsByUserIDWithUsernameRow(row))
+		}
+	}
+	return items, nil
+}
+
+func (q proposalQueries) Update(ctx context.Context, arg proposal.UpdateParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	p, ok := t.proposals[arg.ID]
+	if !ok || p.Status != "pending" {
+		return result{}, nil
+	}
+
+	p.UserID = arg.UserID
+	p.Entry = arg.Entry
+	p.Pos = arg.Pos
+	p.Gloss = arg.Gloss
+	p.Notes = arg.Notes
+	t.proposals[arg.ID] = p
+	return result{rowsAffected: 1}, nil
+}
+
+func (q proposalQueries) UpdateStatus(ctx context.Context, arg proposal.UpdateStatusParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	p, ok := t.proposals[arg.ID]
+	if !ok || p.Status != "pending" {
+		return result{}, nil
+	}
+
+	p.Status = arg.Status
+	p.Reason = arg.Reason
+	t.proposals[arg.ID] = p
+	return result{rowsAffected: 1}, nil
+}
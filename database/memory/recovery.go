@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"wilin.info/api/database/recovery"
+)
+
+type recoveryQueries struct {
+	*data
+}
+
+func (q recoveryQueries) Create(ctx context.Context, arg recovery.CreateParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.recoveries[arg.ID]
+	if ok {
+		return nil, ErrDuplicateKey
+	}
+
+	t.recoveries[arg.ID] = recovery.Recovery{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		ExpiredAt: arg.ExpiredAt,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func (q recoveryQueries) DeleteByID(ctx context.Context, id string) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.recoveries[id]
+	if !ok {
+		return result{}, nil
+	}
+
+	delete(t.recoveries, id)
+	return result{rowsAffected: 1}, nil
+}
+
+func (q recoveryQueries) ReadByID(ctx context.Context, id string) (recovery.Recovery, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return recovery.Recovery{}, err
+	}
+	defer q.unlock()
+
+	r, ok := t.recoveries[id]
+	if !ok {
+		return recovery.Recovery{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (q recoveryQueries) ReadByUserID(ctx context.Context, userID int32) ([]recovery.Recovery, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []recovery.Recovery
+	for _, r := range t.recoveries {
+		if r.UserID == userID {
+			items = append(items, r)
+		}
+	}
+	return items, nil
+}
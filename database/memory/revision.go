@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"time"
+
+	"wilin.info/api/database/revision"
+)
+
+type revisionQueries struct {
+	*data
+}
+
+// compareRevisionIDDesc orders the newest revisions first
+func compareRevisionIDDesc(a revision.KalanRevision, b revision.KalanRevision) int {
+	return cmp.Compare(b.ID, a.ID)
+}
+
+func (q revisionQueries) CreateRevision(ctx context.Context, arg revision.CreateRevisionParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	t.lastRevisionID++
+	t.revisions[t.lastRevisionID] = revision.KalanRevision{
+		ID:        t.lastRevisionID,
+		KalanID:   arg.KalanID,
+		Action:    arg.Action,
+		Entry:     arg.Entry,
+		Pos:       arg.Pos,
+		Gloss:     arg.Gloss,
+		Notes:     arg.Notes,
+		UserID:    arg.UserID,
+		CreatedAt: time.Now(),
+		Senses:    arg.Senses,
+		Links:     arg.Links,
+	}
+	return result{lastInsertID: int64(t.lastRevisionID), rowsAffected: 1}, nil
+}
+
+func (q revisionQueries) ReadLatestRevisionByKalanID(ctx context.Context, kalanID int32) (revision.KalanRevision, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return revision.KalanRevision{}, err
+	}
+	defer q.unlock()
+
+	for _, rev := range sortedValues(t.revisions, compareRevisionIDDesc) {
+		if rev.KalanID == kalanID {
+			return rev, nil
+		}
+	}
+	return revision.KalanRevision{}, sql.ErrNoRows
+}
+
+func (q revisionQueries) ReadRevisionByID(ctx context.Context, id int32) (revision.KalanRevision, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return revision.KalanRevision{}, err
+	}
+	defer q.unlock()
+
+	rev, ok := t.revisions[id]
+	if !ok {
+		return revision.KalanRevision{}, sql.ErrNoRows
+	}
+	return rev, nil
+}
+
+func (q revisionQueries) ReadRevisionsByKalanID(ctx context.Context, kalanID int32) ([]revision.KalanRevision, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var items []revision.KalanRevision
+	for _, rev := range sortedValues(t.revisions, compareRevisionIDDesc) {
+		if rev.KalanID == kalanID {
+			items = append(items, rev)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"wilin.info/api/database/session"
+)
+
+type sessionQueries struct {
+	*data
+}
+
+func (q sessionQueries) CreateRefreshToken(ctx context.Context, arg session.CreateRefreshTokenParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.refreshTokens[arg.ID]
+	if ok {
+		return nil, ErrDuplicateKey
+	}
+
+	t.refreshTokens[arg.ID] = session.RefreshToken{
+		ID:        arg.ID,
+		SessionID: arg.SessionID,
+		ExpiredAt: arg.ExpiredAt,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func (q sessionQueries) CreateSession(ctx context.Context, arg session.CreateSessionParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.sessions[arg.ID]
+	if ok {
+		return nil, ErrDuplicateKey
+	}
+
+	now := time.Now()
+	t.sessions[arg.ID] = session.Session{
+		ID:         arg.ID,
+		UserID:     arg.UserID,
+		UserAgent:  arg.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiredAt:  arg.ExpiredAt,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func (q sessionQueries) ReadActiveSessionsByUserID(ctx context.Context, userID int32) ([]session.Session, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	sessions := sortedValues(t.sessions, func(a session.Session, b session.Session) int {
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+
+	var items []session.Session
+	for _, s := range sessions {
+		if s.UserID == userID && !s.Revoked {
+			items = append(items, s)
+		}
+	}
+	return items, nil
+}
+
+func (q sessionQueries) ReadRefreshTokenByID(ctx context.Context, id string) (session.RefreshToken, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return session.RefreshToken{}, err
+	}
+	defer q.unlock()
+
+	token, ok := t.refreshTokens[id]
+	if !ok {
+		return session.RefreshToken{}, sql.ErrNoRows
+	}
+	return token, nil
+}
+
+func (q sessionQueries) ReadSessionByID(ctx context.Context, id string) (session.Session, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return session.Session{}, err
+	}
+	defer q.unlock()
+
+	s, ok := t.sessions[id]
+	if !ok {
+		return session.Session{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+func (q sessionQueries) RevokeSession(ctx context.Context, id string) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	s, ok := t.sessions[id]
+	if !ok {
+		return result{}, nil
+	}
+
+	s.Revoked = true
+	t.sessions[id] = s
+	return result{rowsAffected: 1}, nil
+}
+
+func (q sessionQueries) RevokeSessionsByUserID(ctx context.Context, userID int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var rowsAffected int64
+	for id, s := range t.sessions {
+		if s.UserID == userID {
+			s.Revoked = true
+			t.sessions[id] = s
+			rowsAffected++
+		}
+	}
+	return result{rowsAffected: rowsAffected}, nil
+}
+
+func (q sessionQueries) TouchSession(ctx context.Context, arg session.TouchSessionParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	s, ok := t.sessions[arg.ID]
+	if !ok {
+		return result{}, nil
+	}
+
+	s.LastUsedAt = time.Now()
+	s.ExpiredAt = arg.ExpiredAt
+	t.sessions[arg.ID] = s
+	return result{rowsAffected: 1}, nil
+}
+
+func (q sessionQueries) UseRefreshToken(ctx context.Context, id string) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	token, ok := t.refreshTokens[id]
+	if !ok || token.Used {
+		return result{}, nil
+	}
+
+	token.Used = true
+	t.refreshTokens[id] = token
+	return result{rowsAffected: 1}, nil
+}
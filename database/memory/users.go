@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"wilin.info/api/database/users"
+)
+
+type userQueries struct {
+	*data
+}
+
+func (q userQueries) CreateUser(ctx context.Context, arg users.CreateUserParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	for _, u := range t.users {
+		if compareFold(u.Email, arg.Email) == 0 || compareFold(u.Username, arg.Username) == 0 {
+			return nil, ErrDuplicateKey
+		}
+	}
+
+	t.lastUserID++
+	t.users[t.lastUserID] = users.User{
+		ID:       t.lastUserID,
+		Email:    arg.Email,
+		Username: arg.Username,
+		Password: arg.Password,
+		Role:     arg.Role,
+	}
+	return result{lastInsertID: int64(t.lastUserID), rowsAffected: 1}, nil
+}
+
+func (q userQueries) ReadUserByEmail(ctx context.Context, email string) (users.User, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+	defer q.unlock()
+
+	for _, u := range t.users {
+		if compareFold(u.Email, email) == 0 {
+			return u, nil
+		}
+	}
+	return users.User{}, sql.ErrNoRows
+}
+
+func (q userQueries) ReadUserByID(ctx context.Context, id int32) (users.User, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+	defer q.unlock()
+
+	u, ok := t.users[id]
+	if !ok {
+		return users.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (q userQueries) ReadUserByUsername(ctx context.Context, username string) (users.User, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+	defer q.unlock()
+
+	for _, u := range t.users {
+		if compareFold(u.Username, username) == 0 {
+			return u, nil
+		}
+	}
+	return users.User{}, sql.ErrNoRows
+}
+
+func (q userQueries) UpdatePassword(ctx context.Context, arg users.UpdatePasswordParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	u, ok := t.users[arg.ID]
+	if !ok {
+		return result{}, nil
+	}
+
+	u.Password = arg.Password
+	t.users[arg.ID] = u
+	return result{rowsAffected: 1}, nil
+}
+
+func (q userQueries) VerifyUser(ctx context.Context, id int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	u, ok := t.users[id]
+	if !ok {
+		return result{}, nil
+	}
+
+	u.Verified = true
+	t.users[id] = u
+	return result{rowsAffected: 1}, nil
+}
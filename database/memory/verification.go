@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"wilin.info/api/database/verification"
+)
+
+type verificationQueries struct {
+	*data
+}
+
+func (q verificationQueries) Create(ctx context.Context, arg verification.CreateParams) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.verifications[arg.ID]
+	if ok {
+		return nil, ErrDuplicateKey
+	}
+
+	t.verifications[arg.ID] = verification.Verification{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		ExpiredAt: arg.ExpiredAt,
+	}
+	return result{rowsAffected: 1}, nil
+}
+
+func (q verificationQueries) DeleteByID(ctx context.Context, id string) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	_, ok := t.verifications[id]
+	if !ok {
+		return result{}, nil
+	}
+
+	delete(t.verifications, id)
+	return result{rowsAffected: 1}, nil
+}
+
+func (q verificationQueries) DeleteByUserID(ctx context.Context, userID int32) (sql.Result, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer q.unlock()
+
+	var rowsAffected int64
+	for id, v := range t.verifications {
+		if v.UserID == userID {
+			delete(t.verifications, id)
+			rowsAffected++
+		}
+	}
+	return result{rowsAffected: rowsAffected}, nil
+}
+
+func (q verificationQueries) ReadByID(ctx context.Context, id string) (verification.Verification, error) {
+	t, err := q.lock(ctx)
+	if err != nil {
+		return verification.Verification{}, err
+	}
+	defer q.unlock()
+
+	v, ok := t.verifications[id]
+	if !ok {
+		return verification.Verification{}, sql.ErrNoRows
+	}
+	return v, nil
+}
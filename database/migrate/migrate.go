@@ -0,0 +1,351 @@
+// Package migrate keeps the database schema up to date with the
+// migrations embedded in the binary.
+//
+// Every migration is a pair of files in the directory of its SQL
+// dialect named NNNN_description.up.sql and NNNN_description.down.sql,
+// and every dialect has its own copy of each migration. The
+// applied versions are stored in the schema_migrations table along
+// with a checksum of their up file, so that a migration cannot be
+// edited once it has run. Statements are separated by semicolons,
+// which must not appear inside of string literals.
+//
+// The sqlc/*/schema.sql files describe the same tables for code
+// generation and must be kept in step with the migrations.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+const MIGRATIONS_DIR = "migrations"
+
+var (
+	ErrUnknownDialect   = errors.New("no migrations for sql dialect")
+	ErrBadFileName      = errors.New("bad migration file name")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrMissingFile      = errors.New("migration is missing its up or down file")
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	ErrUnknownVersion   = errors.New("applied migration is unknown")
+)
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads the migrations in fsys and returns them ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadFileName, entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadFileName, entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateVersion, version)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %v", ErrMissingFile, m.Version)
+		}
+
+		checksum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(checksum[:])
+		migrations = append(migrations, *m)
+	}
+
+	slices.SortFunc(migrations, func(a Migration, b Migration) int {
+		return a.Version - b.Version
+	})
+
+	return migrations, nil
+}
+
+// Embedded returns the migrations built into the binary for the
+// given dialect, which is either "mysql" or "sqlite"
+func Embedded(dialect string) ([]Migration, error) {
+	dir := path.Join(MIGRATIONS_DIR, dialect)
+
+	_, err := fs.Stat(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownDialect, dialect)
+	}
+
+	fsys, err := fs.Sub(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	return Load(fsys)
+}
+
+// splitStatements splits a migration into the statements it is
+// made of, leaving out comment lines
+func splitStatements(migration string) []string {
+	var lines []string
+	for _, line := range strings.Split(migration, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		statement = strings.TrimSpace(statement)
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New creates a migrator for the embedded migrations of the dialect
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := Embedded(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(db, migrations), nil
+}
+
+func NewWithMigrations(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version int PRIMARY KEY NOT NULL,
+    name varchar(255) NOT NULL,
+    checksum varchar(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	return err
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// applied reads the schema_migrations table and makes sure
+// every migration in it matches the one that is embedded
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	err := m.createTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		err = rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for version, a := range applied {
+		i := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+			return migration.Version == version
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %v_%v", ErrUnknownVersion, version, a.name)
+		}
+		if m.migrations[i].Checksum != a.checksum {
+			return nil, fmt.Errorf("%w: %v_%v", ErrChecksumMismatch, version, a.name)
+		}
+	}
+
+	return applied, nil
+}
+
+// run executes the statements of a migration and records the
+// change to schema_migrations in the same transaction. MySQL
+// commits schema changes right away, so a migration that fails
+// halfway must be cleaned up by hand
+func (m *Migrator) run(ctx context.Context, migration string, record string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(migration) {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, record, args...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Up applies every migration that has not been applied yet and
+// returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		_, ok := applied[migration.Version]
+		if ok {
+			continue
+		}
+
+		err = m.run(
+			ctx,
+			migration.Up,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+			migration.Version,
+			migration.Name,
+			migration.Checksum,
+		)
+		if err != nil {
+			return done, fmt.Errorf("migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps migrations that were applied and
+// returns the ones it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range slices.Backward(m.migrations) {
+		if len(done) >= steps {
+			break
+		}
+
+		_, ok := applied[migration.Version]
+		if !ok {
+			continue
+		}
+
+		err = m.run(
+			ctx,
+			migration.Down,
+			"DELETE FROM schema_migrations WHERE version = ?",
+			migration.Version,
+		)
+		if err != nil {
+			return done, fmt.Errorf("migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status lists every migration along with whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		a, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: a.appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
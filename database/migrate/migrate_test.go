@@ -0,0 +1,218 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/migrate"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+type LoadValue struct {
+	name  string
+	files fstest.MapFS
+	err   error
+}
+
+func TestLoadErrors(t *testing.T) {
+	loadValues := []LoadValue{
+		{
+			"bad file name",
+			fstest.MapFS{"create_users.up.sql": file("CREATE TABLE users (id int);")},
+			migrate.ErrBadFileName,
+		},
+		{
+			"missing down file",
+			fstest.MapFS{"0001_create_users.up.sql": file("CREATE TABLE users (id int);")},
+			migrate.ErrMissingFile,
+		},
+		{
+			"duplicate version",
+			fstest.MapFS{
+				"0001_create_users.up.sql":   file("CREATE TABLE users (id int);"),
+				"0001_create_users.down.sql": file("DROP TABLE users;"),
+				"0001_create_kalan.up.sql":   file("CREATE TABLE kalan (id int);"),
+				"0001_create_kalan.down.sql": file("DROP TABLE kalan;"),
+			},
+			migrate.ErrDuplicateVersion,
+		},
+	}
+
+	for _, test := range loadValues {
+		_, err := migrate.Load(test.files)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: got error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestLoadOrder(t *testing.T) {
+	files := fstest.MapFS{
+		"0010_create_kalan.up.sql":   file("CREATE TABLE kalan (id int);"),
+		"0010_create_kalan.down.sql": file("DROP TABLE kalan;"),
+		"0002_create_users.up.sql":   file("CREATE TABLE users (id int);"),
+		"0002_create_users.down.sql": file("DROP TABLE users;"),
+	}
+
+	migrations, err := migrate.Load(files)
+	if err != nil {
+		t.Fatalf("could not load migrations: %v", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("migrations are out of order: %+v", migrations)
+	}
+	if migrations[0].Name != "create_users" || migrations[0].Down != "DROP TABLE users;" {
+		t.Errorf("migration 2 was read wrong: %+v", migrations[0])
+	}
+	if migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("different migrations have the same checksum")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	mysqlMigrations, err := migrate.Embedded("mysql")
+	if err != nil {
+		t.Fatalf("could not load mysql migrations: %v", err)
+	}
+
+	sqliteMigrations, err := migrate.Embedded("sqlite")
+	if err != nil {
+		t.Fatalf("could not load sqlite migrations: %v", err)
+	}
+
+	if len(mysqlMigrations) != len(sqliteMigrations) {
+		t.Fatalf("there are %v mysql migrations but %v sqlite migrations", len(mysqlMigrations), len(sqliteMigrations))
+	}
+
+	for i, m := range mysqlMigrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %v_%v should have version %v", m.Version, m.Name, i+1)
+		}
+		if sqliteMigrations[i].Version != m.Version || sqliteMigrations[i].Name != m.Name {
+			t.Errorf("mysql migration %v_%v has no matching sqlite migration", m.Version, m.Name)
+		}
+	}
+
+	_, err = migrate.Embedded("postgres")
+	if !errors.Is(err, migrate.ErrUnknownDialect) {
+		t.Errorf("unknown dialect: got error %v, want %v", err, migrate.ErrUnknownDialect)
+	}
+}
+
+func TestSQLiteUpDown(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "wilin.db"))
+	if err != nil {
+		t.Fatalf("could not open sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, database.DRIVER_SQLITE)
+	if err != nil {
+		t.Fatalf("could not load migrations: %v", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("could not migrate up: %v", err)
+	}
+
+	reverted, err := migrator.Down(ctx, len(applied))
+	if err != nil {
+		t.Fatalf("could not migrate down: %v", err)
+	}
+	if len(reverted) != len(applied) {
+		t.Errorf("reverted %v migrations, want %v", len(reverted), len(applied))
+	}
+
+	reapplied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("could not migrate up again: %v", err)
+	}
+	if len(reapplied) != len(applied) {
+		t.Errorf("reapplied %v migrations, want %v", len(reapplied), len(applied))
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("could not read status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("migration %v_%v was not applied", status.Version, status.Name)
+		}
+	}
+}
+
+func TestSQLiteSenseBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "wilin.db"))
+	if err != nil {
+		t.Fatalf("could not open sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	migrations, err := migrate.Embedded(database.DRIVER_SQLITE)
+	if err != nil {
+		t.Fatalf("could not load migrations: %v", err)
+	}
+
+	_, err = migrate.NewWithMigrations(db, migrations[:8]).Up(ctx)
+	if err != nil {
+		t.Fatalf("could not migrate up to senses: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO kalan (entry, pos, gloss, notes) VALUES ('wilin', 'noun', 'word', '')")
+	if err != nil {
+		t.Fatalf("could not create kalan: %v", err)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO kalan (entry, pos, gloss, notes) VALUES ('kan', 'noun', 'sound;  noise ;; speech', '')")
+	if err != nil {
+		t.Fatalf("could not create kalan: %v", err)
+	}
+
+	_, err = migrate.NewWithMigrations(db, migrations).Up(ctx)
+	if err != nil {
+		t.Fatalf("could not migrate up: %v", err)
+	}
+
+	var position int
+	var gloss string
+	err = db.QueryRowContext(ctx, "SELECT position, gloss FROM kalan_senses WHERE kalan_id = 1").Scan(&position, &gloss)
+	if err != nil {
+		t.Fatalf("could not read sense: %v", err)
+	}
+	if position != 0 || gloss != "word" {
+		t.Errorf("backfilled sense = %v %q, want 0 %q", position, gloss, "word")
+	}
+
+	// a gloss with separators is split into a sense for each part
+	rows, err := db.QueryContext(ctx, "SELECT position, gloss FROM kalan_senses WHERE kalan_id = 2 ORDER BY position")
+	if err != nil {
+		t.Fatalf("could not read senses: %v", err)
+	}
+	defer rows.Close()
+	senses := []string{}
+	for rows.Next() {
+		err = rows.Scan(&position, &gloss)
+		if err != nil {
+			t.Fatalf("could not read sense: %v", err)
+		}
+		senses = append(senses, fmt.Sprintf("%v:%v", position, gloss))
+	}
+	expected := []string{"0:sound", "1:noise", "2:speech"}
+	if !slices.Equal(senses, expected) {
+		t.Errorf("backfilled senses = %v, want %v", senses, expected)
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    email varchar(127) UNIQUE NOT NULL,
+    username varchar(31) UNIQUE NOT NULL,
+    password varchar(255) NOT NULL,
+    role varchar(255) NOT NULL
+);
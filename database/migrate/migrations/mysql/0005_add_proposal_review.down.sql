@@ -0,0 +1,7 @@
+ALTER TABLE proposals DROP FOREIGN KEY fk_proposals_kalan;
+
+ALTER TABLE proposals
+    DROP COLUMN kalan_id,
+    DROP COLUMN kind,
+    DROP COLUMN reason,
+    DROP COLUMN status;
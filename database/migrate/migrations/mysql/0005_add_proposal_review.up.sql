@@ -0,0 +1,6 @@
+ALTER TABLE proposals
+    ADD COLUMN status varchar(31) NOT NULL DEFAULT 'pending',
+    ADD COLUMN reason varchar(2047) NOT NULL DEFAULT '',
+    ADD COLUMN kind varchar(31) NOT NULL DEFAULT 'new',
+    ADD COLUMN kalan_id int,
+    ADD CONSTRAINT fk_proposals_kalan FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE SET NULL;
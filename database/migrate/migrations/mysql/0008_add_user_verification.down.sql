@@ -0,0 +1,3 @@
+DROP TABLE verifications;
+
+ALTER TABLE users DROP COLUMN verified;
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- accounts made before verification existed are trusted
+UPDATE users SET verified = TRUE;
+
+CREATE TABLE verifications (
+    id varchar(255) PRIMARY KEY NOT NULL,
+    user_id INT NOT NULL,
+    expired_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
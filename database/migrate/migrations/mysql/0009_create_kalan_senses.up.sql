@@ -0,0 +1,35 @@
+CREATE TABLE kalan_senses (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    kalan_id int NOT NULL,
+    position int NOT NULL,
+    gloss VARCHAR(255) NOT NULL,
+    definition TEXT NOT NULL,
+    usage_label VARCHAR(255) NOT NULL,
+    register VARCHAR(255) NOT NULL,
+    UNIQUE (kalan_id, position),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE
+);
+
+-- every part of an existing gloss between separators becomes a
+-- sense of its word, as the server splits glosses sent without
+-- senses. The separator is written as CHAR(59) since migrations
+-- are split into statements on semicolons
+INSERT INTO kalan_senses (kalan_id, position, gloss, definition, usage_label, register)
+WITH RECURSIVE parts (kalan_id, n, part, rest) AS (
+    SELECT id, 0, CAST('' AS CHAR(255)), CAST(CONCAT(gloss, CHAR(59 USING utf8mb4)) AS CHAR(256))
+    FROM kalan
+    UNION ALL
+    SELECT
+        kalan_id,
+        n + 1,
+        TRIM(SUBSTRING_INDEX(rest, CHAR(59 USING utf8mb4), 1)),
+        SUBSTRING(rest, LOCATE(CHAR(59 USING utf8mb4), rest) + 1)
+    FROM parts
+    WHERE rest <> ''
+)
+SELECT kalan_id, ROW_NUMBER() OVER (PARTITION BY kalan_id ORDER BY n) - 1, part, '', '', ''
+FROM parts
+WHERE part <> '';
+
+-- revisions made before senses existed have none
+ALTER TABLE kalan_revisions ADD COLUMN senses TEXT;
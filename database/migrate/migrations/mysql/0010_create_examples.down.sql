@@ -0,0 +1,3 @@
+DROP TABLE kalan_examples;
+
+DROP TABLE examples;
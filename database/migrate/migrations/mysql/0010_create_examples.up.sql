@@ -0,0 +1,14 @@
+CREATE TABLE examples (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    sentence TEXT NOT NULL,
+    gloss TEXT NOT NULL,
+    translation TEXT NOT NULL
+);
+
+CREATE TABLE kalan_examples (
+    kalan_id int NOT NULL,
+    example_id int NOT NULL,
+    PRIMARY KEY (kalan_id, example_id),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE,
+    FOREIGN KEY (example_id) REFERENCES examples (id) ON DELETE CASCADE
+);
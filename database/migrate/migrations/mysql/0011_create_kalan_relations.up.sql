@@ -0,0 +1,9 @@
+CREATE TABLE kalan_relations (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    kalan_id int NOT NULL,
+    related_id int NOT NULL,
+    kind varchar(31) NOT NULL,
+    UNIQUE (kalan_id, related_id, kind),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE,
+    FOREIGN KEY (related_id) REFERENCES kalan (id) ON DELETE CASCADE
+);
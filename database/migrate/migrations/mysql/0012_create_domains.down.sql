@@ -0,0 +1,3 @@
+DROP TABLE kalan_domains;
+
+DROP TABLE domains;
@@ -0,0 +1,3 @@
+-- links of a word to examples, other words and domains are kept when it
+-- is deleted, since they are removed along with it
+ALTER TABLE kalan_revisions ADD COLUMN links TEXT;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email varchar(127) UNIQUE NOT NULL COLLATE NOCASE,
+    username varchar(31) UNIQUE NOT NULL COLLATE NOCASE,
+    password varchar(255) NOT NULL,
+    role varchar(255) NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS kalan (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry VARCHAR(255) NOT NULL COLLATE NOCASE,
+    pos VARCHAR(255) NOT NULL COLLATE NOCASE,
+    gloss VARCHAR(255) NOT NULL COLLATE NOCASE,
+    notes VARCHAR(255) NOT NULL COLLATE NOCASE
+);
@@ -0,0 +1,19 @@
+-- sqlite cannot drop a column that is a foreign key,
+-- so the table is rebuilt without the new columns
+CREATE TABLE proposals_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id int,
+    entry varchar(255) NOT NULL,
+    pos varchar(255) NOT NULL,
+    gloss varchar(255) NOT NULL,
+    notes varchar(2047) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+INSERT INTO proposals_old (id, user_id, entry, pos, gloss, notes)
+SELECT id, user_id, entry, pos, gloss, notes
+FROM proposals;
+
+DROP TABLE proposals;
+
+ALTER TABLE proposals_old RENAME TO proposals;
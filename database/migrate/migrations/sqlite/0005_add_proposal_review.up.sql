@@ -0,0 +1,7 @@
+ALTER TABLE proposals ADD COLUMN status varchar(31) NOT NULL DEFAULT 'pending';
+
+ALTER TABLE proposals ADD COLUMN reason varchar(2047) NOT NULL DEFAULT '';
+
+ALTER TABLE proposals ADD COLUMN kind varchar(31) NOT NULL DEFAULT 'new';
+
+ALTER TABLE proposals ADD COLUMN kalan_id int REFERENCES kalan (id) ON DELETE SET NULL;
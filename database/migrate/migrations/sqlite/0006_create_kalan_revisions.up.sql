@@ -0,0 +1,12 @@
+CREATE TABLE kalan_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kalan_id int NOT NULL,
+    action varchar(31) NOT NULL,
+    entry VARCHAR(255) NOT NULL,
+    pos VARCHAR(255) NOT NULL,
+    gloss VARCHAR(255) NOT NULL,
+    notes VARCHAR(255) NOT NULL,
+    user_id int,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
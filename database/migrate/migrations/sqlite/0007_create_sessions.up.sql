@@ -0,0 +1,18 @@
+CREATE TABLE sessions (
+    id varchar(255) PRIMARY KEY NOT NULL,
+    user_id INT NOT NULL,
+    user_agent varchar(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP NOT NULL,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE refresh_tokens (
+    id varchar(255) PRIMARY KEY NOT NULL,
+    session_id varchar(255) NOT NULL,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    expired_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
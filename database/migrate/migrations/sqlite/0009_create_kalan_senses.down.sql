@@ -0,0 +1,3 @@
+ALTER TABLE kalan_revisions DROP COLUMN senses;
+
+DROP TABLE kalan_senses;
@@ -0,0 +1,35 @@
+CREATE TABLE kalan_senses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kalan_id int NOT NULL,
+    position int NOT NULL,
+    gloss VARCHAR(255) NOT NULL COLLATE NOCASE,
+    definition TEXT NOT NULL COLLATE NOCASE,
+    usage_label VARCHAR(255) NOT NULL COLLATE NOCASE,
+    register VARCHAR(255) NOT NULL COLLATE NOCASE,
+    UNIQUE (kalan_id, position),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE
+);
+
+-- every part of an existing gloss between separators becomes a
+-- sense of its word, as the server splits glosses sent without
+-- senses. The separator is written as char(59) since migrations
+-- are split into statements on semicolons
+INSERT INTO kalan_senses (kalan_id, position, gloss, definition, usage_label, register)
+WITH RECURSIVE parts (kalan_id, n, part, rest) AS (
+    SELECT id, 0, '', gloss || char(59)
+    FROM kalan
+    UNION ALL
+    SELECT
+        kalan_id,
+        n + 1,
+        trim(substr(rest, 1, instr(rest, char(59)) - 1)),
+        substr(rest, instr(rest, char(59)) + 1)
+    FROM parts
+    WHERE rest <> ''
+)
+SELECT kalan_id, ROW_NUMBER() OVER (PARTITION BY kalan_id ORDER BY n) - 1, part, '', '', ''
+FROM parts
+WHERE part <> '';
+
+-- revisions made before senses existed have none
+ALTER TABLE kalan_revisions ADD COLUMN senses TEXT;
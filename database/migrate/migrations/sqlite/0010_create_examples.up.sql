@@ -0,0 +1,14 @@
+CREATE TABLE examples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sentence TEXT NOT NULL COLLATE NOCASE,
+    gloss TEXT NOT NULL COLLATE NOCASE,
+    translation TEXT NOT NULL COLLATE NOCASE
+);
+
+CREATE TABLE kalan_examples (
+    kalan_id int NOT NULL,
+    example_id int NOT NULL,
+    PRIMARY KEY (kalan_id, example_id),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE,
+    FOREIGN KEY (example_id) REFERENCES examples (id) ON DELETE CASCADE
+);
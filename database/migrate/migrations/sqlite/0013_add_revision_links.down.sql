@@ -0,0 +1 @@
+ALTER TABLE kalan_revisions DROP COLUMN links;
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	DRIVER_MYSQL        = "mysql"
+	DRIVER_SQLITE       = "sqlite"
+	DEFAULT_SQLITE_PATH = "wilin.db"
+)
+
+// MYSQL_DUPLICATE_ENTRY is the error number MySQL gives when a
+// row would repeat a primary or unique key
+const MYSQL_DUPLICATE_ENTRY = 1062
+
+var (
+	ErrUnknownDriver = errors.New("unknown database driver")
+	ErrDuplicateKey  = errors.New("duplicate key")
+)
+
+// IsDuplicateKey reports whether err was caused by a row repeating
+// a primary or unique key, whichever store it came from
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == MYSQL_DUPLICATE_ENTRY
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	return errors.Is(err, ErrDuplicateKey)
+}
+
+// Open connects to the database selected by the DB_DRIVER
+// environment variable and returns it along with its SQL dialect,
+// which names the migrations to run against it. MySQL is used if
+// no driver is set
+func Open() (*sql.DB, string, error) {
+	switch os.Getenv("DB_DRIVER") {
+	case DRIVER_MYSQL, "":
+		db, err := OpenMySQL(
+			os.Getenv("DB_USERNAME"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_ADDRESS"),
+			os.Getenv("DB_NAME"),
+		)
+		return db, DRIVER_MYSQL, err
+	case DRIVER_SQLITE:
+		path := os.Getenv("DB_PATH")
+		if path == "" {
+			path = DEFAULT_SQLITE_PATH
+		}
+		db, err := OpenSQLite(path)
+		return db, DRIVER_SQLITE, err
+	default:
+		return nil, "", ErrUnknownDriver
+	}
+}
+
+func OpenMySQL(username string, password string, address string, dbName string) (*sql.DB, error) {
+	dataSource := fmt.Sprintf("%s:%s@(%s)/%s?clientFoundRows=true&parseTime=true", username, password, address, dbName)
+	return sql.Open("mysql", dataSource)
+}
+
+// OpenSQLite opens the database file at path, creating it if it
+// does not exist. SQLite only allows one writer at a time, so the
+// pool is kept to a single connection
+func OpenSQLite(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Set("_foreign_keys", "on")
+	query.Set("_busy_timeout", "5000")
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
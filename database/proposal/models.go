@@ -8,13 +8,42 @@ import (
 	"database/sql"
 )
 
+type Kalan struct {
+	ID    int32
+	Entry string
+	Pos   string
+	Gloss string
+	Notes string
+}
+
+type KalanRelation struct {
+	ID        int32
+	KalanID   int32
+	RelatedID int32
+	Kind      string
+}
+
+type KalanSense struct {
+	ID         int32
+	KalanID    int32
+	Position   int32
+	Gloss      string
+	Definition string
+	UsageLabel string
+	Register   string
+}
+
 type Proposal struct {
-	ID     int32
-	UserID sql.NullInt32
-	Entry  string
-	Pos    string
-	Gloss  string
-	Notes  string
+	ID      int32
+	UserID  sql.NullInt32
+	Entry   string
+	Pos     string
+	Gloss   string
+	Notes   string
+	Status  string
+	Reason  string
+	Kind    string
+	KalanID sql.NullInt32
 }
 
 type User struct {
@@ -23,4 +52,5 @@ type User struct {
 	Username string
 	Password string
 	Role     string
+	Verified bool
 }
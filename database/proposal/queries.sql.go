@@ -17,17 +17,21 @@ INSERT INTO
         entry,
         pos,
         gloss,
-        notes
+        notes,
+        kind,
+        kalan_id
     )
-VALUES (?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateProposalParams struct {
-	UserID sql.NullInt32
-	Entry  string
-	Pos    string
-	Gloss  string
-	Notes  string
+	UserID  sql.NullInt32
+	Entry   string
+	Pos     string
+	Gloss   string
+	Notes   string
+	Kind    string
+	KalanID sql.NullInt32
 }
 
 func (q *Queries) CreateProposal(ctx context.Context, arg CreateProposalParams) (sql.Result, error) {
@@ -37,6 +41,8 @@ func (q *Queries) CreateProposal(ctx context.Context, arg CreateProposalParams)
 		arg.Pos,
 		arg.Gloss,
 		arg.Notes,
+		arg.Kind,
+		arg.KalanID,
 	)
 }
 
@@ -49,7 +55,7 @@ func (q *Queries) Delete(ctx context.Context, id int32) (sql.Result, error) {
 }
 
 const readAllProposalsWithUsername = `-- name: ReadAllProposalsWithUsername :many
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id
 `
@@ -62,6 +68,10 @@ type ReadAllProposalsWithUsernameRow struct {
 	Pos      string
 	Gloss    string
 	Notes    string
+	Status   string
+	Reason   string
+	Kind     string
+	KalanID  sql.NullInt32
 }
 
 func (q *Queries) ReadAllProposalsWithUsername(ctx context.Context) ([]ReadAllProposalsWithUsernameRow, error) {
@@ -81,6 +91,10 @@ func (q *Queries) ReadAllProposalsWithUsername(ctx context.Context) ([]ReadAllPr
 			&i.Pos,
 			&i.Gloss,
 			&i.Notes,
+			&i.Status,
+			&i.Reason,
+			&i.Kind,
+			&i.KalanID,
 		); err != nil {
 			return nil, err
 		}
@@ -96,7 +110,7 @@ func (q *Queries) ReadAllProposalsWithUsername(ctx context.Context) ([]ReadAllPr
 }
 
 const readProposalByIDWithUsername = `-- name: ReadProposalByIDWithUsername :one
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id
 WHERE
@@ -112,6 +126,10 @@ type ReadProposalByIDWithUsernameRow struct {
 	Pos      string
 	Gloss    string
 	Notes    string
+	Status   string
+	Reason   string
+	Kind     string
+	KalanID  sql.NullInt32
 }
 
 func (q *Queries) ReadProposalByIDWithUsername(ctx context.Context, id int32) (ReadProposalByIDWithUsernameRow, error) {
@@ -125,12 +143,16 @@ func (q *Queries) ReadProposalByIDWithUsername(ctx context.Context, id int32) (R
 		&i.Pos,
 		&i.Gloss,
 		&i.Notes,
+		&i.Status,
+		&i.Reason,
+		&i.Kind,
+		&i.KalanID,
 	)
 	return i, err
 }
 
 const readProposalsByUserIDWithUsername = `-- name: ReadProposalsByUserIDWithUsername :many
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id
 WHERE
@@ -145,6 +167,10 @@ type ReadProposalsByUserIDWithUsernameRow struct {
 	Pos      string
 	Gloss    string
 	Notes    string
+	Status   string
+	Reason   string
+	Kind     string
+	KalanID  sql.NullInt32
 }
 
 func (q *Queries) ReadProposalsByUserIDWithUsername(ctx context.Context, id int32) ([]ReadProposalsByUserIDWithUsernameRow, error) {
@@ -164,6 +190,10 @@ func (q *Queries) ReadProposalsByUserIDWithUsername(ctx context.Context, id int3
 			&i.Pos,
 			&i.Gloss,
 			&i.Notes,
+			&i.Status,
+			&i.Reason,
+			&i.Kind,
+			&i.KalanID,
 		); err != nil {
 			return nil, err
 		}
@@ -188,6 +218,7 @@ SET
     notes = ?
 WHERE
     id = ?
+    AND status = 'pending'
 `
 
 type UpdateParams struct {
@@ -209,3 +240,23 @@ func (q *Queries) Update(ctx context.Context, arg UpdateParams) (sql.Result, err
 		arg.ID,
 	)
 }
+
+const updateStatus = `-- name: UpdateStatus :execresult
+UPDATE proposals
+SET
+    status = ?,
+    reason = ?
+WHERE
+    id = ?
+    AND status = 'pending'
+`
+
+type UpdateStatusParams struct {
+	Status string
+	Reason string
+	ID     int32
+}
+
+func (q *Queries) UpdateStatus(ctx context.Context, arg UpdateStatusParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateStatus, arg.Status, arg.Reason, arg.ID)
+}
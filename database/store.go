@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"wilin.info/api/database/domain"
+	"wilin.info/api/database/example"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/database/proposal"
+	"wilin.info/api/database/recovery"
+	"wilin.info/api/database/revision"
+	"wilin.info/api/database/session"
+	"wilin.info/api/database/users"
+	"wilin.info/api/database/verification"
+)
+
+// Store gives access to every set of queries the api runs
+type Store interface {
+	Kalan() kalan.Querier
+	Users() users.Querier
+	Proposal() proposal.Querier
+	Recovery() recovery.Querier
+	Revision() revision.Querier
+	Session() session.Querier
+	Verification() verification.Querier
+	Example() example.Querier
+	Domain() domain.Querier
+
+	// ReadKalanIDsByFilter returns the ids of every kalan that
+	// meets filter, in order
+	ReadKalanIDsByFilter(ctx context.Context, filter KalanFilter) ([]int32, error)
+
+	// InTx runs fn with a store whose queries all belong to the
+	// same transaction. The transaction is committed if fn returns
+	// nil and rolled back otherwise
+	InTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// KalanFilter is a condition on the columns of kalan that is only
+// known at run time, which sqlc cannot generate a query for
+type KalanFilter interface {
+	// Match reports whether k meets the condition
+	Match(k kalan.Kalan) bool
+	// SQL returns the condition with a ? in place of every value in args
+	SQL() (string, []any)
+}
+
+// SQLStore is the Store backed by the sqlc queries
+type SQLStore struct {
+	db                  *sql.DB
+	tx                  *sql.Tx
+	kalanQueries        *kalan.Queries
+	userQueries         *users.Queries
+	proposalQueries     *proposal.Queries
+	recoveryQueries     *recovery.Queries
+	revisionQueries     *revision.Queries
+	sessionQueries      *session.Queries
+	verificationQueries *verification.Queries
+	exampleQueries      *example.Queries
+	domainQueries       *domain.Queries
+}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		db:                  db,
+		kalanQueries:        kalan.New(db),
+		userQueries:         users.New(db),
+		proposalQueries:     proposal.New(db),
+		recoveryQueries:     recovery.New(db),
+		revisionQueries:     revision.New(db),
+		sessionQueries:      session.New(db),
+		verificationQueries: verification.New(db),
+		exampleQueries:      example.New(db),
+		domainQueries:       domain.New(db),
+	}
+}
+
+func (s *SQLStore) Kalan() kalan.Querier {
+	return s.kalanQueries
+}
+
+func (s *SQLStore) Users() users.Querier {
+	return s.userQueries
+}
+
+func (s *SQLStore) Proposal() proposal.Querier {
+	return s.proposalQueries
+}
+
+func (s *SQLStore) Recovery() recovery.Querier {
+	return s.recoveryQueries
+}
+
+func (s *SQLStore) Revision() revision.Querier {
+	return s.revisionQueries
+}
+
+func (s *SQLStore) Session() session.Querier {
+	return s.sessionQueries
+}
+
+func (s *SQLStore) Verification() verification.Querier {
+	return s.verificationQueries
+}
+
+func (s *SQLStore) Example() example.Querier {
+	return s.exampleQueries
+}
+
+func (s *SQLStore) Domain() domain.Querier {
+	return s.domainQueries
+}
+
+func (s *SQLStore) ReadKalanIDsByFilter(ctx context.Context, filter KalanFilter) ([]int32, error) {
+	var db kalan.DBTX = s.db
+	if s.tx != nil {
+		db = s.tx
+	}
+
+	condition, args := filter.SQL()
+	rows, err := db.QueryContext(ctx, "SELECT id FROM kalan WHERE "+condition+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// InTx runs fn inside of a database transaction. Calling InTx
+// on the store given to fn reuses the same transaction
+func (s *SQLStore) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txStore := &SQLStore{
+		db:                  s.db,
+		tx:                  tx,
+		kalanQueries:        s.kalanQueries.WithTx(tx),
+		userQueries:         s.userQueries.WithTx(tx),
+		proposalQueries:     s.proposalQueries.WithTx(tx),
+		recoveryQueries:     s.recoveryQueries.WithTx(tx),
+		revisionQueries:     s.revisionQueries.WithTx(tx),
+		sessionQueries:      s.sessionQueries.WithTx(tx),
+		verificationQueries: s.verificationQueries.WithTx(tx),
+		exampleQueries:      s.exampleQueries.WithTx(tx),
+		domainQueries:       s.domainQueries.WithTx(tx),
+	}
+
+	err = fn(txStore)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
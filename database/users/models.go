@@ -10,4 +10,5 @@ type User struct {
 	Username string
 	Password string
 	Role     string
+	Verified bool
 }
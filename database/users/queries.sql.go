@@ -43,7 +43,8 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     email = ?
@@ -59,6 +60,7 @@ func (q *Queries) ReadUserByEmail(ctx context.Context, email string) (User, erro
 		&i.Username,
 		&i.Password,
 		&i.Role,
+		&i.Verified,
 	)
 	return i, err
 }
@@ -69,7 +71,8 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     id = ?
@@ -85,6 +88,7 @@ func (q *Queries) ReadUserByID(ctx context.Context, id int32) (User, error) {
 		&i.Username,
 		&i.Password,
 		&i.Role,
+		&i.Verified,
 	)
 	return i, err
 }
@@ -95,7 +99,8 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     username = ?
@@ -111,6 +116,7 @@ func (q *Queries) ReadUserByUsername(ctx context.Context, username string) (User
 		&i.Username,
 		&i.Password,
 		&i.Role,
+		&i.Verified,
 	)
 	return i, err
 }
@@ -127,3 +133,11 @@ type UpdatePasswordParams struct {
 func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) (sql.Result, error) {
 	return q.db.ExecContext(ctx, updatePassword, arg.Password, arg.ID)
 }
+
+const verifyUser = `-- name: VerifyUser :execresult
+UPDATE users SET verified = TRUE WHERE id = ?
+`
+
+func (q *Queries) VerifyUser(ctx context.Context, id int32) (sql.Result, error) {
+	return q.db.ExecContext(ctx, verifyUser, id)
+}
@@ -1,23 +1,70 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	"wilin.info/api/database"
+	"wilin.info/api/database/migrate"
 	"wilin.info/api/server"
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/morphology"
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/services"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
 
-func getDataSource() string {
-	username := os.Getenv("DB_USERNAME")
-	password := os.Getenv("DB_PASSWORD")
-	address := os.Getenv("DB_ADDRESS")
-	dbName := os.Getenv("DB_NAME")
-	return fmt.Sprintf("%s:%s@(%s)/%s?clientFoundRows=true&parseTime=true", username, password, address, dbName)
+// runMigrate handles the migrate subcommand:
+//
+//	migrate [up]
+//	migrate down [steps]
+//	migrate status
+func runMigrate(migrator *migrate.Migrator, args []string) error {
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %v", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(server.TIME_FORMAT)
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command: %v", command)
+	}
 }
 
 func main() {
@@ -26,11 +73,52 @@ func main() {
 		log.Fatalf("Error loading .env file: %v\n", err)
 	}
 
-	db, err := sql.Open("mysql", getDataSource())
+	db, dialect, err := database.Open()
 	if err != nil {
 		log.Fatalf("Error opening database connection: %v\n", err)
 	}
 
-	server := server.New(db)
+	migrator, err := migrate.New(db, dialect)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v\n", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(migrator, os.Args[2:])
+		if err != nil {
+			log.Fatalf("Error migrating database: %v\n", err)
+		}
+		return
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		log.Fatalf("Error migrating database: %v\n", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+
+	mailer, err := services.NewMailer()
+	if err != nil {
+		log.Fatalf("Error creating mailer: %v\n", err)
+	}
+
+	collator, err := collation.NewCollator()
+	if err != nil {
+		log.Fatalf("Error loading alphabet: %v\n", err)
+	}
+
+	validator, err := phonology.NewValidator()
+	if err != nil {
+		log.Fatalf("Error loading phonology: %v\n", err)
+	}
+
+	analyzer, err := morphology.NewMorphology()
+	if err != nil {
+		log.Fatalf("Error loading morphology: %v\n", err)
+	}
+
+	server := server.New(database.NewSQLStore(db), mailer, collator, validator, analyzer)
 	server.Logger.Fatal(server.Start(":8080"))
 }
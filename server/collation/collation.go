@@ -0,0 +1,204 @@
+// Package collation orders words by the alphabet of the language,
+// which is an ordered list of letters and digraphs, rather than by
+// the order of their characters in Unicode.
+//
+// Letters are matched greedily, so with the digraph "ng" in the
+// alphabet "nga" is read as ng, a and never as n, g, a.
+package collation
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"wilin.info/api/server/search"
+)
+
+//go:embed alphabet.json
+var defaultAlphabet []byte
+
+var (
+	ErrNoLetters       = errors.New("alphabet has no letters")
+	ErrDuplicateLetter = errors.New("letter appears twice in alphabet")
+)
+
+// Alphabet is the configuration of a collator. Letters are in
+// the order words are sorted by. Characters in Ignore are skipped
+// when ordering, so that "kan-a" sorts right next to "kana"
+type Alphabet struct {
+	Letters []string `json:"letters"`
+	Ignore  []string `json:"ignore"`
+}
+
+// Keys are strings of runes so that they compare correctly
+// byte by byte and survive being stored as JSON. Every letter
+// of the alphabet becomes the rune FIRST_LETTER plus its place
+// in the alphabet. Characters that are not in the alphabet
+// come after every letter and keep their Unicode order
+const (
+	KEY_SEPARATOR rune = 0x1
+	FIRST_LETTER  rune = 0x1000
+	UNKNOWN       rune = 0xF000
+)
+
+type Collator struct {
+	ranks   map[string]int
+	longest int
+	ignore  map[string]bool
+}
+
+func New(alphabet Alphabet) (*Collator, error) {
+	if len(alphabet.Letters) < 1 {
+		return nil, ErrNoLetters
+	}
+	if len(alphabet.Letters) > int(UNKNOWN-FIRST_LETTER) {
+		return nil, fmt.Errorf("alphabet has more than %v letters", UNKNOWN-FIRST_LETTER)
+	}
+
+	c := &Collator{
+		ranks:  map[string]int{},
+		ignore: map[string]bool{},
+	}
+	for i, letter := range alphabet.Letters {
+		letter = strings.ToLower(letter)
+		if letter == "" {
+			return nil, ErrNoLetters
+		}
+
+		_, ok := c.ranks[letter]
+		if ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateLetter, letter)
+		}
+		c.ranks[letter] = i
+		c.longest = max(c.longest, utf8.RuneCountInString(letter))
+	}
+	for _, ignored := range alphabet.Ignore {
+		c.ignore[ignored] = true
+	}
+
+	return c, nil
+}
+
+// Load reads the alphabet stored as JSON in the file at path
+func Load(path string) (*Collator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Collator, error) {
+	var alphabet Alphabet
+	err := json.Unmarshal(data, &alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return New(alphabet)
+}
+
+// Default returns the collator for the alphabet built into the binary
+func Default() *Collator {
+	c, err := Parse(defaultAlphabet)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default alphabet: %v", err))
+	}
+	return c
+}
+
+// NewCollator creates the collator for the alphabet in the file
+// named by the ALPHABET_FILE environment variable, or the default
+// alphabet if it is not set
+func NewCollator() (*Collator, error) {
+	path := os.Getenv("ALPHABET_FILE")
+	if path == "" {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Split breaks s into the letters of the alphabet it is made of.
+// Characters that are not part of any letter are kept on their own
+func (c *Collator) Split(s string) []string {
+	s = norm.NFC.String(strings.ToLower(s))
+
+	var letters []string
+	for len(s) > 0 {
+		letter := c.nextLetter(s)
+		letters = append(letters, letter)
+		s = s[len(letter):]
+	}
+	return letters
+}
+
+// nextLetter returns the longest letter of the alphabet that s
+// starts with, or its first character if it starts with none
+func (c *Collator) nextLetter(s string) string {
+	end := 0
+	for i := 0; i < c.longest && end < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+
+	for end > 0 {
+		_, ok := c.ranks[s[:end]]
+		if ok {
+			return s[:end]
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:end])
+		end -= size
+	}
+
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size]
+}
+
+// Rank returns the place of letter in the alphabet. Letters with
+// diacritics that are not in the alphabet take the place of the
+// letter without them
+func (c *Collator) Rank(letter string) (int, bool) {
+	rank, ok := c.ranks[letter]
+	if ok {
+		return rank, true
+	}
+	rank, ok = c.ranks[search.Fold(letter)]
+	return rank, ok
+}
+
+// Key returns a string that sorts before the key of every word that
+// s comes before in the alphabet. Words that only differ by case,
+// diacritics or ignored characters are then ordered by their text
+func (c *Collator) Key(s string) string {
+	var b strings.Builder
+	for _, letter := range c.Split(s) {
+		if c.ignore[letter] {
+			continue
+		}
+
+		rank, ok := c.Rank(letter)
+		if ok {
+			b.WriteRune(FIRST_LETTER + rune(rank))
+			continue
+		}
+
+		for _, r := range letter {
+			b.WriteRune(UNKNOWN)
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteRune(KEY_SEPARATOR)
+	b.WriteString(s)
+	return b.String()
+}
+
+// Compare returns -1 if a sorts before b, 1 if it sorts after and 0
+// if they are the same
+func (c *Collator) Compare(a string, b string) int {
+	return strings.Compare(c.Key(a), c.Key(b))
+}
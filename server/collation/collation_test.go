@@ -0,0 +1,106 @@
+package collation_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf8"
+
+	"wilin.info/api/server/collation"
+)
+
+func newCollator(t *testing.T) *collation.Collator {
+	t.Helper()
+
+	// the vowels come first and ng is a letter of its own
+	c, err := collation.New(collation.Alphabet{
+		Letters: []string{"a", "e", "i", "o", "u", "k", "l", "n", "ng", "s", "w"},
+		Ignore:  []string{"-", "'"},
+	})
+	if err != nil {
+		t.Fatalf("could not create collator: %v", err)
+	}
+	return c
+}
+
+func TestSplit(t *testing.T) {
+	c := newCollator(t)
+
+	letters := c.Split("Ngawin-ka")
+	expected := []string{"ng", "a", "w", "i", "n", "-", "k", "a"}
+	if !slices.Equal(letters, expected) {
+		t.Errorf("got letters %v, want %v", letters, expected)
+	}
+}
+
+func TestSort(t *testing.T) {
+	c := newCollator(t)
+
+	words := []string{"wilin", "kan", "nga", "nu", "ala", "ka-n", "Kan", "kán", "zeta", "ekan", "na"}
+	slices.SortFunc(words, c.Compare)
+
+	expected := []string{"ala", "ekan", "Kan", "ka-n", "kan", "kán", "na", "nu", "nga", "wilin", "zeta"}
+	if !slices.Equal(words, expected) {
+		t.Errorf("got order %v, want %v", words, expected)
+	}
+}
+
+func TestKeyIsValidUTF8(t *testing.T) {
+	c := newCollator(t)
+
+	for _, word := range []string{"wilin", "zeta", "ŋa", ""} {
+		key := c.Key(word)
+		if !utf8.ValidString(key) {
+			t.Errorf("key of %q is not valid UTF-8", word)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := collation.New(collation.Alphabet{})
+	if !errors.Is(err, collation.ErrNoLetters) {
+		t.Errorf("empty alphabet: got error %v, want %v", err, collation.ErrNoLetters)
+	}
+
+	_, err = collation.New(collation.Alphabet{Letters: []string{"a", "b", "A"}})
+	if !errors.Is(err, collation.ErrDuplicateLetter) {
+		t.Errorf("duplicate letter: got error %v, want %v", err, collation.ErrDuplicateLetter)
+	}
+}
+
+func TestNewCollator(t *testing.T) {
+	t.Setenv("ALPHABET_FILE", "")
+	c, err := collation.NewCollator()
+	if err != nil {
+		t.Fatalf("could not load default alphabet: %v", err)
+	}
+	if c.Compare("b", "a") <= 0 {
+		t.Errorf("default alphabet does not put a before b")
+	}
+	if c.Compare("nga", "nu") <= 0 {
+		t.Errorf("default alphabet does not put ng after n")
+	}
+
+	path := filepath.Join(t.TempDir(), "alphabet.json")
+	err = os.WriteFile(path, []byte(`{"letters": ["b", "a"]}`), 0o644)
+	if err != nil {
+		t.Fatalf("could not write alphabet: %v", err)
+	}
+
+	t.Setenv("ALPHABET_FILE", path)
+	c, err = collation.NewCollator()
+	if err != nil {
+		t.Fatalf("could not load alphabet file: %v", err)
+	}
+	if c.Compare("b", "a") >= 0 {
+		t.Errorf("alphabet file does not put b before a")
+	}
+
+	t.Setenv("ALPHABET_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	_, err = collation.NewCollator()
+	if err == nil {
+		t.Errorf("missing alphabet file did not fail")
+	}
+}
@@ -0,0 +1,235 @@
+// Package morphology splits words of running text into a stem and the
+// affixes of the language around it, so that each part can be glossed.
+//
+// A word can be read in several ways. Analyses lists them all, with
+// the readings that strip the fewest affixes first, and it is up to
+// the caller to pick the first one whose stem is in the dictionary.
+package morphology
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed morphology.json
+var defaultMorphology []byte
+
+// MAX_AFFIXES is the most affixes stripped from a single word
+const MAX_AFFIXES = 3
+
+var (
+	ErrNoForm         = errors.New("affix has no form")
+	ErrNoAffixGloss   = errors.New("affix has no gloss")
+	ErrInvalidForm    = errors.New("affix form cannot contain spaces")
+	ErrDuplicateAffix = errors.New("affix appears twice")
+)
+
+// Affix is a bound morpheme. Gloss is usually a grammatical
+// category in capitals, such as "PL" or "PST"
+type Affix struct {
+	Form  string `json:"form"`
+	Gloss string `json:"gloss"`
+}
+
+// Config is the configuration of a morphology. Prefixes are
+// stripped from the start of a word and suffixes from its end
+type Config struct {
+	Prefixes []Affix `json:"prefixes"`
+	Suffixes []Affix `json:"suffixes"`
+}
+
+type Morphology struct {
+	prefixes []Affix
+	suffixes []Affix
+}
+
+func New(config Config) (*Morphology, error) {
+	prefixes, err := newAffixes(config.Prefixes)
+	if err != nil {
+		return nil, fmt.Errorf("prefixes: %w", err)
+	}
+	suffixes, err := newAffixes(config.Suffixes)
+	if err != nil {
+		return nil, fmt.Errorf("suffixes: %w", err)
+	}
+	return &Morphology{prefixes: prefixes, suffixes: suffixes}, nil
+}
+
+// newAffixes checks affixes and returns them in lower case
+func newAffixes(affixes []Affix) ([]Affix, error) {
+	seen := map[string]bool{}
+	checked := []Affix{}
+	for _, affix := range affixes {
+		affix.Form = strings.ToLower(affix.Form)
+		if affix.Form == "" {
+			return nil, ErrNoForm
+		}
+		if strings.IndexFunc(affix.Form, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidForm, affix.Form)
+		}
+		if affix.Gloss == "" {
+			return nil, fmt.Errorf("%w: %q", ErrNoAffixGloss, affix.Form)
+		}
+		if seen[affix.Form] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateAffix, affix.Form)
+		}
+		seen[affix.Form] = true
+		checked = append(checked, affix)
+	}
+	return checked, nil
+}
+
+// Load reads the morphology stored as JSON in the file at path
+func Load(path string) (*Morphology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Morphology, error) {
+	var config Config
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	return New(config)
+}
+
+// Default returns the morphology built into the binary
+func Default() *Morphology {
+	m, err := Parse(defaultMorphology)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default morphology: %v", err))
+	}
+	return m
+}
+
+// NewMorphology creates the morphology in the file named by the
+// MORPHOLOGY_FILE environment variable, or the default morphology
+// if it is not set
+func NewMorphology() (*Morphology, error) {
+	path := os.Getenv("MORPHOLOGY_FILE")
+	if path == "" {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Token is a word of running text. Text is the word as it was
+// written and Word is what is left of it without punctuation
+// around it, which is empty if the token is only punctuation
+type Token struct {
+	Text string
+	Word string
+}
+
+// isWordPunct reports whether r is punctuation that can
+// be part of a word, and is not trimmed from tokens
+func isWordPunct(r rune) bool {
+	return r == '-' || r == '\''
+}
+
+// Tokenize splits text into tokens at spaces
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	for _, field := range strings.Fields(text) {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return (unicode.IsPunct(r) && !isWordPunct(r)) || unicode.IsSymbol(r)
+		})
+		tokens = append(tokens, Token{Text: field, Word: word})
+	}
+	return tokens
+}
+
+// Analysis is one reading of a word as a stem with affixes.
+// Prefixes and Suffixes are in the order they appear in the word
+type Analysis struct {
+	Prefixes []Affix
+	Stem     string
+	Suffixes []Affix
+}
+
+// Affixes returns how many affixes were stripped from the word
+func (a Analysis) Affixes() int {
+	return len(a.Prefixes) + len(a.Suffixes)
+}
+
+// Analyses returns every way word can be split into a stem and
+// at most MAX_AFFIXES affixes, starting with the word as a whole.
+// Readings with fewer affixes come first, then those with longer
+// stems, so that the first reading with a known stem is the
+// most likely one
+func (m *Morphology) Analyses(word string) []Analysis {
+	word = strings.ToLower(word)
+	if word == "" {
+		return nil
+	}
+
+	analyses := []Analysis{}
+	for _, prefixed := range m.stripPrefixes(Analysis{Stem: word}) {
+		analyses = append(analyses, m.stripSuffixes(prefixed)...)
+	}
+
+	slices.SortStableFunc(analyses, func(a Analysis, b Analysis) int {
+		if a.Affixes() != b.Affixes() {
+			return a.Affixes() - b.Affixes()
+		}
+		return utf8.RuneCountInString(b.Stem) - utf8.RuneCountInString(a.Stem)
+	})
+	return analyses
+}
+
+// stripPrefixes returns analysis along with every reading
+// made by taking more prefixes off the start of its stem
+func (m *Morphology) stripPrefixes(analysis Analysis) []Analysis {
+	analyses := []Analysis{analysis}
+	if analysis.Affixes() >= MAX_AFFIXES {
+		return analyses
+	}
+
+	for _, prefix := range m.prefixes {
+		stem, ok := strings.CutPrefix(analysis.Stem, prefix.Form)
+		if !ok || stem == "" {
+			continue
+		}
+		next := Analysis{
+			Prefixes: append(slices.Clone(analysis.Prefixes), prefix),
+			Stem:     stem,
+		}
+		analyses = append(analyses, m.stripPrefixes(next)...)
+	}
+	return analyses
+}
+
+// stripSuffixes returns analysis along with every reading
+// made by taking more suffixes off the end of its stem
+func (m *Morphology) stripSuffixes(analysis Analysis) []Analysis {
+	analyses := []Analysis{analysis}
+	if analysis.Affixes() >= MAX_AFFIXES {
+		return analyses
+	}
+
+	for _, suffix := range m.suffixes {
+		stem, ok := strings.CutSuffix(analysis.Stem, suffix.Form)
+		if !ok || stem == "" {
+			continue
+		}
+		next := Analysis{
+			Prefixes: analysis.Prefixes,
+			Stem:     stem,
+			// suffixes are stripped from the outside in
+			Suffixes: append([]Affix{suffix}, analysis.Suffixes...),
+		}
+		analyses = append(analyses, m.stripSuffixes(next)...)
+	}
+	return analyses
+}
@@ -0,0 +1,139 @@
+package morphology_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"wilin.info/api/server/morphology"
+)
+
+func newMorphology(t *testing.T) *morphology.Morphology {
+	t.Helper()
+
+	m, err := morphology.New(morphology.Config{
+		Prefixes: []morphology.Affix{{Form: "ka", Gloss: "CAUS"}},
+		Suffixes: []morphology.Affix{
+			{Form: "an", Gloss: "PL"},
+			{Form: "i", Gloss: "PST"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create morphology: %v", err)
+	}
+	return m
+}
+
+// readings returns the analyses as stems with their affixes
+// joined by hyphens, such as "CAUS-moku-PL"
+func readings(analyses []morphology.Analysis) []string {
+	result := []string{}
+	for _, a := range analyses {
+		reading := ""
+		for _, prefix := range a.Prefixes {
+			reading += prefix.Gloss + "-"
+		}
+		reading += a.Stem
+		for _, suffix := range a.Suffixes {
+			reading += "-" + suffix.Gloss
+		}
+		result = append(result, reading)
+	}
+	return result
+}
+
+func TestAnalyses(t *testing.T) {
+	m := newMorphology(t)
+
+	got := readings(m.Analyses("Kamokuani"))
+	expected := []string{
+		"kamokuani",
+		"kamokuan-PST",
+		"CAUS-mokuani",
+		"kamoku-PL-PST",
+		"CAUS-mokuan-PST",
+		"CAUS-moku-PL-PST",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("got analyses %v, want %v", got, expected)
+	}
+}
+
+func TestAnalysesKeepStem(t *testing.T) {
+	m := newMorphology(t)
+
+	// the suffix is the whole word, which leaves no stem
+	got := readings(m.Analyses("an"))
+	if !slices.Equal(got, []string{"an"}) {
+		t.Errorf("got analyses %v, want only the word itself", got)
+	}
+
+	if len(m.Analyses("")) != 0 {
+		t.Errorf("the empty word has analyses")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := morphology.Tokenize(" «mi  moku-an,» jan'a — ")
+	expected := []morphology.Token{
+		{Text: "«mi", Word: "mi"},
+		{Text: "moku-an,»", Word: "moku-an"},
+		{Text: "jan'a", Word: "jan'a"},
+		{Text: "—", Word: ""},
+	}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("got tokens %+v, want %+v", tokens, expected)
+	}
+}
+
+type ConfigValue struct {
+	name   string
+	config morphology.Config
+	err    error
+}
+
+func TestConfigErrors(t *testing.T) {
+	configValues := []ConfigValue{
+		{"no form", morphology.Config{Prefixes: []morphology.Affix{{Gloss: "CAUS"}}}, morphology.ErrNoForm},
+		{"no gloss", morphology.Config{Suffixes: []morphology.Affix{{Form: "an"}}}, morphology.ErrNoAffixGloss},
+		{"space in form", morphology.Config{Suffixes: []morphology.Affix{{Form: "a n", Gloss: "PL"}}}, morphology.ErrInvalidForm},
+		{
+			"duplicate affix",
+			morphology.Config{Suffixes: []morphology.Affix{{Form: "an", Gloss: "PL"}, {Form: "AN", Gloss: "DU"}}},
+			morphology.ErrDuplicateAffix,
+		},
+	}
+
+	for _, test := range configValues {
+		_, err := morphology.New(test.config)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v: got error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestNewMorphology(t *testing.T) {
+	t.Setenv("MORPHOLOGY_FILE", "")
+	_, err := morphology.NewMorphology()
+	if err != nil {
+		t.Fatalf("could not load default morphology: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "morphology.json")
+	err = os.WriteFile(path, []byte(`{"suffixes": [{"form": "an", "gloss": "PL"}]}`), 0o644)
+	if err != nil {
+		t.Fatalf("could not write morphology: %v", err)
+	}
+	t.Setenv("MORPHOLOGY_FILE", path)
+
+	m, err := morphology.NewMorphology()
+	if err != nil {
+		t.Fatalf("could not load morphology file: %v", err)
+	}
+	got := readings(m.Analyses("mokuan"))
+	if !slices.Equal(got, []string{"mokuan", "moku-PL"}) {
+		t.Errorf("got analyses %v from file, want the suffix to be stripped", got)
+	}
+}
@@ -0,0 +1,168 @@
+package phonology
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"unicode/utf8"
+
+	"wilin.info/api/server/search"
+)
+
+// MAX_GENERATE_ATTEMPTS is how many candidates are tried for each
+// word asked for before giving up, since most of them may already
+// exist or break the rules once the dictionary is large
+const MAX_GENERATE_ATTEMPTS = 200
+
+// MAX_PATTERN_LENGTH and MAX_PATTERN_GROUPS bound the patterns
+// words are generated from, since every optional group doubles the
+// number of patterns a template expands to
+const (
+	MAX_PATTERN_LENGTH = 32
+	MAX_PATTERN_GROUPS = 6
+)
+
+var (
+	ErrUnusablePattern = errors.New("pattern needs a class of segments that is not in the inventory")
+	ErrPatternTooLarge = fmt.Errorf("pattern must be at most %v characters with at most %v optional groups", MAX_PATTERN_LENGTH, MAX_PATTERN_GROUPS)
+)
+
+// CheckPattern returns ErrPatternTooLarge if pattern is
+// too large to be expanded into the patterns it allows
+func CheckPattern(pattern string) error {
+	if utf8.RuneCountInString(pattern) > MAX_PATTERN_LENGTH || strings.Count(pattern, "(") > MAX_PATTERN_GROUPS {
+		return ErrPatternTooLarge
+	}
+	return nil
+}
+
+// GenerateOptions describes the words to generate. Words follow
+// Pattern, a template of C and V such as "CV(C)CV", or are made
+// of Syllables syllables if there is no pattern. Candidates that
+// sound within Distance edits of a word in Exclude are left out
+type GenerateOptions struct {
+	Count     int
+	Syllables int
+	Pattern   string
+	Exclude   []string
+	Distance  int
+}
+
+// Generate returns at most options.Count new words that follow the
+// phonology, using rng so that the same seed gives the same words.
+// Fewer words are returned if not enough could be found. It stops
+// with the error of ctx once ctx is done
+func (v *Validator) Generate(ctx context.Context, rng *rand.Rand, options GenerateOptions) ([]string, error) {
+	var patterns [][]rune
+	if options.Pattern != "" {
+		err := CheckPattern(options.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := expandTemplate(options.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, pattern := range expanded {
+			if !v.canFill(pattern) {
+				return nil, ErrUnusablePattern
+			}
+		}
+		patterns = expanded
+	}
+
+	syllables := [][]rune{}
+	for _, pattern := range v.syllables {
+		if v.canFill(pattern) {
+			syllables = append(syllables, pattern)
+		}
+	}
+	if len(patterns) < 1 && len(syllables) < 1 {
+		return nil, ErrUnusablePattern
+	}
+
+	excluded := []string{}
+	for _, word := range options.Exclude {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		excluded = append(excluded, v.Sound(word))
+	}
+
+	words := []string{}
+	seen := map[string]bool{}
+	for attempt := 0; attempt < options.Count*MAX_GENERATE_ATTEMPTS && len(words) < options.Count; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var pattern []rune
+		if len(patterns) > 0 {
+			pattern = patterns[rng.IntN(len(patterns))]
+		} else {
+			for range options.Syllables {
+				pattern = append(pattern, syllables[rng.IntN(len(syllables))]...)
+			}
+		}
+
+		word := v.fill(rng, pattern)
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		if len(v.Validate(word)) > 0 {
+			continue
+		}
+		if len(patterns) < 1 && len(v.Syllables(word)) != options.Syllables {
+			continue
+		}
+		if v.soundsLike(word, excluded, options.Distance) {
+			continue
+		}
+
+		words = append(words, word)
+		excluded = append(excluded, v.Sound(word))
+	}
+	return words, nil
+}
+
+// canFill reports whether the inventory has
+// segments for every class of pattern
+func (v *Validator) canFill(pattern []rune) bool {
+	for _, class := range pattern {
+		if len(v.segments[class]) < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// fill picks a random segment for each class of pattern
+func (v *Validator) fill(rng *rand.Rand, pattern []rune) string {
+	var b strings.Builder
+	for _, class := range pattern {
+		segments := v.segments[class]
+		b.WriteString(segments[rng.IntN(len(segments))])
+	}
+	return b.String()
+}
+
+// soundsLike reports whether word sounds within
+// distance edits of any of the sounds in excluded
+func (v *Validator) soundsLike(word string, excluded []string, distance int) bool {
+	sound := v.Sound(word)
+	length := len([]rune(sound))
+	for _, other := range excluded {
+		difference := length - len([]rune(other))
+		if difference > distance || -difference > distance {
+			continue
+		}
+		if search.Distance(sound, other) <= distance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,397 @@
+// Package phonology checks that words are made of the sounds of the
+// language and follow its syllable structure.
+//
+// The inventory, the syllable templates and the clusters that are
+// never allowed are read from a JSON configuration, so the rules can
+// change as the language does without a new release.
+package phonology
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"wilin.info/api/server/search"
+)
+
+//go:embed phonology.json
+var defaultConfig []byte
+
+// Mode is what happens to words that break the rules
+type Mode string
+
+const (
+	MODE_REJECT Mode = "reject"
+	MODE_WARN   Mode = "warn"
+)
+
+// Reasons a word can be invalid
+const (
+	REASON_UNKNOWN_SEGMENT   = "unknown_segment"
+	REASON_FORBIDDEN_CLUSTER = "forbidden_cluster"
+	REASON_INVALID_SYLLABLE  = "invalid_syllable"
+)
+
+// Classes of segments, as written in syllable templates
+const (
+	CLASS_CONSONANT = 'C'
+	CLASS_VOWEL     = 'V'
+)
+
+var (
+	ErrUnknownMode      = errors.New("unknown phonology mode")
+	ErrUnknownStress    = errors.New("unknown stress placement")
+	ErrNoVowels         = errors.New("inventory has no vowels")
+	ErrDuplicateSegment = errors.New("segment appears twice in inventory")
+	ErrNoSyllables      = errors.New("no syllable templates")
+	ErrInvalidTemplate  = errors.New("invalid syllable template")
+)
+
+// Config is the phonology of the language. Segments may be longer
+// than one character, such as "ng", and are matched greedily.
+// Syllables are templates of C and V, where the parts in
+// parentheses are optional, such as "(C)V(C)". Characters in
+// Ignore separate words and are otherwise skipped.
+//
+// IPA maps segments to how they are pronounced, unless one of
+// the Rules applies first. Segments that are in neither are
+// pronounced the way they are written
+type Config struct {
+	Mode       Mode              `json:"mode"`
+	Vowels     []string          `json:"vowels"`
+	Consonants []string          `json:"consonants"`
+	Syllables  []string          `json:"syllables"`
+	Forbidden  []string          `json:"forbidden"`
+	Ignore     []string          `json:"ignore"`
+	IPA        map[string]string `json:"ipa"`
+	Rules      []Rule            `json:"rules"`
+	Stress     Stress            `json:"stress"`
+}
+
+// Violation is a rule that a word breaks. Offset counts the
+// characters of the word as it was given before the part that
+// breaks the rule, and Text is that part as a segment reads
+type Violation struct {
+	Reason string
+	Offset int
+	Text   string
+}
+
+// Segment is a single sound of a word along with the number of
+// characters before it in the word as it was given. Text is the
+// segment lowercased and in NFC, which is the form it is matched in
+type Segment struct {
+	Text   string
+	Offset int
+	Class  rune
+}
+
+type Validator struct {
+	mode      Mode
+	classes   map[string]rune
+	segments  map[rune][]string
+	longest   int
+	syllables [][]rune
+	forbidden [][]string
+	ignore    map[string]bool
+	ipa       map[string]string
+	rules     []Rule
+	stress    Stress
+}
+
+func New(config Config) (*Validator, error) {
+	if config.Mode == "" {
+		config.Mode = MODE_WARN
+	}
+	if config.Mode != MODE_REJECT && config.Mode != MODE_WARN {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMode, config.Mode)
+	}
+	if len(config.Vowels) < 1 {
+		return nil, ErrNoVowels
+	}
+	if len(config.Syllables) < 1 {
+		return nil, ErrNoSyllables
+	}
+	if !slices.Contains(STRESSES, config.Stress) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStress, config.Stress)
+	}
+
+	v := &Validator{
+		mode:     config.Mode,
+		classes:  map[string]rune{},
+		segments: map[rune][]string{},
+		ignore:   map[string]bool{},
+		ipa:      map[string]string{},
+		rules:    config.Rules,
+		stress:   config.Stress,
+	}
+	for segment, ipa := range config.IPA {
+		v.ipa[strings.ToLower(segment)] = ipa
+	}
+
+	inventory := []struct {
+		segments []string
+		class    rune
+	}{
+		{config.Vowels, CLASS_VOWEL},
+		{config.Consonants, CLASS_CONSONANT},
+	}
+	for _, group := range inventory {
+		for _, segment := range group.segments {
+			segment = strings.ToLower(segment)
+			_, ok := v.classes[segment]
+			if ok || segment == "" {
+				return nil, fmt.Errorf("%w: %q", ErrDuplicateSegment, segment)
+			}
+			v.classes[segment] = group.class
+			v.segments[group.class] = append(v.segments[group.class], segment)
+			v.longest = max(v.longest, utf8.RuneCountInString(segment))
+		}
+	}
+
+	for _, template := range config.Syllables {
+		patterns, err := expandTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		v.syllables = append(v.syllables, patterns...)
+	}
+
+	for _, ignored := range config.Ignore {
+		v.ignore[ignored] = true
+	}
+
+	for _, cluster := range config.Forbidden {
+		segments := []string{}
+		for _, segment := range v.Segments(cluster) {
+			segments = append(segments, search.Fold(segment.Text))
+		}
+		if len(segments) > 0 {
+			v.forbidden = append(v.forbidden, segments)
+		}
+	}
+
+	return v, nil
+}
+
+// Load reads the configuration stored as JSON in the file at path
+func Load(path string) (*Validator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Validator, error) {
+	var config Config
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	return New(config)
+}
+
+// Default returns the validator for the configuration built into
+// the binary, which only warns about invalid words
+func Default() *Validator {
+	v, err := Parse(defaultConfig)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default phonology: %v", err))
+	}
+	return v
+}
+
+// NewValidator creates the validator for the configuration in the
+// file named by the PHONOLOGY_FILE environment variable, or the
+// default configuration if it is not set
+func NewValidator() (*Validator, error) {
+	path := os.Getenv("PHONOLOGY_FILE")
+	if path == "" {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+func (v *Validator) Mode() Mode {
+	return v.mode
+}
+
+// Segments breaks word into the segments of the inventory.
+// Characters that are not part of any segment are kept on their
+// own with no class, and ignored characters are left out
+func (v *Validator) Segments(word string) []Segment {
+	segments := []Segment{}
+	for _, segment := range v.scan(word) {
+		if !v.ignore[segment.Text] {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// scan breaks word into segments like Segments, keeping the
+// ignored ones. The word is matched lowercased and in NFC, but
+// offsets count the characters of word as it was given
+func (v *Validator) scan(word string) []Segment {
+	normalized, offsets := normalize(word)
+
+	segments := []Segment{}
+	i := 0
+	for len(normalized) > 0 {
+		text := v.nextSegment(normalized)
+		normalized = normalized[len(text):]
+
+		segments = append(segments, Segment{
+			Text:   text,
+			Offset: offsets[i],
+			Class:  v.class(text),
+		})
+		i += utf8.RuneCountInString(text)
+	}
+	return segments
+}
+
+// normalize lowercases word and puts it in NFC. Along with it comes
+// the offset in word of the character each of its characters was
+// made from, so that a position in one can be found in the other
+func normalize(word string) (string, []int) {
+	var builder strings.Builder
+	offsets := []int{}
+
+	var iter norm.Iter
+	iter.InitString(norm.NFC, word)
+	for !iter.Done() {
+		offset := utf8.RuneCountInString(word[:iter.Pos()])
+		part := norm.NFC.String(strings.ToLower(string(iter.Next())))
+
+		builder.WriteString(part)
+		for range utf8.RuneCountInString(part) {
+			offsets = append(offsets, offset)
+		}
+	}
+	return builder.String(), offsets
+}
+
+// nextSegment returns the longest segment of the inventory that
+// word starts with, or its first character if it starts with none
+func (v *Validator) nextSegment(word string) string {
+	end := 0
+	for i := 0; i < v.longest && end < len(word); i++ {
+		_, size := utf8.DecodeRuneInString(word[end:])
+		end += size
+	}
+
+	for end > 0 {
+		_, ok := v.classes[word[:end]]
+		if ok {
+			return word[:end]
+		}
+		_, size := utf8.DecodeLastRuneInString(word[:end])
+		end -= size
+	}
+
+	_, size := utf8.DecodeRuneInString(word)
+	return word[:size]
+}
+
+// class returns the class of segment, which is the class of the
+// segment without diacritics if it is not in the inventory itself
+func (v *Validator) class(segment string) rune {
+	class, ok := v.classes[segment]
+	if ok {
+		return class
+	}
+	return v.classes[search.Fold(segment)]
+}
+
+// words splits entry into the runs of segments between
+// ignored characters, such as the parts of a compound
+func (v *Validator) words(entry string) [][]Segment {
+	words := [][]Segment{}
+	var word []Segment
+	for _, segment := range v.scan(entry) {
+		if v.ignore[segment.Text] {
+			if len(word) > 0 {
+				words = append(words, word)
+			}
+			word = nil
+			continue
+		}
+		word = append(word, segment)
+	}
+	if len(word) > 0 {
+		words = append(words, word)
+	}
+	return words
+}
+
+// Validate returns every rule that entry breaks, in the order
+// they appear in entry. Words with segments that are not in the
+// inventory are not checked any further
+func (v *Validator) Validate(entry string) []Violation {
+	violations := []Violation{}
+	for _, word := range v.words(entry) {
+		unknown := false
+		for _, segment := range word {
+			if segment.Class == 0 {
+				unknown = true
+				violations = append(violations, Violation{
+					Reason: REASON_UNKNOWN_SEGMENT,
+					Offset: segment.Offset,
+					Text:   segment.Text,
+				})
+			}
+		}
+		if unknown {
+			continue
+		}
+
+		violations = append(violations, v.forbiddenClusters(word)...)
+
+		_, stuck := v.syllabify(word)
+		if stuck < len(word) {
+			violations = append(violations, Violation{
+				Reason: REASON_INVALID_SYLLABLE,
+				Offset: word[stuck].Offset,
+				Text:   word[stuck].Text,
+			})
+		}
+	}
+	return violations
+}
+
+func (v *Validator) forbiddenClusters(word []Segment) []Violation {
+	violations := []Violation{}
+	for i := range word {
+		for _, cluster := range v.forbidden {
+			if i+len(cluster) > len(word) {
+				continue
+			}
+
+			matches := true
+			text := ""
+			for j, segment := range cluster {
+				if search.Fold(word[i+j].Text) != segment {
+					matches = false
+					break
+				}
+				text += word[i+j].Text
+			}
+			if matches {
+				violations = append(violations, Violation{
+					Reason: REASON_FORBIDDEN_CLUSTER,
+					Offset: word[i].Offset,
+					Text:   text,
+				})
+			}
+		}
+	}
+	return violations
+}
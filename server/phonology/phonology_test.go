@@ -0,0 +1,275 @@
+package phonology_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/search"
+)
+
+func newValidator(t *testing.T) *phonology.Validator {
+	t.Helper()
+
+	v, err := phonology.New(phonology.Config{
+		Mode:       phonology.MODE_REJECT,
+		Vowels:     []string{"a", "e", "i", "o", "u"},
+		Consonants: []string{"k", "l", "m", "n", "ng", "p", "s", "t", "w"},
+		Syllables:  []string{"(C)V(C)"},
+		Forbidden:  []string{"wu", "ti"},
+		Ignore:     []string{"-", " "},
+	})
+	if err != nil {
+		t.Fatalf("could not create validator: %v", err)
+	}
+	return v
+}
+
+func TestSegments(t *testing.T) {
+	v := newValidator(t)
+
+	segments := []string{}
+	for _, segment := range v.Segments("Ngawin-ka") {
+		segments = append(segments, segment.Text)
+	}
+	expected := []string{"ng", "a", "w", "i", "n", "k", "a"}
+	if !slices.Equal(segments, expected) {
+		t.Errorf("got segments %v, want %v", segments, expected)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		entry    string
+		expected []phonology.Violation
+	}{
+		{"wilin", []phonology.Violation{}},
+		{"Kán", []phonology.Violation{}},
+		{"wilin-ka nga", []phonology.Violation{}},
+		{"wibin", []phonology.Violation{{phonology.REASON_UNKNOWN_SEGMENT, 2, "b"}}},
+		{"kala wuta", []phonology.Violation{{phonology.REASON_FORBIDDEN_CLUSTER, 5, "wu"}}},
+		{"aksa", []phonology.Violation{}},
+		{"aksta", []phonology.Violation{{phonology.REASON_INVALID_SYLLABLE, 2, "s"}}},
+		{"kalamn", []phonology.Violation{{phonology.REASON_INVALID_SYLLABLE, 5, "n"}}},
+		{"tikb", []phonology.Violation{{phonology.REASON_UNKNOWN_SEGMENT, 3, "b"}}},
+		{"WIBIN", []phonology.Violation{{phonology.REASON_UNKNOWN_SEGMENT, 2, "b"}}},
+		{"ka\u0301bin", []phonology.Violation{{phonology.REASON_UNKNOWN_SEGMENT, 3, "b"}}},
+		{"ka\u0301la-wuta", []phonology.Violation{{phonology.REASON_FORBIDDEN_CLUSTER, 6, "wu"}}},
+		{"tikst", []phonology.Violation{
+			{phonology.REASON_FORBIDDEN_CLUSTER, 0, "ti"},
+			{phonology.REASON_INVALID_SYLLABLE, 3, "s"},
+		}},
+	}
+	for _, test := range tests {
+		violations := v.Validate(test.entry)
+		if !slices.Equal(violations, test.expected) {
+			t.Errorf("Validate(%q) = %v, want %v", test.entry, violations, test.expected)
+		}
+	}
+}
+
+func TestSyllables(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		entry    string
+		expected []string
+	}{
+		{"wilinka", []string{"wi", "lin", "ka"}},
+		{"anana", []string{"a", "na", "na"}},
+		{"kan-a", []string{"kan", "a"}},
+		{"aksta", []string{"aksta"}},
+	}
+	for _, test := range tests {
+		syllables := v.Syllables(test.entry)
+		if !slices.Equal(syllables, test.expected) {
+			t.Errorf("Syllables(%q) = %v, want %v", test.entry, syllables, test.expected)
+		}
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	v, err := phonology.New(phonology.Config{
+		Vowels:     []string{"a", "e", "i", "o", "u"},
+		Consonants: []string{"j", "k", "l", "n", "ng", "s", "t", "w"},
+		Syllables:  []string{"(C)V(C)"},
+		Ignore:     []string{"-", " "},
+		IPA:        map[string]string{"j": "dʒ", "ng": "ŋ"},
+		Rules: []phonology.Rule{
+			{Segment: "n", IPA: "ŋ", Following: []string{"k"}},
+			{Segment: "s", IPA: "z", Preceding: []string{"V"}, Following: []string{"V"}},
+			{Segment: "e", IPA: "ə", Following: []string{phonology.WORD_BOUNDARY}},
+		},
+		Stress: phonology.STRESS_PENULTIMATE,
+	})
+	if err != nil {
+		t.Fatalf("could not create validator: %v", err)
+	}
+
+	tests := []struct {
+		text      string
+		ipa       string
+		syllables []string
+	}{
+		{"wilin", "ˈwi.lin", []string{"wi", "lin"}},
+		{"wilinka", "wi.ˈliŋ.ka", []string{"wi", "lin", "ka"}},
+		{"Jan", "dʒan", []string{"jan"}},
+		{"ngasa kase", "ˈŋa.za ˈka.zə", []string{"nga", "sa", "ka", "se"}},
+		{"kán", "kán", []string{"kán"}},
+		{"akst", "akst", []string{"akst"}},
+		{"", "", []string{}},
+	}
+	for _, test := range tests {
+		transcription := v.Transcribe(test.text)
+		if transcription.IPA != test.ipa || !slices.Equal(transcription.Syllables, test.syllables) {
+			t.Errorf("Transcribe(%q) = %q %v, want %q %v", test.text, transcription.IPA, transcription.Syllables, test.ipa, test.syllables)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	v := newValidator(t)
+
+	options := phonology.GenerateOptions{
+		Count:     20,
+		Syllables: 2,
+		Exclude:   []string{"kala"},
+		Distance:  1,
+	}
+	words, err := v.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), options)
+	if err != nil {
+		t.Fatalf("could not generate words: %v", err)
+	}
+	if len(words) != options.Count {
+		t.Fatalf("generated %v words, want %v", len(words), options.Count)
+	}
+
+	for _, word := range words {
+		if violations := v.Validate(word); len(violations) > 0 {
+			t.Errorf("generated %q, which breaks %v", word, violations)
+		}
+		if syllables := v.Syllables(word); len(syllables) != 2 {
+			t.Errorf("generated %q, which has syllables %v", word, syllables)
+		}
+		if search.Distance(word, "kala") <= 1 {
+			t.Errorf("generated %q, which sounds like kala", word)
+		}
+	}
+
+	again, _ := v.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), options)
+	if !slices.Equal(words, again) {
+		t.Errorf("same seed generated %v and then %v", words, again)
+	}
+
+	options.Pattern = "CV(n)"
+	_, err = v.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), options)
+	if !errors.Is(err, phonology.ErrInvalidTemplate) {
+		t.Errorf("invalid pattern: got error %v, want %v", err, phonology.ErrInvalidTemplate)
+	}
+
+	options.Pattern = "VCV"
+	words, _ = v.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), options)
+	for _, word := range words {
+		segments := v.Segments(word)
+		if len(segments) != 3 || segments[0].Class != phonology.CLASS_VOWEL || segments[1].Class != phonology.CLASS_CONSONANT {
+			t.Errorf("generated %q, which does not follow VCV", word)
+		}
+	}
+
+	options.Pattern = strings.Repeat("(C)", phonology.MAX_PATTERN_GROUPS+1) + "V"
+	_, err = v.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), options)
+	if !errors.Is(err, phonology.ErrPatternTooLarge) {
+		t.Errorf("pattern with too many groups: got error %v, want %v", err, phonology.ErrPatternTooLarge)
+	}
+
+	options.Pattern = "CV"
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = v.Generate(canceled, rand.New(rand.NewPCG(1, 2)), options)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: got error %v, want %v", err, context.Canceled)
+	}
+
+	vowels, _ := phonology.New(phonology.Config{Vowels: []string{"a"}, Syllables: []string{"V"}})
+	_, err = vowels.Generate(context.Background(), rand.New(rand.NewPCG(1, 2)), phonology.GenerateOptions{Count: 1, Pattern: "CV"})
+	if !errors.Is(err, phonology.ErrUnusablePattern) {
+		t.Errorf("pattern without consonants: got error %v, want %v", err, phonology.ErrUnusablePattern)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	config := phonology.Config{
+		Vowels:    []string{"a"},
+		Syllables: []string{"CV"},
+	}
+
+	tests := []struct {
+		name     string
+		change   func(c *phonology.Config)
+		expected error
+	}{
+		{"unknown mode", func(c *phonology.Config) { c.Mode = "ignore" }, phonology.ErrUnknownMode},
+		{"no vowels", func(c *phonology.Config) { c.Vowels = nil }, phonology.ErrNoVowels},
+		{"no syllables", func(c *phonology.Config) { c.Syllables = nil }, phonology.ErrNoSyllables},
+		{"duplicate segment", func(c *phonology.Config) { c.Consonants = []string{"A"} }, phonology.ErrDuplicateSegment},
+		{"unknown class", func(c *phonology.Config) { c.Syllables = []string{"CVX"} }, phonology.ErrInvalidTemplate},
+		{"unclosed group", func(c *phonology.Config) { c.Syllables = []string{"(CV"} }, phonology.ErrInvalidTemplate},
+		{"empty group", func(c *phonology.Config) { c.Syllables = []string{"()V"} }, phonology.ErrInvalidTemplate},
+		{"unknown stress", func(c *phonology.Config) { c.Stress = "second" }, phonology.ErrUnknownStress},
+	}
+	for _, test := range tests {
+		c := config
+		test.change(&c)
+		_, err := phonology.New(c)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%v: got error %v, want %v", test.name, err, test.expected)
+		}
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	t.Setenv("PHONOLOGY_FILE", "")
+	v, err := phonology.NewValidator()
+	if err != nil {
+		t.Fatalf("could not load default phonology: %v", err)
+	}
+	if v.Mode() != phonology.MODE_WARN {
+		t.Errorf("default phonology has mode %v, want %v", v.Mode(), phonology.MODE_WARN)
+	}
+
+	// ng is one segment, as it is one letter of the alphabet
+	for _, word := range []string{"nga", "wilinga", "kan"} {
+		if violations := v.Validate(word); len(violations) > 0 {
+			t.Errorf("default phonology: Validate(%q) = %v, want no violations", word, violations)
+		}
+	}
+	if segments := v.Segments("nga"); len(segments) != 2 || segments[0].Text != "ng" {
+		t.Errorf("default phonology: Segments(%q) = %+v, want ng, a", "nga", segments)
+	}
+
+	path := filepath.Join(t.TempDir(), "phonology.json")
+	err = os.WriteFile(path, []byte(`{"mode": "reject", "vowels": ["a"], "syllables": ["V"]}`), 0o644)
+	if err != nil {
+		t.Fatalf("could not write phonology: %v", err)
+	}
+
+	t.Setenv("PHONOLOGY_FILE", path)
+	v, err = phonology.NewValidator()
+	if err != nil {
+		t.Fatalf("could not load phonology file: %v", err)
+	}
+	if v.Mode() != phonology.MODE_REJECT {
+		t.Errorf("phonology file has mode %v, want %v", v.Mode(), phonology.MODE_REJECT)
+	}
+	if len(v.Validate("aa")) != 0 || len(v.Validate("ab")) != 1 {
+		t.Errorf("phonology file does not allow only vowels")
+	}
+}
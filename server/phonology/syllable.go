@@ -0,0 +1,136 @@
+package phonology
+
+import (
+	"fmt"
+	"slices"
+)
+
+// expandTemplate returns every pattern of classes that template
+// allows, from the shortest to the longest, so that "(C)V(C)"
+// becomes V, CV, VC and CVC
+func expandTemplate(template string) ([][]rune, error) {
+	patterns := [][]rune{{}}
+	optional := false
+	var group []rune
+
+	for _, r := range template {
+		switch {
+		case r == '(' && !optional:
+			optional = true
+			group = nil
+		case r == ')' && optional:
+			if len(group) < 1 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidTemplate, template)
+			}
+			optional = false
+			expanded := [][]rune{}
+			for _, pattern := range patterns {
+				expanded = append(expanded, pattern)
+				expanded = append(expanded, append(slices.Clone(pattern), group...))
+			}
+			patterns = expanded
+		case r == CLASS_CONSONANT || r == CLASS_VOWEL:
+			if optional {
+				group = append(group, r)
+				continue
+			}
+			for i := range patterns {
+				patterns[i] = append(patterns[i], r)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTemplate, template)
+		}
+	}
+
+	if optional {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTemplate, template)
+	}
+
+	patterns = slices.DeleteFunc(patterns, func(pattern []rune) bool {
+		return len(pattern) < 1
+	})
+	if len(patterns) < 1 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTemplate, template)
+	}
+	slices.SortStableFunc(patterns, func(a []rune, b []rune) int {
+		return len(a) - len(b)
+	})
+	return patterns, nil
+}
+
+// matches reports whether the segments of word starting
+// at start have the classes of pattern
+func matches(word []Segment, start int, pattern []rune) bool {
+	if start+len(pattern) > len(word) {
+		return false
+	}
+	for i, class := range pattern {
+		if word[start+i].Class != class {
+			return false
+		}
+	}
+	return true
+}
+
+// syllabify splits word into syllables that each match a template.
+// Each syllable is kept as short as it can be while the rest of the
+// word can still be split, so that consonants go to the onset of
+// the next syllable when possible. If word cannot be split, it
+// returns the index of the segment that no syllable can continue
+// from, and len(word) otherwise
+func (v *Validator) syllabify(word []Segment) ([][]Segment, int) {
+	// splits[i] is true if the segments from i onward can be split
+	splits := make([]bool, len(word)+1)
+	splits[len(word)] = true
+	for i := len(word) - 1; i >= 0; i-- {
+		for _, pattern := range v.syllables {
+			if matches(word, i, pattern) && splits[i+len(pattern)] {
+				splits[i] = true
+				break
+			}
+		}
+	}
+
+	if !splits[0] {
+		return nil, v.furthestSyllable(word)
+	}
+
+	syllables := [][]Segment{}
+	for i := 0; i < len(word); {
+		for _, pattern := range v.syllables {
+			if matches(word, i, pattern) && splits[i+len(pattern)] {
+				syllables = append(syllables, word[i:i+len(pattern)])
+				i += len(pattern)
+				break
+			}
+		}
+	}
+	return syllables, len(word)
+}
+
+// furthestSyllable returns the index of the furthest segment
+// of word that a sequence of valid syllables can end before
+func (v *Validator) furthestSyllable(word []Segment) int {
+	reached := make([]bool, len(word)+1)
+	reached[0] = true
+	furthest := 0
+	for i := range word {
+		if !reached[i] {
+			continue
+		}
+		furthest = i
+		for _, pattern := range v.syllables {
+			if matches(word, i, pattern) {
+				reached[i+len(pattern)] = true
+			}
+		}
+	}
+	return furthest
+}
+
+// Syllables splits entry into syllables, joining the segments of
+// each syllable. Parts of entry that cannot be split are returned
+// whole as a single syllable
+func (v *Validator) Syllables(entry string) []string {
+	return v.Transcribe(entry).Syllables
+}
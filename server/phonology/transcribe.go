@@ -0,0 +1,157 @@
+package phonology
+
+import (
+	"slices"
+	"strings"
+
+	"wilin.info/api/server/search"
+)
+
+// Stress is which syllable of a word is stressed
+type Stress string
+
+const (
+	STRESS_NONE        Stress = ""
+	STRESS_INITIAL     Stress = "initial"
+	STRESS_PENULTIMATE Stress = "penultimate"
+	STRESS_FINAL       Stress = "final"
+)
+
+var STRESSES = []Stress{STRESS_NONE, STRESS_INITIAL, STRESS_PENULTIMATE, STRESS_FINAL}
+
+const (
+	STRESS_MARK        = "ˈ"
+	SYLLABLE_SEPARATOR = "."
+
+	// WORD_BOUNDARY stands for the start or the end
+	// of a word in the context of a rule
+	WORD_BOUNDARY = "#"
+)
+
+// Rule pronounces Segment as IPA when the segment before it is one
+// of Preceding and the segment after it is one of Following. Either
+// may be left empty to match anything, and may hold the classes C
+// and V or WORD_BOUNDARY as well as segments, so that a rule for "n"
+// with the IPA "ŋ" and Following ["k"] is pronounced as in "sink"
+type Rule struct {
+	Segment   string   `json:"segment"`
+	IPA       string   `json:"ipa"`
+	Preceding []string `json:"preceding"`
+	Following []string `json:"following"`
+}
+
+// Transcription is how a text is pronounced and
+// the syllables that it is split into
+type Transcription struct {
+	IPA       string
+	Syllables []string
+}
+
+// Transcribe returns the pronunciation of text in IPA. Syllables are
+// separated by SYLLABLE_SEPARATOR and the stressed syllable of words
+// with more than one is marked with STRESS_MARK. Words that cannot
+// be split into syllables are transcribed segment by segment
+func (v *Validator) Transcribe(text string) Transcription {
+	transcription := Transcription{Syllables: []string{}}
+
+	words := []string{}
+	for _, word := range v.words(text) {
+		split, stuck := v.syllabify(word)
+		if stuck < len(word) {
+			split = [][]Segment{word}
+		}
+
+		stressed := -1
+		if stuck == len(word) && len(split) > 1 {
+			stressed = v.stressed(len(split))
+		}
+
+		syllables := []string{}
+		i := 0
+		for s, syllable := range split {
+			text := ""
+			ipa := ""
+			for _, segment := range syllable {
+				text += segment.Text
+				ipa += v.pronounce(word, i)
+				i++
+			}
+			if s == stressed {
+				ipa = STRESS_MARK + ipa
+			}
+
+			transcription.Syllables = append(transcription.Syllables, text)
+			syllables = append(syllables, ipa)
+		}
+		words = append(words, strings.Join(syllables, SYLLABLE_SEPARATOR))
+	}
+
+	transcription.IPA = strings.Join(words, " ")
+	return transcription
+}
+
+// Sound returns the pronunciation of word without the marks for
+// syllables and stress, so that words can be compared by how they
+// sound rather than how they are written
+func (v *Validator) Sound(word string) string {
+	ipa := v.Transcribe(word).IPA
+	ipa = strings.ReplaceAll(ipa, STRESS_MARK, "")
+	ipa = strings.ReplaceAll(ipa, SYLLABLE_SEPARATOR, "")
+	return search.Fold(ipa)
+}
+
+// stressed returns the index of the stressed syllable
+// of a word with count syllables, or -1 if there is none
+func (v *Validator) stressed(count int) int {
+	switch v.stress {
+	case STRESS_INITIAL:
+		return 0
+	case STRESS_PENULTIMATE:
+		return count - 2
+	case STRESS_FINAL:
+		return count - 1
+	default:
+		return -1
+	}
+}
+
+// pronounce returns the IPA of the segment of word at index i
+func (v *Validator) pronounce(word []Segment, i int) string {
+	segment := word[i]
+	folded := search.Fold(segment.Text)
+
+	for _, rule := range v.rules {
+		if rule.Segment != segment.Text && rule.Segment != folded {
+			continue
+		}
+		if v.inContext(word, i-1, rule.Preceding) && v.inContext(word, i+1, rule.Following) {
+			return rule.IPA
+		}
+	}
+
+	ipa, ok := v.ipa[segment.Text]
+	if ok {
+		return ipa
+	}
+	ipa, ok = v.ipa[folded]
+	if ok {
+		return ipa
+	}
+	return segment.Text
+}
+
+// inContext reports whether the segment of word at index i, which
+// may be past either end of word, is one of context
+func (v *Validator) inContext(word []Segment, i int, context []string) bool {
+	if len(context) < 1 {
+		return true
+	}
+	if i < 0 || i >= len(word) {
+		return slices.Contains(context, WORD_BOUNDARY)
+	}
+
+	segment := word[i]
+	return slices.Contains(context, segment.Text) ||
+		slices.Contains(context, search.Fold(segment.Text)) ||
+		slices.Contains(context, string(segment.Class))
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"wilin.info/api/database"
 	"wilin.info/api/database/users"
 	"wilin.info/api/server/services"
 )
@@ -33,15 +34,17 @@ type UserDTO struct {
 	Username string `json:"username" form:"username"`
 	Password string `json:"password,omitempty" form:"password"`
 	Role     string `json:"role" form:"role"`
+	Verified bool   `json:"verified" form:"verified"`
 }
 
-func NewUserDTO(id int, email string, username string, password string, role string) UserDTO {
+func NewUserDTO(id int, email string, username string, password string, role string, verified bool) UserDTO {
 	return UserDTO{
 		ID:       id,
 		Email:    email,
 		Username: username,
 		Password: password,
 		Role:     role,
+		Verified: verified,
 	}
 }
 
@@ -80,7 +83,7 @@ const (
 	PasswordTooLong  = "password too long"
 )
 
-func validateSignUpFields(ctx context.Context, userQueries *users.Queries, fields SignUpFields) (int, error) {
+func validateSignUpFields(ctx context.Context, userQueries users.Querier, fields SignUpFields) (int, error) {
 	if !services.IsValidEmail(fields.Email) {
 		return http.StatusBadRequest, errors.New(InvalidEmail)
 	}
@@ -125,7 +128,7 @@ func (r *Router) ExtractUserID(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		tokenString := authParts[1]
-		claims, err := services.ParseToken(tokenString)
+		claims, err := services.ParseAuthToken(tokenString)
 		if err != nil {
 			return next(ctx)
 		}
@@ -135,7 +138,21 @@ func (r *Router) ExtractUserID(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(ctx)
 		}
 
+		// a token stops working as soon as its session is ended,
+		// rather than when the token itself expires
+		s, err := r.sessionQueries.ReadSessionByID(ctx.Request().Context(), claims.SessionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return next(ctx)
+			}
+			return serverError(ctx, err, ServerError)
+		}
+		if s.Revoked || s.UserID != int32(userID) || isExpired(&s.ExpiredAt) {
+			return next(ctx)
+		}
+
 		ctx.Set("userID", userID)
+		ctx.Set("sessionID", claims.SessionID)
 		return next(ctx)
 	}
 }
@@ -149,7 +166,13 @@ func (r *Router) HandleSignUp(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	statusCode, err := validateSignUpFields(r.ctx, r.userQueries, *signUpFields)
+	signUpFields.Email, err = services.NormalizeEmail(signUpFields.Email)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidEmail)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	statusCode, err := validateSignUpFields(ctx.Request().Context(), r.userQueries, *signUpFields)
 	if err != nil {
 		errJSON := NewErrorJson(err.Error())
 		return ctx.JSON(statusCode, errJSON)
@@ -157,8 +180,7 @@ func (r *Router) HandleSignUp(ctx echo.Context) error {
 
 	passwordHash, err := services.GeneratePasswordHash(signUpFields.Password)
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	params := users.CreateUserParams{
@@ -167,16 +189,14 @@ func (r *Router) HandleSignUp(ctx echo.Context) error {
 		Password: passwordHash,
 		Role:     services.ROLE_USER.String(),
 	}
-	result, err := r.userQueries.CreateUser(r.ctx, params)
+	result, err := r.userQueries.CreateUser(ctx.Request().Context(), params)
 	if err != nil {
-		errJSON := NewErrorJson("failed to create user")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, "failed to create user")
 	}
 
 	userID, err := result.LastInsertId()
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	userDTO := NewUserDTO(
@@ -185,8 +205,15 @@ func (r *Router) HandleSignUp(ctx echo.Context) error {
 		signUpFields.Username,
 		"",
 		services.ROLE_USER.String(),
+		false,
 	)
 
+	err = r.sendVerification(ctx.Request().Context(), int32(userID), signUpFields.Email, signUpFields.Username)
+	if err != nil {
+		// the user can still ask for a new link once logged in
+		ctx.Logger().Errorf("could not send verification mail: %v\n", err)
+	}
+
 	return ctx.JSON(http.StatusCreated, userDTO)
 }
 
@@ -199,11 +226,10 @@ func (r *Router) HandleLogin(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	user, err := r.userQueries.ReadUserByUsername(r.ctx, loginFields.Username)
+	user, err := r.userQueries.ReadUserByUsername(ctx.Request().Context(), loginFields.Username)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
-			errJSON := NewErrorJson(ServerError)
-			return ctx.JSON(http.StatusInternalServerError, errJSON)
+			return serverError(ctx, err, ServerError)
 		}
 
 		// run a fake hash to simulate invalid password
@@ -220,28 +246,23 @@ func (r *Router) HandleLogin(ctx echo.Context) error {
 		return ctx.JSON(http.StatusUnauthorized, errJSON)
 	}
 
-	userID := strconv.Itoa(int(user.ID))
-
-	authToken, err := services.GenerateToken("authToken", userID, TIME_TO_AUTH_EXPIRE_MINUTES)
-	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
-
-	refreshToken, err := services.GenerateToken("refreshToken", userID, TIME_TO_REFRESH_EXPIRE_MINUTES)
+	var tokensDTO TokensDTO
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		tokensDTO, err = r.createSession(ctx.Request().Context(), tx, user.ID, ctx.Request().UserAgent())
+		return err
+	})
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		ctx.Logger().Errorf("could not create session: %v", err.Error())
+		return serverError(ctx, err, ServerError)
 	}
 
-	tokensDTO := TokensDTO{AuthToken: authToken, RefreshToken: refreshToken}
-
 	userDTO := NewUserDTO(
 		int(user.ID),
 		user.Email,
 		user.Username,
 		"",
 		user.Role,
+		user.Verified,
 	)
 
 	loginReturnDTO := LoginReturnDTO{User: userDTO, TokensDTO: tokensDTO}
@@ -255,10 +276,9 @@ func (r *Router) GetMe(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	user, err := r.userQueries.ReadUserByID(r.ctx, int32(userID))
+	user, err := r.userQueries.ReadUserByID(ctx.Request().Context(), int32(userID))
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	userDTO := NewUserDTO(
@@ -267,6 +287,7 @@ func (r *Router) GetMe(ctx echo.Context) error {
 		user.Username,
 		"",
 		user.Role,
+		user.Verified,
 	)
 
 	return ctx.JSON(http.StatusOK, userDTO)
@@ -280,21 +301,55 @@ func (r *Router) HandleRefresh(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	claims, err := services.ParseToken(tokens.RefreshToken)
+	claims, err := services.ParseRefreshToken(tokens.RefreshToken)
 	if err != nil {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	userID := claims.Subject
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
 
-	token, err := services.GenerateToken("authToken", userID, TIME_TO_AUTH_EXPIRE_MINUTES)
+	refreshToken, err := r.sessionQueries.ReadRefreshTokenByID(ctx.Request().Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		return serverError(ctx, err, ServerError)
+	}
+	if isExpired(&refreshToken.ExpiredAt) {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
 
+	s, err := r.sessionQueries.ReadSessionByID(ctx.Request().Context(), refreshToken.SessionID)
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
+	}
+
+	if s.Revoked || s.UserID != int32(userID) || isExpired(&s.ExpiredAt) {
+		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	tokensDTO := TokensDTO{AuthToken: token}
+	var tokensDTO TokensDTO
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		tokensDTO, err = r.rotateTokens(ctx.Request().Context(), tx, refreshToken, s.UserID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrTokenReused) {
+			// the whole session is compromised once a refresh token is replayed
+			ctx.Logger().Warnf("refresh token reused, revoking session %v", s.ID)
+			_, err = r.sessionQueries.RevokeSession(ctx.Request().Context(), s.ID)
+			if err != nil {
+				ctx.Logger().Errorf("could not revoke session: %v", err.Error())
+			}
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+
+		ctx.Logger().Errorf("could not rotate tokens: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
 
 	return ctx.JSON(http.StatusOK, tokensDTO)
 }
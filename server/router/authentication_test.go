@@ -0,0 +1,121 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"wilin.info/api/database/session"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+func TestAccountRoutes(t *testing.T) {
+	forEachStore(t, testAccountRoutes)
+}
+
+func testAccountRoutes(t *testing.T, s *testServer) {
+
+	signUp := router.SignUpFields{Email: "jan@wilin.info", Username: "jan", Password: TEST_PASSWORD}
+	routeValues := []RouteValue{
+		{"sign up", http.MethodPost, "/signup", signUp, "", http.StatusCreated},
+		{"sign up twice", http.MethodPost, "/signup", signUp, "", http.StatusConflict},
+		{"sign up twice with a display name", http.MethodPost, "/signup", router.SignUpFields{Email: "Jan <jan@wilin.info>", Username: "jan2", Password: TEST_PASSWORD}, "", http.StatusConflict},
+		{"sign up with short password", http.MethodPost, "/signup", router.SignUpFields{Email: "a@wilin.info", Username: "a", Password: "short"}, "", http.StatusBadRequest},
+		{"log in with wrong password", http.MethodPost, "/login", router.LoginFields{Username: "jan", Password: "wrong-password"}, "", http.StatusUnauthorized},
+		{"verify with invalid token", http.MethodPost, "/verify/abc", nil, "", http.StatusNotFound},
+	}
+	runRoutes(t, s, routeValues)
+
+	login := s.login(t, "jan", TEST_PASSWORD)
+	if login.User.Verified {
+		t.Errorf("new user is verified before following the link")
+	}
+
+	rec := s.request(t, http.MethodPost, "/verify", nil, login.AuthToken)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("POST /verify = %v %v", rec.Code, rec.Body.String())
+	}
+
+	verificationID := s.mailer.link(t, signUp.Email, "/verify")
+	rec = s.request(t, http.MethodPost, "/verify/"+verificationID, nil, "")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("POST /verify/:token = %v %v", rec.Code, rec.Body.String())
+	}
+
+	rec = s.request(t, http.MethodGet, "/me", nil, login.AuthToken)
+	me := decode[router.UserDTO](t, rec)
+	if !me.Verified || me.Username != "jan" {
+		t.Errorf("GET /me = %+v, want verified jan", me)
+	}
+
+	other := s.login(t, "jan", TEST_PASSWORD)
+
+	rec = s.request(t, http.MethodGet, "/sessions", nil, login.AuthToken)
+	sessions := decode[router.SessionArrDTO](t, rec)
+	if len(sessions.Sessions) != 2 {
+		t.Fatalf("GET /sessions returned %v sessions, want 2", len(sessions.Sessions))
+	}
+
+	refresh := router.TokensDTO{RefreshToken: login.RefreshToken}
+	rec = s.request(t, http.MethodPost, "/refresh", refresh, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /refresh = %v %v", rec.Code, rec.Body.String())
+	}
+	rotated := decode[router.TokensDTO](t, rec)
+
+	sessionless, err := services.GenerateToken(services.TOKEN_TYPE_AUTH, strconv.Itoa(login.User.ID), 15)
+	if err != nil {
+		t.Fatalf("could not generate token: %v", err)
+	}
+
+	routeValues = []RouteValue{
+		{"reuse refresh token", http.MethodPost, "/refresh", refresh, "", http.StatusUnauthorized},
+		{"use rotated token of revoked session", http.MethodPost, "/refresh", rotated, "", http.StatusUnauthorized},
+		{"end missing session", http.MethodDelete, "/sessions/abc", nil, other.AuthToken, http.StatusNotFound},
+		{"log out", http.MethodPost, "/logout", router.TokensDTO{RefreshToken: other.RefreshToken}, "", http.StatusNoContent},
+		{"refresh after logout", http.MethodPost, "/refresh", router.TokensDTO{RefreshToken: other.RefreshToken}, "", http.StatusUnauthorized},
+		{"auth token after logout", http.MethodGet, "/me", nil, other.AuthToken, http.StatusUnauthorized},
+		{"auth token of revoked session", http.MethodGet, "/sessions", nil, login.AuthToken, http.StatusUnauthorized},
+		{"auth token without session", http.MethodGet, "/me", nil, sessionless, http.StatusUnauthorized},
+	}
+	runRoutes(t, s, routeValues)
+
+	third := s.login(t, "jan", TEST_PASSWORD)
+	rec = s.request(t, http.MethodGet, "/sessions", nil, third.AuthToken)
+	sessions = decode[router.SessionArrDTO](t, rec)
+	if len(sessions.Sessions) != 1 || !sessions.Sessions[0].Current {
+		t.Fatalf("GET /sessions = %+v, want only the current session", sessions)
+	}
+
+	// a refresh token is refused once it expires, even if its session has not
+	expiredParams := session.CreateRefreshTokenParams{
+		ID:        "expired",
+		SessionID: sessions.Sessions[0].ID,
+		ExpiredAt: time.Now().Add(-time.Minute),
+	}
+	_, err = s.store.Session().CreateRefreshToken(context.Background(), expiredParams)
+	if err != nil {
+		t.Fatalf("could not create refresh token: %v", err)
+	}
+	expired, err := services.GenerateSessionToken(services.TOKEN_TYPE_REFRESH, strconv.Itoa(third.User.ID), expiredParams.SessionID, expiredParams.ID, 15)
+	if err != nil {
+		t.Fatalf("could not generate token: %v", err)
+	}
+	rec = s.request(t, http.MethodPost, "/refresh", router.TokensDTO{RefreshToken: expired}, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /refresh with expired token = %v, want %v", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec = s.request(t, http.MethodDelete, "/sessions/"+sessions.Sessions[0].ID, nil, third.AuthToken)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE /sessions/:id = %v %v", rec.Code, rec.Body.String())
+	}
+
+	rec = s.request(t, http.MethodGet, "/me", nil, third.AuthToken)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /me after ending the session = %v, want %v", rec.Code, http.StatusUnauthorized)
+	}
+}
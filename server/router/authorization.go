@@ -11,7 +11,7 @@ import (
 
 func extractUserRole(
 	ctx context.Context,
-	userQueries *users.Queries,
+	userQueries users.Querier,
 	userIDInterface interface{},
 ) services.Role {
 	userID, ok := userIDInterface.(int)
@@ -24,7 +24,7 @@ func extractUserRole(
 		return services.ROLE_GUEST
 	}
 
-	return services.NewRole(user.Role)
+	return services.NewUserRole(user.Role, user.Verified)
 }
 
 func handleUnauthorized(ctx echo.Context, role services.Role) error {
@@ -39,7 +39,7 @@ func (r *Router) VerifyPermissionsAll(perms ...services.Permission) echo.Middlew
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			userIDInterface := ctx.Get("userID")
-			role := extractUserRole(r.ctx, r.userQueries, userIDInterface)
+			role := extractUserRole(ctx.Request().Context(), r.userQueries, userIDInterface)
 
 			if role.CanAll(perms...) {
 				return next(ctx)
@@ -54,7 +54,7 @@ func (r *Router) VerifyPermissionsAny(perms ...services.Permission) echo.Middlew
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			userIDInterface := ctx.Get("userID")
-			role := extractUserRole(r.ctx, r.userQueries, userIDInterface)
+			role := extractUserRole(ctx.Request().Context(), r.userQueries, userIDInterface)
 
 			if role.CanAny(perms...) {
 				return next(ctx)
@@ -0,0 +1,96 @@
+package router_test
+
+import (
+	"net/http"
+	"testing"
+
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+func TestPermissions(t *testing.T) {
+	forEachStore(t, testPermissions)
+}
+
+func testPermissions(t *testing.T, s *testServer) {
+
+	admin := s.addUser(t, "admin", services.ROLE_ADMIN, true)
+	user := s.addUser(t, "user", services.ROLE_USER, true)
+	unverified := s.addUser(t, "unverified", services.ROLE_USER, false)
+
+	s.addKalan(t, "wilin", "word")
+	s.addProposal(t, admin.User.ID)
+
+	kalanDTO := router.KalanDTO{ID: 1, Entry: "wilin", Pos: "noun", Gloss: "word"}
+	proposalDTO := router.ProposalDTO{Id: 1, Entry: "jan", Pos: "noun", Gloss: "person"}
+	exampleDTO := router.ExampleDTO{ID: 1, Sentence: "wilin", Gloss: "word", Translation: "a word"}
+	reason := map[string]string{"reason": "no"}
+
+	routeValues := []RouteValue{
+		{"guest views words", http.MethodGet, "/kalan", nil, "", http.StatusOK},
+		{"guest searches words", http.MethodGet, "/kalan/paginated?search=wi&fields=entry", nil, "", http.StatusOK},
+		{"guest views a word", http.MethodGet, "/kalan/1", nil, "", http.StatusOK},
+
+		{"guest adds word", http.MethodPost, "/kalan", kalanDTO, "", http.StatusUnauthorized},
+		{"user adds word", http.MethodPost, "/kalan", kalanDTO, user.AuthToken, http.StatusForbidden},
+		{"guest modifies word", http.MethodPut, "/kalan", kalanDTO, "", http.StatusUnauthorized},
+		{"user modifies word", http.MethodPut, "/kalan", kalanDTO, user.AuthToken, http.StatusForbidden},
+		{"guest deletes word", http.MethodDelete, "/kalan/1", nil, "", http.StatusUnauthorized},
+		{"user deletes word", http.MethodDelete, "/kalan/1", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest views history", http.MethodGet, "/kalan/1/history", nil, "", http.StatusUnauthorized},
+		{"user views history", http.MethodGet, "/kalan/1/history", nil, user.AuthToken, http.StatusForbidden},
+		{"user reverts word", http.MethodPost, "/kalan/1/revert/1", nil, user.AuthToken, http.StatusForbidden},
+		{"user restores word", http.MethodPost, "/kalan/1/restore", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest views proposals", http.MethodGet, "/proposal", nil, "", http.StatusUnauthorized},
+		{"user views all proposals", http.MethodGet, "/proposal", nil, user.AuthToken, http.StatusForbidden},
+		{"guest views a proposal", http.MethodGet, "/proposal/1", nil, "", http.StatusUnauthorized},
+		{"user views a proposal of another", http.MethodGet, "/proposal/1", nil, user.AuthToken, http.StatusForbidden},
+		{"guest views own proposals", http.MethodGet, "/proposal/me", nil, "", http.StatusUnauthorized},
+		{"guest proposes", http.MethodPost, "/proposal", proposalDTO, "", http.StatusUnauthorized},
+		{"unverified user proposes", http.MethodPost, "/proposal", proposalDTO, unverified.AuthToken, http.StatusForbidden},
+		{"guest modifies proposal", http.MethodPut, "/proposal", proposalDTO, "", http.StatusUnauthorized},
+		{"user modifies proposal of another", http.MethodPut, "/proposal", proposalDTO, user.AuthToken, http.StatusForbidden},
+		{"guest deletes proposal", http.MethodDelete, "/proposal/1", nil, "", http.StatusUnauthorized},
+		{"user deletes proposal of another", http.MethodDelete, "/proposal/1", nil, user.AuthToken, http.StatusForbidden},
+		{"user approves proposal", http.MethodPost, "/proposal/1/approve", nil, user.AuthToken, http.StatusForbidden},
+		{"user rejects proposal", http.MethodPost, "/proposal/1/reject", reason, user.AuthToken, http.StatusForbidden},
+		{"guest withdraws proposal", http.MethodPost, "/proposal/1/withdraw", nil, "", http.StatusUnauthorized},
+		{"user withdraws proposal of another", http.MethodPost, "/proposal/1/withdraw", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest generates words", http.MethodGet, "/kalan/generate", nil, "", http.StatusUnauthorized},
+		{"unverified user generates words", http.MethodGet, "/kalan/generate", nil, unverified.AuthToken, http.StatusForbidden},
+		{"user generates words", http.MethodGet, "/kalan/generate", nil, user.AuthToken, http.StatusOK},
+
+		{"guest views relations", http.MethodGet, "/kalan/1/relations", nil, "", http.StatusOK},
+		{"user adds relation", http.MethodPost, "/kalan/1/relations", router.AddRelationDTO{RelatedID: 1, Kind: router.RELATION_SYNONYM}, user.AuthToken, http.StatusForbidden},
+		{"user deletes relation", http.MethodDelete, "/kalan/1/relations/1", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest views examples", http.MethodGet, "/example", nil, "", http.StatusOK},
+		{"guest views an example", http.MethodGet, "/example/1", nil, "", http.StatusNotFound},
+		{"guest adds example", http.MethodPost, "/example", exampleDTO, "", http.StatusUnauthorized},
+		{"user adds example", http.MethodPost, "/example", exampleDTO, user.AuthToken, http.StatusForbidden},
+		{"user modifies example", http.MethodPut, "/example", exampleDTO, user.AuthToken, http.StatusForbidden},
+		{"user deletes example", http.MethodDelete, "/example/1", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest views domains", http.MethodGet, "/domains", nil, "", http.StatusOK},
+		{"guest adds domain", http.MethodPost, "/domains", router.DomainDTO{Name: "Nature"}, "", http.StatusUnauthorized},
+		{"user adds domain", http.MethodPost, "/domains", router.DomainDTO{Name: "Nature"}, user.AuthToken, http.StatusForbidden},
+		{"user modifies domain", http.MethodPut, "/domains", router.DomainDTO{ID: 1, Name: "Nature"}, user.AuthToken, http.StatusForbidden},
+		{"user deletes domain", http.MethodDelete, "/domains/1", nil, user.AuthToken, http.StatusForbidden},
+		{"user tags word", http.MethodPost, "/kalan/1/domains", router.TagKalanDTO{DomainID: 1}, user.AuthToken, http.StatusForbidden},
+		{"user untags word", http.MethodDelete, "/kalan/1/domains/1", nil, user.AuthToken, http.StatusForbidden},
+
+		{"guest views self", http.MethodGet, "/me", nil, "", http.StatusUnauthorized},
+		{"guest views sessions", http.MethodGet, "/sessions", nil, "", http.StatusUnauthorized},
+		{"guest ends session", http.MethodDelete, "/sessions/abc", nil, "", http.StatusUnauthorized},
+		{"guest resends verification", http.MethodPost, "/verify", nil, "", http.StatusUnauthorized},
+		{"refresh token used as auth token", http.MethodGet, "/me", nil, user.RefreshToken, http.StatusUnauthorized},
+
+		{"admin views history", http.MethodGet, "/kalan/1/history", nil, admin.AuthToken, http.StatusNotFound},
+		{"admin views all proposals", http.MethodGet, "/proposal", nil, admin.AuthToken, http.StatusOK},
+	}
+
+	runRoutes(t, s, routeValues)
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrRequestTimeout  = errors.New("request timed out")
+	ErrRequestCanceled = errors.New("request canceled")
+)
+
+// QueryTimeout bounds the context of every request so that its
+// queries are stopped once the timeout of its route has passed.
+// Routes are looked up by their method and path, as in "GET /kalan",
+// and routes that are not in timeouts are given defaultTimeout
+func (r *Router) QueryTimeout(defaultTimeout time.Duration, timeouts map[string]time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			timeout, ok := timeouts[ctx.Request().Method+" "+ctx.Path()]
+			if !ok {
+				timeout = defaultTimeout
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx.Request().Context(), timeout)
+			defer cancel()
+
+			ctx.SetRequest(ctx.Request().WithContext(reqCtx))
+			return next(ctx)
+		}
+	}
+}
+
+// requestEnded returns the status code and error to respond with
+// if err was caused by the context of the request ending. The
+// error is nil if err has some other cause
+func requestEnded(err error) (int, error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ErrRequestTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, ErrRequestCanceled
+	}
+	return http.StatusOK, nil
+}
+
+// serverError responds to a request that failed with err. The
+// client is told if the request ran out of time or was canceled,
+// and is given a 500 with message otherwise
+func serverError(ctx echo.Context, err error, message string) error {
+	statusCode, ctxErr := requestEnded(err)
+	if ctxErr != nil {
+		errJSON := NewErrorJson(ctxErr.Error())
+		return ctx.JSON(statusCode, errJSON)
+	}
+
+	errJSON := NewErrorJson(message)
+	return ctx.JSON(http.StatusInternalServerError, errJSON)
+}
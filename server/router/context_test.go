@@ -0,0 +1,24 @@
+package router_test
+
+import (
+	"testing"
+
+	"wilin.info/api/database/memory"
+	"wilin.info/api/server"
+)
+
+// every query timeout must name a route that is registered, or it
+// silently leaves that route with the default
+func TestQueryTimeoutRoutes(t *testing.T) {
+	s := newTestServer(t, memory.New())
+
+	routes := map[string]bool{}
+	for _, route := range s.echo.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for key := range server.QUERY_TIMEOUTS {
+		if !routes[key] {
+			t.Errorf("query timeout %q matches no route", key)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/search"
+)
+
+const MAX_PAGE_SIZE = 500
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// resultKey is where a search result falls in the order of a
+// search: by score if the search is ranked, then by the sort
+// keys, then by id so that no two results are equal
+type resultKey struct {
+	Score  int      `json:"score"`
+	Values []string `json:"values"`
+	ID     int32    `json:"id"`
+}
+
+// resultOrder is how the results of a search are sorted
+type resultOrder struct {
+	keys     []SortKey
+	rank     bool
+	collator *collation.Collator
+}
+
+func (o resultOrder) key(result search.Result) resultKey {
+	return resultKey{
+		Score:  result.Score,
+		Values: kalanSortValues(result.Kalan, o.keys, o.collator),
+		ID:     result.Kalan.ID,
+	}
+}
+
+func (o resultOrder) compare(a resultKey, b resultKey) int {
+	if o.rank && a.Score != b.Score {
+		return cmp.Compare(b.Score, a.Score)
+	}
+	return compareSortValues(a.Values, a.ID, b.Values, b.ID, o.keys)
+}
+
+// Cursor marks a place in the results of a search so that the
+// next page starts right after it, or the previous page ends right
+// before it, even if words have been added since the last page.
+// The ordering of the search is kept in the cursor so that it
+// cannot be used with a search that is ordered differently
+type Cursor struct {
+	Key     resultKey `json:"key"`
+	Before  bool      `json:"before,omitempty"`
+	OrderBy string    `json:"orderBy"`
+	Rank    bool      `json:"rank,omitempty"`
+}
+
+// Encode turns the cursor into the opaque token given to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func (o resultOrder) cursor(result search.Result, before bool) Cursor {
+	return Cursor{
+		Key:     o.key(result),
+		Before:  before,
+		OrderBy: sortString(o.keys),
+		Rank:    o.rank,
+	}
+}
+
+// sort orders search results by their keys
+func (o resultOrder) sort(results []search.Result) {
+	keys := map[int32]resultKey{}
+	for _, result := range results {
+		keys[result.Kalan.ID] = o.key(result)
+	}
+
+	slices.SortFunc(results, func(a search.Result, b search.Result) int {
+		return o.compare(keys[a.Kalan.ID], keys[b.Kalan.ID])
+	})
+}
+
+// resultPage is a page of sorted search results along with
+// the cursors of the pages around it, which are empty if there
+// are no results on that side of the page
+type resultPage struct {
+	results    []search.Result
+	nextCursor string
+	prevCursor string
+}
+
+// pageAt returns the page of at most limit results starting
+// at the given index of the sorted results
+func (o resultOrder) pageAt(results []search.Result, start int, limit int) resultPage {
+	start = max(0, min(start, len(results)))
+	end := min(start+limit, len(results))
+
+	page := resultPage{results: results[start:end]}
+	if end < len(results) && end > 0 {
+		page.nextCursor = o.cursor(results[end-1], false).Encode()
+	}
+	if start > 0 && start < len(results) {
+		page.prevCursor = o.cursor(results[start], true).Encode()
+	}
+	return page
+}
+
+// pageAtCursor returns the page of at most limit sorted results
+// that comes right after or right before the cursor
+func (o resultOrder) pageAtCursor(results []search.Result, cursor Cursor, limit int) (resultPage, error) {
+	if cursor.OrderBy != sortString(o.keys) || cursor.Rank != o.rank || len(cursor.Key.Values) != len(o.keys) {
+		return resultPage{}, ErrInvalidCursor
+	}
+
+	// the index of the first result that comes after the cursor
+	after, _ := slices.BinarySearchFunc(results, cursor.Key, func(result search.Result, key resultKey) int {
+		c := o.compare(o.key(result), key)
+		if c == 0 {
+			return -1
+		}
+		return c
+	})
+
+	if !cursor.Before {
+		return o.pageAt(results, after, limit), nil
+	}
+
+	// the result at the cursor itself is not part of
+	// the page before it, if it still exists
+	end := after
+	if end > 0 && o.compare(o.key(results[end-1]), cursor.Key) == 0 {
+		end--
+	}
+	return o.pageAt(results, end-limit, min(limit, end)), nil
+}
@@ -0,0 +1,76 @@
+package router_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestCursorRoutes(t *testing.T) {
+	forEachStore(t, testCursorRoutes)
+}
+
+func testCursorRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	for _, entry := range []string{"e", "b", "d", "a", "c"} {
+		s.addKalan(t, entry, "letter")
+	}
+
+	page := func(query string) router.KalanArrayDTO {
+		t.Helper()
+		rec := s.request(t, http.MethodGet, "/kalan/paginated?"+query, nil, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /kalan/paginated?%v = %v %v", query, rec.Code, rec.Body.String())
+		}
+		return decode[router.KalanArrayDTO](t, rec)
+	}
+	entries := func(arr router.KalanArrayDTO) []string {
+		entries := []string{}
+		for _, k := range arr.Kalans {
+			entries = append(entries, k.Entry)
+		}
+		return entries
+	}
+
+	first := page("sort=entry&limit=2")
+	if !slices.Equal(entries(first), []string{"a", "b"}) || first.PageCount != 3 || first.PrevCursor != "" {
+		t.Fatalf("first page = %+v, want a and b out of 3 pages", first)
+	}
+
+	// words added before the cursor do not shift the next page
+	rec := s.request(t, http.MethodPost, "/kalan", router.KalanDTO{Entry: "aa", Pos: "noun", Gloss: "letters"}, token)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /kalan = %v %v", rec.Code, rec.Body.String())
+	}
+
+	second := page("sort=entry&limit=2&cursor=" + first.NextCursor)
+	if !slices.Equal(entries(second), []string{"c", "d"}) {
+		t.Errorf("second page = %v, want c and d", entries(second))
+	}
+
+	third := page("sort=entry&limit=2&cursor=" + second.NextCursor)
+	if !slices.Equal(entries(third), []string{"e"}) || third.NextCursor != "" {
+		t.Errorf("third page = %+v, want only e and no next cursor", third)
+	}
+
+	back := page("sort=entry&limit=2&cursor=" + second.PrevCursor)
+	if !slices.Equal(entries(back), []string{"aa", "b"}) {
+		t.Errorf("page before the second = %v, want aa and b", entries(back))
+	}
+
+	numbered := page("sort=entry&limit=2&page=2")
+	if !slices.Equal(entries(numbered), []string{"b", "c"}) || numbered.NextCursor == "" || numbered.PrevCursor == "" {
+		t.Errorf("page 2 = %+v, want b and c with cursors on both sides", numbered)
+	}
+
+	routeValues := []RouteValue{
+		{"limit too large", http.MethodGet, "/kalan/paginated?limit=1000", nil, "", http.StatusBadRequest},
+		{"negative limit", http.MethodGet, "/kalan/paginated?limit=-1", nil, "", http.StatusBadRequest},
+		{"malformed cursor", http.MethodGet, "/kalan/paginated?cursor=abc", nil, "", http.StatusBadRequest},
+		{"cursor from another sort", http.MethodGet, "/kalan/paginated?sort=gloss&cursor=" + first.NextCursor, nil, "", http.StatusBadRequest},
+	}
+	runRoutes(t, s, routeValues)
+}
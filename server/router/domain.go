@@ -0,0 +1,510 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	MAX_DOMAIN_CODE_LENGTH = 31
+	MAX_DOMAIN_NAME_LENGTH = 255
+)
+
+// DOMAIN_CODE_SEPARATOR splits the levels of a domain code, as in "1.2.3"
+const DOMAIN_CODE_SEPARATOR = "."
+
+var (
+	ErrNoDomainName       = errors.New("no domain name")
+	ErrDomainCodeTooLong  = fmt.Errorf("domain code must be at most %v characters", MAX_DOMAIN_CODE_LENGTH)
+	ErrDomainNameTooLong  = fmt.Errorf("domain name must be at most %v characters", MAX_DOMAIN_NAME_LENGTH)
+	ErrNoSuchDomain       = errors.New("no such domain")
+	ErrDomainGone         = errors.New("domain no longer exists")
+	ErrCircularDomain     = errors.New("a domain cannot be placed under itself or one of its subdomains")
+	ErrDomainHasChildren  = errors.New("domain still has subdomains")
+	ErrDuplicateDomainTag = errors.New("the word is already tagged with that domain")
+)
+
+type DomainDTO struct {
+	ID       int    `json:"id" form:"id"`
+	ParentID *int   `json:"parentId" form:"parentId"`
+	Code     string `json:"code" form:"code"`
+	Name     string `json:"name" form:"name"`
+}
+
+func NewDomainDTO(d domain.Domain) DomainDTO {
+	domainDTO := DomainDTO{ID: int(d.ID), Code: d.Code, Name: d.Name}
+	if d.ParentID.Valid {
+		parentID := int(d.ParentID.Int32)
+		domainDTO.ParentID = &parentID
+	}
+	return domainDTO
+}
+
+// DomainNodeDTO is a domain of the taxonomy along with its
+// subdomains. Count is how many words are tagged with the domain
+// itself and Total how many are tagged with it or any subdomain
+type DomainNodeDTO struct {
+	DomainDTO
+	Count    int             `json:"count"`
+	Total    int             `json:"total"`
+	Children []DomainNodeDTO `json:"children"`
+}
+
+type DomainTreeDTO struct {
+	Domains []DomainNodeDTO `json:"domains"`
+}
+
+// TagKalanDTO tags the word in the path, which is
+// never read from the body, with the domain DomainID
+type TagKalanDTO struct {
+	ID       int `param:"id" json:"-" form:"-"`
+	DomainID int `json:"domainId" form:"domainId"`
+}
+
+type DomainIDParam struct {
+	ID int `param:"id"`
+}
+
+type KalanDomainParam struct {
+	ID     int `param:"id"`
+	Domain int `param:"domain"`
+}
+
+// compareDomainCode orders codes level by level, comparing
+// levels that are both numbers by their value so that "1.10"
+// comes after "1.9"
+func compareDomainCode(a string, b string) int {
+	aLevels := strings.Split(a, DOMAIN_CODE_SEPARATOR)
+	bLevels := strings.Split(b, DOMAIN_CODE_SEPARATOR)
+	for i := 0; i < len(aLevels) && i < len(bLevels); i++ {
+		aNum, aErr := strconv.Atoi(aLevels[i])
+		bNum, bErr := strconv.Atoi(bLevels[i])
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				return cmp.Compare(aNum, bNum)
+			}
+			continue
+		}
+		c := compareFold(aLevels[i], bLevels[i])
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aLevels), len(bLevels))
+}
+
+// compareFold orders strings without regard to case
+func compareFold(a string, b string) int {
+	return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// domainChildren maps the id of every domain to its subdomains.
+// Domains at the top of the taxonomy are under the id 0
+func domainChildren(domains []domain.Domain) map[int32][]domain.Domain {
+	children := map[int32][]domain.Domain{}
+	for _, d := range domains {
+		children[d.ParentID.Int32] = append(children[d.ParentID.Int32], d)
+	}
+	for _, siblings := range children {
+		slices.SortFunc(siblings, func(a domain.Domain, b domain.Domain) int {
+			return cmp.Or(
+				compareDomainCode(a.Code, b.Code),
+				compareFold(a.Name, b.Name),
+				cmp.Compare(a.ID, b.ID),
+			)
+		})
+	}
+	return children
+}
+
+// domainSubtree returns the ids of the domain with id
+// and of every domain under it, however deep
+func domainSubtree(children map[int32][]domain.Domain, id int32) map[int32]bool {
+	subtree := map[int32]bool{id: true}
+	frontier := []int32{id}
+	for len(frontier) > 0 {
+		next := []int32{}
+		for _, parent := range frontier {
+			for _, child := range children[parent] {
+				if !subtree[child.ID] {
+					subtree[child.ID] = true
+					next = append(next, child.ID)
+				}
+			}
+		}
+		frontier = next
+	}
+	return subtree
+}
+
+// domainTree builds the taxonomy out of domains with the number
+// of words tagged with each of them. A word tagged with several
+// domains of the same subtree counts once towards its total
+func domainTree(domains []domain.Domain, links []domain.KalanDomain) []DomainNodeDTO {
+	children := domainChildren(domains)
+	tagged := map[int32][]int32{}
+	for _, link := range links {
+		tagged[link.DomainID] = append(tagged[link.DomainID], link.KalanID)
+	}
+
+	var build func(d domain.Domain) (DomainNodeDTO, map[int32]bool)
+	build = func(d domain.Domain) (DomainNodeDTO, map[int32]bool) {
+		node := DomainNodeDTO{
+			DomainDTO: NewDomainDTO(d),
+			Count:     len(tagged[d.ID]),
+			Children:  []DomainNodeDTO{},
+		}
+		kalanIDs := map[int32]bool{}
+		for _, id := range tagged[d.ID] {
+			kalanIDs[id] = true
+		}
+		for _, child := range children[d.ID] {
+			childNode, childIDs := build(child)
+			node.Children = append(node.Children, childNode)
+			for id := range childIDs {
+				kalanIDs[id] = true
+			}
+		}
+		node.Total = len(kalanIDs)
+		return node, kalanIDs
+	}
+
+	roots := []DomainNodeDTO{}
+	for _, d := range children[0] {
+		root, _ := build(d)
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// validateDomainJson checks that a domain has a name and that
+// its code and name are not too long
+func validateDomainJson(domainDTO *DomainDTO) error {
+	domainDTO.Code = strings.TrimSpace(domainDTO.Code)
+	domainDTO.Name = strings.TrimSpace(domainDTO.Name)
+
+	if domainDTO.Name == "" {
+		return ErrNoDomainName
+	}
+	if utf8.RuneCountInString(domainDTO.Code) > MAX_DOMAIN_CODE_LENGTH {
+		return ErrDomainCodeTooLong
+	}
+	if utf8.RuneCountInString(domainDTO.Name) > MAX_DOMAIN_NAME_LENGTH {
+		return ErrDomainNameTooLong
+	}
+	return nil
+}
+
+// domainParent checks that the domain with parentID can hold the
+// domain with id, which is 0 for a new domain. It must be called
+// inside of a transaction
+func domainParent(ctx context.Context, tx database.Store, id int32, parentID *int) (sql.NullInt32, error) {
+	if parentID == nil {
+		return sql.NullInt32{}, nil
+	}
+
+	domains, err := tx.Domain().ReadDomains(ctx)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	parent := int32(*parentID)
+	if !slices.ContainsFunc(domains, func(d domain.Domain) bool { return d.ID == parent }) {
+		return sql.NullInt32{}, fmt.Errorf("%w: id=%v", ErrNoSuchDomain, parent)
+	}
+	if id != 0 && domainSubtree(domainChildren(domains), id)[parent] {
+		return sql.NullInt32{}, ErrCircularDomain
+	}
+
+	return sql.NullInt32{Int32: parent, Valid: true}, nil
+}
+
+// readKalanDomains returns the domains the kalan with id is tagged with
+func (r *Router) readKalanDomains(ctx context.Context, id int32) ([]DomainDTO, error) {
+	domains, err := r.domainQueries.ReadDomainsByKalanID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	domainDTOs := []DomainDTO{}
+	for _, d := range domains {
+		domainDTOs = append(domainDTOs, NewDomainDTO(d))
+	}
+	return domainDTOs, nil
+}
+
+// domainKalanIDs returns the ids of every word tagged with one of
+// the domains in query, a list of domain ids split by commas, or
+// with one of their subdomains
+func (r *Router) domainKalanIDs(ctx context.Context, query string) (map[int32]bool, error) {
+	domains, err := r.domainQueries.ReadDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := domainChildren(domains)
+
+	wanted := map[int32]bool{}
+	for _, value := range splitQuery(query) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrNoSuchDomain, value)
+		}
+		if !slices.ContainsFunc(domains, func(d domain.Domain) bool { return d.ID == int32(id) }) {
+			return nil, fmt.Errorf("%w: id=%v", ErrNoSuchDomain, id)
+		}
+		for subdomain := range domainSubtree(children, int32(id)) {
+			wanted[subdomain] = true
+		}
+	}
+
+	links, err := r.domainQueries.ReadKalanDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kalanIDs := map[int32]bool{}
+	for _, link := range links {
+		if wanted[link.DomainID] {
+			kalanIDs[link.KalanID] = true
+		}
+	}
+	return kalanIDs, nil
+}
+
+func (r *Router) GetDomains(ctx echo.Context) error {
+	domains, err := r.domainQueries.ReadDomains(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch domains")
+	}
+
+	links, err := r.domainQueries.ReadKalanDomains(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch domains")
+	}
+
+	return ctx.JSON(http.StatusOK, DomainTreeDTO{Domains: domainTree(domains, links)})
+}
+
+func (r *Router) AddDomain(ctx echo.Context) error {
+	var domainDTO DomainDTO
+	err := ctx.Bind(&domainDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	err = validateDomainJson(&domainDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		parentID, err := domainParent(ctx.Request().Context(), tx, 0, domainDTO.ParentID)
+		if err != nil {
+			return err
+		}
+
+		createParams := domain.CreateDomainParams{ParentID: parentID, Code: domainDTO.Code, Name: domainDTO.Name}
+		result, err := tx.Domain().CreateDomain(ctx.Request().Context(), createParams)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		domainDTO.ID = int(id)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoSuchDomain) {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not add domain to database")
+	}
+
+	return ctx.JSON(http.StatusCreated, domainDTO)
+}
+
+func (r *Router) UpdateDomain(ctx echo.Context) error {
+	var domainDTO DomainDTO
+	err := ctx.Bind(&domainDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	if domainDTO.ID == 0 {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrNoId.Error()))
+	}
+	err = validateDomainJson(&domainDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		_, err := tx.Domain().ReadDomainByID(ctx.Request().Context(), int32(domainDTO.ID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrDomainGone
+			}
+			return err
+		}
+
+		parentID, err := domainParent(ctx.Request().Context(), tx, int32(domainDTO.ID), domainDTO.ParentID)
+		if err != nil {
+			return err
+		}
+
+		updateParams := domain.UpdateDomainParams{
+			ParentID: parentID,
+			Code:     domainDTO.Code,
+			Name:     domainDTO.Name,
+			ID:       int32(domainDTO.ID),
+		}
+		_, err = tx.Domain().UpdateDomain(ctx.Request().Context(), updateParams)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDomainGone):
+			errMsg := fmt.Sprintf("no domain with id=%v", domainDTO.ID)
+			return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+		case errors.Is(err, ErrNoSuchDomain), errors.Is(err, ErrCircularDomain):
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not update domain")
+	}
+
+	return ctx.JSON(http.StatusOK, domainDTO)
+}
+
+func (r *Router) DeleteDomain(ctx echo.Context) error {
+	var params DomainIDParam
+	err := ctx.Bind(&params)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		domains, err := tx.Domain().ReadDomains(ctx.Request().Context())
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(domains, func(d domain.Domain) bool { return d.ID == int32(params.ID) }) {
+			return ErrDomainGone
+		}
+
+		// subdomains are never deleted along with their parent,
+		// they have to be moved or deleted first
+		if len(domainChildren(domains)[int32(params.ID)]) > 0 {
+			return ErrDomainHasChildren
+		}
+
+		_, err = tx.Domain().DeleteDomain(ctx.Request().Context(), int32(params.ID))
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDomainGone):
+			errMsg := fmt.Sprintf("no domain with id=%v", params.ID)
+			return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+		case errors.Is(err, ErrDomainHasChildren):
+			return ctx.JSON(http.StatusConflict, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not delete domain")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (r *Router) TagKalan(ctx echo.Context) error {
+	var tagKalanDTO TagKalanDTO
+	err := ctx.Bind(&tagKalanDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	if tagKalanDTO.DomainID == 0 {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("no domain id"))
+	}
+
+	var tagged domain.Domain
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		_, err := tx.Kalan().ReadKalanById(ctx.Request().Context(), int32(tagKalanDTO.ID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrKalanGone
+			}
+			return err
+		}
+
+		tagged, err = tx.Domain().ReadDomainByID(ctx.Request().Context(), int32(tagKalanDTO.DomainID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%v", ErrNoSuchDomain, tagKalanDTO.DomainID)
+			}
+			return err
+		}
+
+		domains, err := tx.Domain().ReadDomainsByKalanID(ctx.Request().Context(), int32(tagKalanDTO.ID))
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(domains, func(d domain.Domain) bool { return d.ID == tagged.ID }) {
+			return ErrDuplicateDomainTag
+		}
+
+		createParams := domain.CreateKalanDomainParams{KalanID: int32(tagKalanDTO.ID), DomainID: tagged.ID}
+		_, err = tx.Domain().CreateKalanDomain(ctx.Request().Context(), createParams)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrKalanGone):
+			errMsg := fmt.Sprintf("no kalan with id=%v", tagKalanDTO.ID)
+			return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+		case errors.Is(err, ErrNoSuchDomain):
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		case errors.Is(err, ErrDuplicateDomainTag):
+			return ctx.JSON(http.StatusConflict, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not tag word")
+	}
+
+	return ctx.JSON(http.StatusCreated, NewDomainDTO(tagged))
+}
+
+func (r *Router) UntagKalan(ctx echo.Context) error {
+	var params KalanDomainParam
+	err := ctx.Bind(&params)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	deleteParams := domain.DeleteKalanDomainParams{KalanID: int32(params.ID), DomainID: int32(params.Domain)}
+	result, err := r.domainQueries.DeleteKalanDomain(ctx.Request().Context(), deleteParams)
+	if err != nil {
+		return serverError(ctx, err, "could not untag word")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return serverError(ctx, err, "could not untag word")
+	}
+	if rowsAffected < 1 {
+		errMsg := fmt.Sprintf("kalan with id=%v is not tagged with domain with id=%v", params.ID, params.Domain)
+		return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
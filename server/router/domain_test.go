@@ -0,0 +1,119 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestDomainRoutes(t *testing.T) {
+	forEachStore(t, testDomainRoutes)
+}
+
+func testDomainRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	addDomain := func(parentID *int, code string, name string) router.DomainDTO {
+		t.Helper()
+		rec := s.request(t, http.MethodPost, "/domains", router.DomainDTO{ParentID: parentID, Code: code, Name: name}, token)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST /domains = %v %v", rec.Code, rec.Body.String())
+		}
+		return decode[router.DomainDTO](t, rec)
+	}
+	nature := addDomain(nil, "1", "Nature")
+	animals := addDomain(&nature.ID, "1.10", "Animals")
+	plants := addDomain(&nature.ID, "1.9", "Plants")
+	birds := addDomain(&animals.ID, "1.10.1", "Birds")
+	person := addDomain(nil, "2", "Person")
+
+	wilin := s.addKalan(t, "wilin", "word")
+	kala := s.addKalan(t, "kala", "fish")
+	waso := s.addKalan(t, "waso", "bird")
+	kasi := s.addKalan(t, "kasi", "plant")
+
+	tag := func(kalanID int32, domainID int) {
+		t.Helper()
+		path := fmt.Sprintf("/kalan/%v/domains", kalanID)
+		rec := s.request(t, http.MethodPost, path, router.TagKalanDTO{DomainID: domainID}, token)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST %v = %v %v", path, rec.Code, rec.Body.String())
+		}
+	}
+	tag(kala, animals.ID)
+	tag(waso, animals.ID)
+	tag(waso, birds.ID)
+	tag(kasi, plants.ID)
+	tag(wilin, person.ID)
+
+	rec := s.request(t, http.MethodGet, "/domains", nil, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /domains = %v %v", rec.Code, rec.Body.String())
+	}
+	var tree []string
+	var walk func(nodes []router.DomainNodeDTO)
+	walk = func(nodes []router.DomainNodeDTO) {
+		for _, node := range nodes {
+			tree = append(tree, fmt.Sprintf("%v:%v/%v", node.Name, node.Count, node.Total))
+			walk(node.Children)
+		}
+	}
+	walk(decode[router.DomainTreeDTO](t, rec).Domains)
+	expected := []string{"Nature:0/3", "Plants:1/1", "Animals:2/2", "Birds:1/1", "Person:1/1"}
+	if !slices.Equal(tree, expected) {
+		t.Errorf("GET /domains = %v, want %v", tree, expected)
+	}
+
+	rec = s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v", waso), nil, "")
+	if got := decode[router.KalanDTO](t, rec); len(got.Domains) != 2 {
+		t.Errorf("GET /kalan/%v domains = %+v, want animals and birds", waso, got.Domains)
+	}
+
+	searchValues := []SearchValue{
+		{fmt.Sprintf("domain=%v&sort=entry", nature.ID), []string{"kala", "kasi", "waso"}},
+		{fmt.Sprintf("domain=%v", birds.ID), []string{"waso"}},
+		{fmt.Sprintf("domain=%v,%v&sort=entry", plants.ID, person.ID), []string{"kasi", "wilin"}},
+		{fmt.Sprintf("search=a&fields=entry&domain=%v&sort=entry", animals.ID), []string{"kala", "waso"}},
+	}
+	for _, test := range searchValues {
+		got := s.searchEntries(t, test.query)
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("search %v = %v, want %v", test.query, got, test.expected)
+		}
+	}
+
+	underBirds := animals
+	underBirds.ParentID = &birds.ID
+	moved := birds
+	moved.ParentID = &person.ID
+	routeValues := []RouteValue{
+		{"domain without name", http.MethodPost, "/domains", router.DomainDTO{Code: "3"}, token, http.StatusBadRequest},
+		{"domain under missing domain", http.MethodPost, "/domains", router.DomainDTO{ParentID: new(int), Name: "x"}, token, http.StatusBadRequest},
+		{"domain under its subdomain", http.MethodPut, "/domains", underBirds, token, http.StatusBadRequest},
+		{"update missing domain", http.MethodPut, "/domains", router.DomainDTO{ID: 99, Name: "x"}, token, http.StatusNotFound},
+		{"move domain", http.MethodPut, "/domains", moved, token, http.StatusOK},
+		{"tag with missing domain", http.MethodPost, fmt.Sprintf("/kalan/%v/domains", kala), router.TagKalanDTO{DomainID: 99}, token, http.StatusBadRequest},
+		{"tag missing word", http.MethodPost, "/kalan/99/domains", router.TagKalanDTO{DomainID: nature.ID}, token, http.StatusNotFound},
+		{"tag twice", http.MethodPost, fmt.Sprintf("/kalan/%v/domains", kala), router.TagKalanDTO{DomainID: animals.ID}, token, http.StatusConflict},
+		{"search missing domain", http.MethodGet, "/kalan/paginated?domain=99", nil, "", http.StatusBadRequest},
+		{"search invalid domain", http.MethodGet, "/kalan/paginated?domain=abc", nil, "", http.StatusBadRequest},
+		{"untag word", http.MethodDelete, fmt.Sprintf("/kalan/%v/domains/%v", kasi, plants.ID), nil, token, http.StatusNoContent},
+		{"untag untagged word", http.MethodDelete, fmt.Sprintf("/kalan/%v/domains/%v", kasi, plants.ID), nil, token, http.StatusNotFound},
+		{"delete domain with subdomains", http.MethodDelete, fmt.Sprintf("/domains/%v", person.ID), nil, token, http.StatusConflict},
+		{"delete domain", http.MethodDelete, fmt.Sprintf("/domains/%v", animals.ID), nil, token, http.StatusNoContent},
+		{"delete deleted domain", http.MethodDelete, fmt.Sprintf("/domains/%v", animals.ID), nil, token, http.StatusNotFound},
+		{"delete tagged word", http.MethodDelete, fmt.Sprintf("/kalan/%v", waso), nil, token, http.StatusNoContent},
+	}
+	runRoutes(t, s, routeValues)
+
+	got := s.searchEntries(t, fmt.Sprintf("domain=%v&sort=entry", person.ID))
+	if !slices.Equal(got, []string{"wilin"}) {
+		t.Errorf("search under person = %v, want only wilin after moving birds and deleting waso", got)
+	}
+	if got := s.searchEntries(t, fmt.Sprintf("domain=%v", nature.ID)); len(got) != 0 {
+		t.Errorf("search under nature = %v, want none", got)
+	}
+}
@@ -0,0 +1,363 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/example"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	MAX_EXAMPLE_LENGTH = 1000
+	MAX_EXAMPLE_KALANS = 50
+)
+
+// MORPHEME_SEPARATOR splits the morphemes of a word in a sentence
+// and their glosses in the gloss line, as in "wilin-an" and "word-PL"
+const MORPHEME_SEPARATOR = "-"
+
+var (
+	ErrNoSentence      = errors.New("no sentence")
+	ErrNoTranslation   = errors.New("no translation")
+	ErrExampleTooLong  = fmt.Errorf("sentence, gloss and translation must be at most %v characters each", MAX_EXAMPLE_LENGTH)
+	ErrTooManyKalans   = fmt.Errorf("an example can be linked to at most %v words", MAX_EXAMPLE_KALANS)
+	ErrMisalignedGloss = errors.New("gloss does not line up with the sentence")
+	ErrNoSuchKalan     = errors.New("no such word")
+	ErrExampleGone     = errors.New("example no longer exists")
+)
+
+// InterlinearWordDTO is a word of an example
+// sentence along with its line of the gloss
+type InterlinearWordDTO struct {
+	Word  string `json:"word"`
+	Gloss string `json:"gloss"`
+}
+
+type ExampleDTO struct {
+	ID          int    `json:"id" form:"id"`
+	Sentence    string `json:"sentence" form:"sentence"`
+	Gloss       string `json:"gloss" form:"gloss"`
+	Translation string `json:"translation" form:"translation"`
+
+	// KalanIDs are the words the sentence is an example of
+	KalanIDs []int `json:"kalanIds" form:"kalanIds"`
+
+	// Words pairs every word of the sentence with its
+	// gloss and is never read from requests
+	Words []InterlinearWordDTO `json:"words" form:"-"`
+}
+
+func NewExampleDTO(e example.Example, kalanIDs []int32) ExampleDTO {
+	exampleDTO := ExampleDTO{
+		ID:          int(e.ID),
+		Sentence:    e.Sentence,
+		Gloss:       e.Gloss,
+		Translation: e.Translation,
+		KalanIDs:    []int{},
+	}
+	for _, id := range kalanIDs {
+		exampleDTO.KalanIDs = append(exampleDTO.KalanIDs, int(id))
+	}
+
+	exampleDTO.Words, _ = alignGloss(e.Sentence, e.Gloss)
+	if exampleDTO.Words == nil {
+		exampleDTO.Words = []InterlinearWordDTO{}
+	}
+	return exampleDTO
+}
+
+type ExampleArrDTO struct {
+	Examples []ExampleDTO `json:"examples"`
+}
+
+func (arr *ExampleArrDTO) AddExample(exampleDTO ExampleDTO) {
+	arr.Examples = append(arr.Examples, exampleDTO)
+}
+
+type ExampleIDParam struct {
+	ID int `param:"id"`
+}
+
+// alignGloss pairs each word of sentence with the word of gloss in
+// the same place. Both must have as many words, and each word as
+// many morphemes as its gloss, for the gloss to be interlinear
+func alignGloss(sentence string, gloss string) ([]InterlinearWordDTO, error) {
+	words := strings.Fields(sentence)
+	glosses := strings.Fields(gloss)
+	if len(words) != len(glosses) {
+		return nil, fmt.Errorf("%w: the sentence has %v words but the gloss has %v", ErrMisalignedGloss, len(words), len(glosses))
+	}
+
+	interlinear := []InterlinearWordDTO{}
+	for i, word := range words {
+		morphemes := strings.Count(word, MORPHEME_SEPARATOR)
+		glossMorphemes := strings.Count(glosses[i], MORPHEME_SEPARATOR)
+		if morphemes != glossMorphemes {
+			return nil, fmt.Errorf("%w: %q has %v morphemes but %q has %v", ErrMisalignedGloss, word, morphemes+1, glosses[i], glossMorphemes+1)
+		}
+		interlinear = append(interlinear, InterlinearWordDTO{Word: word, Gloss: glosses[i]})
+	}
+	return interlinear, nil
+}
+
+// validateExampleJson checks that every part of an example is
+// there and that its gloss lines up with its sentence. Repeated
+// kalan ids are dropped
+func validateExampleJson(exampleDTO *ExampleDTO) error {
+	exampleDTO.Sentence = strings.TrimSpace(exampleDTO.Sentence)
+	exampleDTO.Gloss = strings.TrimSpace(exampleDTO.Gloss)
+	exampleDTO.Translation = strings.TrimSpace(exampleDTO.Translation)
+
+	if exampleDTO.Sentence == "" {
+		return ErrNoSentence
+	}
+	if exampleDTO.Gloss == "" {
+		return ErrNoGloss
+	}
+	if exampleDTO.Translation == "" {
+		return ErrNoTranslation
+	}
+	for _, text := range []string{exampleDTO.Sentence, exampleDTO.Gloss, exampleDTO.Translation} {
+		if utf8.RuneCountInString(text) > MAX_EXAMPLE_LENGTH {
+			return ErrExampleTooLong
+		}
+	}
+
+	_, err := alignGloss(exampleDTO.Sentence, exampleDTO.Gloss)
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(exampleDTO.KalanIDs)
+	exampleDTO.KalanIDs = slices.Compact(exampleDTO.KalanIDs)
+	if exampleDTO.KalanIDs == nil {
+		exampleDTO.KalanIDs = []int{}
+	}
+	if len(exampleDTO.KalanIDs) > MAX_EXAMPLE_KALANS {
+		return ErrTooManyKalans
+	}
+	return nil
+}
+
+// linkExample replaces the words the example with id is linked to.
+// It must be called inside of a transaction
+func linkExample(ctx context.Context, tx database.Store, id int32, kalanIDs []int) error {
+	exampleQueries := tx.Example()
+
+	_, err := exampleQueries.DeleteKalanExamplesByExampleID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, kalanID := range kalanIDs {
+		_, err = tx.Kalan().ReadKalanById(ctx, int32(kalanID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: id=%v", ErrNoSuchKalan, kalanID)
+			}
+			return err
+		}
+
+		linkParams := example.CreateKalanExampleParams{KalanID: int32(kalanID), ExampleID: id}
+		_, err = exampleQueries.CreateKalanExample(ctx, linkParams)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readKalanExamples returns the examples of the kalan with id
+// along with every word each of them is linked to
+func (r *Router) readKalanExamples(ctx context.Context, id int32) ([]ExampleDTO, error) {
+	examples, err := r.exampleQueries.ReadExamplesByKalanID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	exampleDTOs := []ExampleDTO{}
+	for _, e := range examples {
+		kalanIDs, err := r.exampleQueries.ReadKalanIDsByExampleID(ctx, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		exampleDTOs = append(exampleDTOs, NewExampleDTO(e, kalanIDs))
+	}
+	return exampleDTOs, nil
+}
+
+func (r *Router) GetAllExamples(ctx echo.Context) error {
+	examples, err := r.exampleQueries.ReadExamples(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch examples")
+	}
+
+	links, err := r.exampleQueries.ReadKalanExamples(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch examples")
+	}
+	kalanIDs := map[int32][]int32{}
+	for _, link := range links {
+		kalanIDs[link.ExampleID] = append(kalanIDs[link.ExampleID], link.KalanID)
+	}
+
+	exampleArrDTO := ExampleArrDTO{Examples: []ExampleDTO{}}
+	for _, e := range examples {
+		exampleArrDTO.AddExample(NewExampleDTO(e, kalanIDs[e.ID]))
+	}
+
+	return ctx.JSON(http.StatusOK, exampleArrDTO)
+}
+
+func (r *Router) GetExampleByID(ctx echo.Context) error {
+	var exampleID ExampleIDParam
+	err := ctx.Bind(&exampleID)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("invalid id"))
+	}
+
+	e, err := r.exampleQueries.ReadExampleByID(ctx.Request().Context(), int32(exampleID.ID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.JSON(http.StatusNotFound, NewErrorJson("invalid example, does not exist"))
+		}
+		return serverError(ctx, err, "could not fetch example")
+	}
+
+	kalanIDs, err := r.exampleQueries.ReadKalanIDsByExampleID(ctx.Request().Context(), e.ID)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch example")
+	}
+
+	return ctx.JSON(http.StatusOK, NewExampleDTO(e, kalanIDs))
+}
+
+func (r *Router) AddExample(ctx echo.Context) error {
+	var exampleDTO ExampleDTO
+	err := ctx.Bind(&exampleDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	err = validateExampleJson(&exampleDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	createParams := example.CreateExampleParams{
+		Sentence:    exampleDTO.Sentence,
+		Gloss:       exampleDTO.Gloss,
+		Translation: exampleDTO.Translation,
+	}
+
+	var exampleID int32
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		result, err := tx.Example().CreateExample(ctx.Request().Context(), createParams)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		exampleID = int32(id)
+
+		return linkExample(ctx.Request().Context(), tx, exampleID, exampleDTO.KalanIDs)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoSuchKalan) {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not add example to database")
+	}
+
+	exampleDTO.ID = int(exampleID)
+	exampleDTO.Words, _ = alignGloss(exampleDTO.Sentence, exampleDTO.Gloss)
+	return ctx.JSON(http.StatusCreated, exampleDTO)
+}
+
+func (r *Router) UpdateExample(ctx echo.Context) error {
+	var exampleDTO ExampleDTO
+	err := ctx.Bind(&exampleDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	if exampleDTO.ID == 0 {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrNoId.Error()))
+	}
+	err = validateExampleJson(&exampleDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	updateParams := example.UpdateExampleParams{
+		Sentence:    exampleDTO.Sentence,
+		Gloss:       exampleDTO.Gloss,
+		Translation: exampleDTO.Translation,
+		ID:          int32(exampleDTO.ID),
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		_, err := tx.Example().ReadExampleByID(ctx.Request().Context(), updateParams.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrExampleGone
+			}
+			return err
+		}
+
+		_, err = tx.Example().UpdateExample(ctx.Request().Context(), updateParams)
+		if err != nil {
+			return err
+		}
+
+		return linkExample(ctx.Request().Context(), tx, updateParams.ID, exampleDTO.KalanIDs)
+	})
+	if err != nil {
+		if errors.Is(err, ErrExampleGone) {
+			errMsg := fmt.Sprintf("no example with id=%v", exampleDTO.ID)
+			return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+		}
+		if errors.Is(err, ErrNoSuchKalan) {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not update example")
+	}
+
+	exampleDTO.Words, _ = alignGloss(exampleDTO.Sentence, exampleDTO.Gloss)
+	return ctx.JSON(http.StatusOK, exampleDTO)
+}
+
+func (r *Router) DeleteExample(ctx echo.Context) error {
+	var exampleID ExampleIDParam
+	err := ctx.Bind(&exampleID)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	result, err := r.exampleQueries.DeleteExample(ctx.Request().Context(), int32(exampleID.ID))
+	if err != nil {
+		return serverError(ctx, err, "could not delete example")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return serverError(ctx, err, "could not delete example")
+	}
+	if rowsAffected < 1 {
+		errMsg := fmt.Sprintf("no example with id=%v", exampleID.ID)
+		return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
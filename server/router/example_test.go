@@ -0,0 +1,100 @@
+package router_test
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestExampleRoutes(t *testing.T) {
+	forEachStore(t, testExampleRoutes)
+}
+
+func testExampleRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	mi := s.addKalan(t, "mi", "I")
+	moku := s.addKalan(t, "moku", "eat")
+	s.addKalan(t, "telo", "water")
+
+	exampleDTO := router.ExampleDTO{
+		Sentence:    "mi moku-an",
+		Gloss:       "1SG eat-PST",
+		Translation: "I ate",
+		KalanIDs:    []int{int(moku), int(mi), int(mi)},
+	}
+	rec := s.request(t, http.MethodPost, "/example", exampleDTO, token)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /example = %v %v", rec.Code, rec.Body.String())
+	}
+	created := decode[router.ExampleDTO](t, rec)
+	if !slices.Equal(created.KalanIDs, []int{int(mi), int(moku)}) {
+		t.Errorf("POST /example kalan ids = %v, want %v", created.KalanIDs, []int{int(mi), int(moku)})
+	}
+	if len(created.Words) != 2 || created.Words[1] != (router.InterlinearWordDTO{Word: "moku-an", Gloss: "eat-PST"}) {
+		t.Errorf("POST /example words = %+v, want each word with its gloss", created.Words)
+	}
+	path := "/example/" + strconv.Itoa(created.ID)
+
+	rec = s.request(t, http.MethodGet, "/kalan/"+strconv.Itoa(int(moku)), nil, "")
+	got := decode[router.KalanDTO](t, rec)
+	if len(got.Examples) != 1 || got.Examples[0].Sentence != "mi moku-an" {
+		t.Errorf("GET /kalan/%v examples = %+v, want the example", moku, got.Examples)
+	}
+
+	updated := created
+	updated.Translation = "I have eaten"
+	updated.KalanIDs = []int{int(mi)}
+	rec = s.request(t, http.MethodPut, "/example", updated, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /example = %v %v", rec.Code, rec.Body.String())
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/"+strconv.Itoa(int(moku)), nil, "")
+	got = decode[router.KalanDTO](t, rec)
+	if len(got.Examples) != 0 {
+		t.Errorf("GET /kalan/%v examples = %+v, want none after unlinking", moku, got.Examples)
+	}
+
+	rec = s.request(t, http.MethodGet, "/example", nil, "")
+	all := decode[router.ExampleArrDTO](t, rec)
+	if len(all.Examples) != 1 || all.Examples[0].Translation != "I have eaten" || !slices.Equal(all.Examples[0].KalanIDs, []int{int(mi)}) {
+		t.Errorf("GET /example = %+v, want the updated example", all)
+	}
+
+	misaligned := exampleDTO
+	misaligned.Gloss = "1SG eat"
+	unknownKalan := exampleDTO
+	unknownKalan.KalanIDs = []int{99}
+	missing := updated
+	missing.ID = 99
+
+	routeValues := []RouteValue{
+		{"example without sentence", http.MethodPost, "/example", router.ExampleDTO{Gloss: "a", Translation: "b"}, token, http.StatusBadRequest},
+		{"example without translation", http.MethodPost, "/example", router.ExampleDTO{Sentence: "a", Gloss: "b"}, token, http.StatusBadRequest},
+		{"gloss with too few morphemes", http.MethodPost, "/example", misaligned, token, http.StatusBadRequest},
+		{"gloss with too few words", http.MethodPost, "/example", router.ExampleDTO{Sentence: "mi moku", Gloss: "1SG", Translation: "I eat"}, token, http.StatusBadRequest},
+		{"example of missing word", http.MethodPost, "/example", unknownKalan, token, http.StatusBadRequest},
+		{"update missing example", http.MethodPut, "/example", missing, token, http.StatusNotFound},
+		{"get missing example", http.MethodGet, "/example/99", nil, "", http.StatusNotFound},
+		{"get example", http.MethodGet, path, nil, "", http.StatusOK},
+		{"delete word of example", http.MethodDelete, "/kalan/" + strconv.Itoa(int(mi)), nil, token, http.StatusNoContent},
+	}
+	runRoutes(t, s, routeValues)
+
+	rec = s.request(t, http.MethodGet, path, nil, "")
+	unlinked := decode[router.ExampleDTO](t, rec)
+	if len(unlinked.KalanIDs) != 0 {
+		t.Errorf("GET %v kalan ids = %v, want none after the word was deleted", path, unlinked.KalanIDs)
+	}
+
+	routeValues = []RouteValue{
+		{"delete example", http.MethodDelete, path, nil, token, http.StatusNoContent},
+		{"delete deleted example", http.MethodDelete, path, nil, token, http.StatusNotFound},
+		{"get deleted example", http.MethodGet, path, nil, "", http.StatusNotFound},
+	}
+	runRoutes(t, s, routeValues)
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/server/phonology"
+)
+
+const (
+	DEFAULT_GENERATE_COUNT     = 10
+	MAX_GENERATE_COUNT         = 50
+	DEFAULT_GENERATE_SYLLABLES = 2
+	MAX_GENERATE_SYLLABLES     = 6
+	DEFAULT_GENERATE_DISTANCE  = 1
+	MAX_GENERATE_DISTANCE      = 3
+)
+
+// MAX_SEED keeps seeds small enough to be read back
+// from JSON by clients that only have float numbers
+const MAX_SEED = 1 << 53
+
+type GenerateQueryDTO struct {
+	Count     int    `query:"count"`
+	Syllables int    `query:"syllables"`
+	Pattern   string `query:"pattern"`
+	Distance  *int   `query:"distance"`
+	Seed      string `query:"seed"`
+}
+
+type GeneratedDTO struct {
+	Entry     string   `json:"entry"`
+	IPA       string   `json:"ipa"`
+	Syllables []string `json:"syllables"`
+}
+
+type GeneratedArrDTO struct {
+	// Seed generates the same words again
+	// as long as the dictionary is the same
+	Seed  int64          `json:"seed"`
+	Words []GeneratedDTO `json:"words"`
+}
+
+func (arr *GeneratedArrDTO) AddWord(word GeneratedDTO) {
+	arr.Words = append(arr.Words, word)
+}
+
+func (r *Router) GenerateKalan(ctx echo.Context) error {
+	var generateQueryDTO GenerateQueryDTO
+	err := ctx.Bind(&generateQueryDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid query"))
+	}
+
+	count := generateQueryDTO.Count
+	if count == 0 {
+		count = DEFAULT_GENERATE_COUNT
+	}
+	if count < 1 || count > MAX_GENERATE_COUNT {
+		errMsg := fmt.Sprintf("count must be between 1 and %v", MAX_GENERATE_COUNT)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	syllables := generateQueryDTO.Syllables
+	if syllables != 0 && generateQueryDTO.Pattern != "" {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("syllables and pattern cannot be used together"))
+	}
+	if syllables == 0 {
+		syllables = DEFAULT_GENERATE_SYLLABLES
+	}
+	if syllables < 1 || syllables > MAX_GENERATE_SYLLABLES {
+		errMsg := fmt.Sprintf("syllables must be between 1 and %v", MAX_GENERATE_SYLLABLES)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	err = phonology.CheckPattern(generateQueryDTO.Pattern)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	distance := DEFAULT_GENERATE_DISTANCE
+	if generateQueryDTO.Distance != nil {
+		distance = *generateQueryDTO.Distance
+	}
+	if distance < 0 || distance > MAX_GENERATE_DISTANCE {
+		errMsg := fmt.Sprintf("distance must be between 0 and %v", MAX_GENERATE_DISTANCE)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	seed := rand.Int64N(MAX_SEED)
+	if generateQueryDTO.Seed != "" {
+		seed, err = strconv.ParseInt(generateQueryDTO.Seed, 10, 64)
+		if err != nil || seed < 0 || seed >= MAX_SEED {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson("invalid seed"))
+		}
+	}
+
+	kalans, err := r.kalanQueries.ReadKalan(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch words")
+	}
+	exclude := []string{}
+	for _, k := range kalans {
+		exclude = append(exclude, k.Entry)
+	}
+
+	options := phonology.GenerateOptions{
+		Count:     count,
+		Syllables: syllables,
+		Pattern:   generateQueryDTO.Pattern,
+		Exclude:   exclude,
+		Distance:  distance,
+	}
+	if options.Pattern != "" {
+		options.Syllables = 0
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	words, err := r.phonology.Generate(ctx.Request().Context(), rng, options)
+	if err != nil {
+		if errors.Is(err, phonology.ErrInvalidTemplate) || errors.Is(err, phonology.ErrUnusablePattern) {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not generate words")
+	}
+
+	generatedArrDTO := GeneratedArrDTO{Seed: seed, Words: []GeneratedDTO{}}
+	for _, word := range words {
+		transcription := r.phonology.Transcribe(word)
+		generatedArrDTO.AddWord(GeneratedDTO{
+			Entry:     word,
+			IPA:       transcription.IPA,
+			Syllables: transcription.Syllables,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, generatedArrDTO)
+}
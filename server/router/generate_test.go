@@ -0,0 +1,86 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/search"
+)
+
+func TestGenerateRoutes(t *testing.T) {
+	forEachStore(t, testGenerateRoutes)
+}
+
+func testGenerateRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+	s.addKalan(t, "wilin", "word")
+
+	generate := func(query string) router.GeneratedArrDTO {
+		t.Helper()
+		rec := s.request(t, http.MethodGet, "/kalan/generate?"+query, nil, token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /kalan/generate?%v = %v %v", query, rec.Code, rec.Body.String())
+		}
+		return decode[router.GeneratedArrDTO](t, rec)
+	}
+	entries := func(generated router.GeneratedArrDTO) []string {
+		words := []string{}
+		for _, word := range generated.Words {
+			words = append(words, word.Entry)
+		}
+		return words
+	}
+
+	generated := generate("count=30&syllables=3")
+	if len(generated.Words) != 30 {
+		t.Fatalf("generated %v words, want 30", len(generated.Words))
+	}
+	for _, word := range generated.Words {
+		if len(word.Syllables) != 3 || word.IPA == "" {
+			t.Errorf("generated %+v, want 3 syllables and an IPA", word)
+		}
+		sound := strings.NewReplacer(phonology.STRESS_MARK, "", phonology.SYLLABLE_SEPARATOR, "").Replace(word.IPA)
+		if search.Distance(sound, "wilin") <= router.DEFAULT_GENERATE_DISTANCE {
+			t.Errorf("generated %q, which sounds like wilin", word.Entry)
+		}
+	}
+
+	// the same seed gives the same words, but not once they are taken
+	seeded := generate(fmt.Sprintf("count=5&seed=%v", generated.Seed))
+	again := generate(fmt.Sprintf("count=5&seed=%v", generated.Seed))
+	if !slices.Equal(entries(seeded), entries(again)) {
+		t.Errorf("seed %v generated %v and then %v", generated.Seed, entries(seeded), entries(again))
+	}
+	s.addKalan(t, seeded.Words[0].Entry, "taken")
+	again = generate(fmt.Sprintf("count=5&seed=%v", generated.Seed))
+	if slices.Contains(entries(again), seeded.Words[0].Entry) {
+		t.Errorf("seed %v generated %q after it was added", generated.Seed, seeded.Words[0].Entry)
+	}
+
+	patterned := generate("count=5&pattern=VCV&distance=0")
+	for _, word := range patterned.Words {
+		// a consonant may be written with two letters, as ng is
+		length := utf8.RuneCountInString(word.Entry)
+		if len(word.Syllables) != 2 || length < 3 || length > 4 {
+			t.Errorf("generated %+v, want the pattern VCV", word)
+		}
+	}
+
+	routeValues := []RouteValue{
+		{"too many words", http.MethodGet, "/kalan/generate?count=51", nil, token, http.StatusBadRequest},
+		{"too many syllables", http.MethodGet, "/kalan/generate?syllables=7", nil, token, http.StatusBadRequest},
+		{"negative distance", http.MethodGet, "/kalan/generate?distance=-1", nil, token, http.StatusBadRequest},
+		{"invalid seed", http.MethodGet, "/kalan/generate?seed=abc", nil, token, http.StatusBadRequest},
+		{"invalid pattern", http.MethodGet, "/kalan/generate?pattern=CVX", nil, token, http.StatusBadRequest},
+		{"pattern and syllables", http.MethodGet, "/kalan/generate?pattern=CV&syllables=2", nil, token, http.StatusBadRequest},
+		{"pattern with too many groups", http.MethodGet, "/kalan/generate?pattern=" + strings.Repeat("(C)", phonology.MAX_PATTERN_GROUPS+1) + "V", nil, token, http.StatusBadRequest},
+		{"pattern too long", http.MethodGet, "/kalan/generate?pattern=" + strings.Repeat("CV", phonology.MAX_PATTERN_LENGTH), nil, token, http.StatusBadRequest},
+	}
+	runRoutes(t, s, routeValues)
+}
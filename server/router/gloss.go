@@ -0,0 +1,225 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/morphology"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	GLOSS_FORMAT_JSON = "json"
+	GLOSS_FORMAT_TEXT = "text"
+	GLOSS_FORMAT_HTML = "html"
+)
+
+const MAX_GLOSSING_LENGTH = 1000
+
+// UNKNOWN_GLOSS is the gloss of words whose stem is not in the dictionary
+const UNKNOWN_GLOSS = "?"
+
+var ErrUnknownGlossFormat = fmt.Errorf("format must be %v, %v or %v", GLOSS_FORMAT_JSON, GLOSS_FORMAT_TEXT, GLOSS_FORMAT_HTML)
+
+type GlossRequestDTO struct {
+	Text   string `json:"text" form:"text"`
+	Format string `json:"format" form:"format"`
+}
+
+// GlossedWordDTO is a word of glossed text split into its
+// morphemes, with a gloss for each of them
+type GlossedWordDTO struct {
+	Text      string   `json:"text"`
+	Morphemes []string `json:"morphemes"`
+	Glosses   []string `json:"glosses"`
+	KalanID   int      `json:"kalanId,omitempty"`
+	Unknown   bool     `json:"unknown"`
+}
+
+// segmented returns the word with its morphemes split by
+// MORPHEME_SEPARATOR, as it is written in the first line
+func (w GlossedWordDTO) segmented() string {
+	return strings.Join(w.Morphemes, MORPHEME_SEPARATOR)
+}
+
+// gloss returns the glosses of the word as they
+// are written under it in the second line
+func (w GlossedWordDTO) gloss() string {
+	return strings.Join(w.Glosses, MORPHEME_SEPARATOR)
+}
+
+// InterlinearDTO is text glossed the Leipzig way. Lines are the
+// segmented words and their glosses, padded so that every word
+// lines up with its gloss
+type InterlinearDTO struct {
+	Text    string           `json:"text"`
+	Lines   []string         `json:"lines"`
+	Words   []GlossedWordDTO `json:"words"`
+	Unknown []string         `json:"unknown"`
+}
+
+func (dto *InterlinearDTO) AddWord(word GlossedWordDTO) {
+	dto.Words = append(dto.Words, word)
+	if word.Unknown {
+		dto.Unknown = append(dto.Unknown, word.Text)
+	}
+}
+
+// stemGloss turns the gloss of a kalan into the gloss of a stem,
+// which is its first sense with dots in place of spaces
+func stemGloss(gloss string) string {
+	first, _, _ := strings.Cut(gloss, SENSE_SEPARATOR)
+	return strings.Join(strings.Fields(first), ".")
+}
+
+// alignLines pads the segmented words and their glosses so
+// that each word starts at the same column as its gloss
+func alignLines(words []GlossedWordDTO) []string {
+	var segmented, glosses strings.Builder
+	for i, word := range words {
+		if i > 0 {
+			segmented.WriteString(" ")
+			glosses.WriteString(" ")
+		}
+		morphemes, gloss := word.segmented(), word.gloss()
+		width := max(utf8.RuneCountInString(morphemes), utf8.RuneCountInString(gloss))
+		segmented.WriteString(morphemes + strings.Repeat(" ", width-utf8.RuneCountInString(morphemes)))
+		glosses.WriteString(gloss + strings.Repeat(" ", width-utf8.RuneCountInString(gloss)))
+	}
+	return []string{
+		strings.TrimRight(segmented.String(), " "),
+		strings.TrimRight(glosses.String(), " "),
+	}
+}
+
+// glossTable renders words as an HTML table with a row for the
+// segmented words and a row for their glosses. Cells of unknown
+// words have the class "unknown"
+func glossTable(words []GlossedWordDTO) string {
+	cell := func(word GlossedWordDTO, text string) string {
+		if word.Unknown {
+			return `<td class="unknown">` + html.EscapeString(text) + "</td>"
+		}
+		return "<td>" + html.EscapeString(text) + "</td>"
+	}
+
+	var b strings.Builder
+	b.WriteString("<table class=\"interlinear\">\n<tr>")
+	for _, word := range words {
+		b.WriteString(cell(word, word.segmented()))
+	}
+	b.WriteString("</tr>\n<tr>")
+	for _, word := range words {
+		b.WriteString(cell(word, word.gloss()))
+	}
+	b.WriteString("</tr>\n</table>\n")
+	return b.String()
+}
+
+// glossWord looks up the readings of token in order until one has
+// a stem in the dictionary. Lookups are kept in stems so that each
+// stem is only read once for the whole text
+func (r *Router) glossWord(ctx context.Context, token morphology.Token, stems map[string]*kalan.Kalan) (GlossedWordDTO, error) {
+	if token.Word == "" {
+		return GlossedWordDTO{Text: token.Text, Morphemes: []string{token.Text}, Glosses: []string{""}}, nil
+	}
+
+	// morpheme boundaries may already be written into
+	// the word, as long as it is not an entry itself
+	analyses := r.morphology.Analyses(token.Word)
+	if strings.Contains(token.Word, MORPHEME_SEPARATOR) {
+		joined := strings.ReplaceAll(token.Word, MORPHEME_SEPARATOR, "")
+		analyses = append(analyses[:1], r.morphology.Analyses(joined)...)
+	}
+
+	for _, analysis := range analyses {
+		k, ok := stems[analysis.Stem]
+		if !ok {
+			found, err := r.kalanQueries.ReadKalanByEntry(ctx, analysis.Stem)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return GlossedWordDTO{}, err
+			}
+			if err == nil {
+				k = &found
+			}
+			stems[analysis.Stem] = k
+		}
+		if k == nil {
+			continue
+		}
+
+		word := GlossedWordDTO{Text: token.Text, KalanID: int(k.ID)}
+		for _, prefix := range analysis.Prefixes {
+			word.Morphemes = append(word.Morphemes, prefix.Form)
+			word.Glosses = append(word.Glosses, prefix.Gloss)
+		}
+		word.Morphemes = append(word.Morphemes, analysis.Stem)
+		word.Glosses = append(word.Glosses, stemGloss(k.Gloss))
+		for _, suffix := range analysis.Suffixes {
+			word.Morphemes = append(word.Morphemes, suffix.Form)
+			word.Glosses = append(word.Glosses, suffix.Gloss)
+		}
+		return word, nil
+	}
+
+	unknown := GlossedWordDTO{
+		Text:      token.Text,
+		Morphemes: []string{strings.ToLower(token.Word)},
+		Glosses:   []string{UNKNOWN_GLOSS},
+		Unknown:   true,
+	}
+	return unknown, nil
+}
+
+func (r *Router) GlossText(ctx echo.Context) error {
+	var glossRequestDTO GlossRequestDTO
+	err := ctx.Bind(&glossRequestDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	text := strings.TrimSpace(glossRequestDTO.Text)
+	if text == "" {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("no text"))
+	}
+	if utf8.RuneCountInString(text) > MAX_GLOSSING_LENGTH {
+		errMsg := fmt.Sprintf("text must be at most %v characters", MAX_GLOSSING_LENGTH)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	format := glossRequestDTO.Format
+	if format == "" {
+		format = GLOSS_FORMAT_JSON
+	}
+	if format != GLOSS_FORMAT_JSON && format != GLOSS_FORMAT_TEXT && format != GLOSS_FORMAT_HTML {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrUnknownGlossFormat.Error()))
+	}
+
+	interlinearDTO := InterlinearDTO{Text: text, Words: []GlossedWordDTO{}, Unknown: []string{}}
+	stems := map[string]*kalan.Kalan{}
+	for _, token := range morphology.Tokenize(text) {
+		word, err := r.glossWord(ctx.Request().Context(), token, stems)
+		if err != nil {
+			return serverError(ctx, err, "could not look up words")
+		}
+		interlinearDTO.AddWord(word)
+	}
+	interlinearDTO.Lines = alignLines(interlinearDTO.Words)
+
+	switch format {
+	case GLOSS_FORMAT_TEXT:
+		return ctx.String(http.StatusOK, strings.Join(interlinearDTO.Lines, "\n")+"\n")
+	case GLOSS_FORMAT_HTML:
+		return ctx.HTML(http.StatusOK, glossTable(interlinearDTO.Words))
+	default:
+		return ctx.JSON(http.StatusOK, interlinearDTO)
+	}
+}
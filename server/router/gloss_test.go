@@ -0,0 +1,80 @@
+package router_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/server/router"
+)
+
+func TestGlossRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "morphology.json")
+	config := `{
+		"prefixes": [{"form": "ka", "gloss": "CAUS"}],
+		"suffixes": [{"form": "an", "gloss": "PL"}]
+	}`
+	err := os.WriteFile(path, []byte(config), 0o644)
+	if err != nil {
+		t.Fatalf("could not write morphology: %v", err)
+	}
+	t.Setenv("MORPHOLOGY_FILE", path)
+
+	forEachStore(t, testGlossRoutes)
+}
+
+func testGlossRoutes(t *testing.T, s *testServer) {
+	s.addKalan(t, "mi", "I")
+	moku := s.addKalan(t, "moku", "eat; food")
+	s.addKalan(t, "telo", "big water")
+
+	text := "Mi ka-moku-an telo, xyz!"
+	rec := s.request(t, http.MethodPost, "/gloss", router.GlossRequestDTO{Text: text}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /gloss = %v %v", rec.Code, rec.Body.String())
+	}
+	glossed := decode[router.InterlinearDTO](t, rec)
+
+	expected := []string{
+		"mi ka-moku-an  telo      xyz",
+		"I  CAUS-eat-PL big.water ?",
+	}
+	if !slices.Equal(glossed.Lines, expected) {
+		t.Errorf("POST /gloss lines = %q, want %q", glossed.Lines, expected)
+	}
+	if len(glossed.Words) != 4 || glossed.Words[1].KalanID != int(moku) || !slices.Equal(glossed.Words[1].Glosses, []string{"CAUS", "eat", "PL"}) {
+		t.Errorf("POST /gloss words = %+v, want ka-moku-an glossed by morpheme", glossed.Words)
+	}
+	if !slices.Equal(glossed.Unknown, []string{"xyz!"}) || !glossed.Words[3].Unknown {
+		t.Errorf("POST /gloss unknown = %v, want xyz!", glossed.Unknown)
+	}
+
+	// boundaries are found without hyphens too
+	rec = s.request(t, http.MethodPost, "/gloss", router.GlossRequestDTO{Text: "mokuan"}, "")
+	glossed = decode[router.InterlinearDTO](t, rec)
+	if !slices.Equal(glossed.Lines, []string{"moku-an", "eat-PL"}) {
+		t.Errorf("POST /gloss mokuan = %q, want moku-an", glossed.Lines)
+	}
+
+	rec = s.request(t, http.MethodPost, "/gloss", router.GlossRequestDTO{Text: text, Format: router.GLOSS_FORMAT_TEXT}, "")
+	if rec.Body.String() != strings.Join(expected, "\n")+"\n" || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMETextPlain) {
+		t.Errorf("POST /gloss as text = %q %v", rec.Body.String(), rec.Header().Get(echo.HeaderContentType))
+	}
+
+	rec = s.request(t, http.MethodPost, "/gloss", router.GlossRequestDTO{Text: "mi x&y", Format: router.GLOSS_FORMAT_HTML}, "")
+	table := rec.Body.String()
+	if !strings.Contains(table, "<td>mi</td>") || !strings.Contains(table, `<td class="unknown">x&amp;y</td>`) {
+		t.Errorf("POST /gloss as html = %q, want a table with escaped unknown words", table)
+	}
+
+	routeValues := []RouteValue{
+		{"gloss without text", http.MethodPost, "/gloss", router.GlossRequestDTO{Text: " "}, "", http.StatusBadRequest},
+		{"gloss in unknown format", http.MethodPost, "/gloss", router.GlossRequestDTO{Text: "mi", Format: "pdf"}, "", http.StatusBadRequest},
+		{"gloss too long", http.MethodPost, "/gloss", router.GlossRequestDTO{Text: strings.Repeat("mi ", router.MAX_GLOSSING_LENGTH)}, "", http.StatusBadRequest},
+	}
+	runRoutes(t, s, routeValues)
+}
@@ -1,13 +1,17 @@
 package router
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"slices"
+	"unicode/utf8"
 
+	"wilin.info/api/database"
 	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/search"
 
 	"github.com/labstack/echo/v4"
 )
@@ -18,6 +22,24 @@ type KalanDTO struct {
 	Pos   string `json:"pos" form:"pos"`
 	Gloss string `json:"gloss" form:"gloss"`
 	Notes string `json:"notes" form:"notes"`
+
+	// Senses are the meanings of the kalan in order. Gloss is
+	// the glosses of every sense joined by SENSE_SEPARATOR
+	Senses []SenseDTO `json:"senses" form:"-"`
+
+	// Examples and Domains are only filled
+	// in when a single kalan is read
+	Examples []ExampleDTO `json:"examples,omitempty" form:"-"`
+	Domains  []DomainDTO  `json:"domains,omitempty" form:"-"`
+
+	// IPA and Syllables are worked out from the entry
+	// by the phonology and are never read from requests
+	IPA       string   `json:"ipa" form:"-"`
+	Syllables []string `json:"syllables" form:"-"`
+
+	// Warnings holds the rules of the phonology that a new
+	// or updated entry breaks, when they are not rejected
+	Warnings []PhonologyViolationDTO `json:"warnings,omitempty" form:"-"`
 }
 
 func NewKalanDTO(id int, entry string, pos string, gloss string, notes string) KalanDTO {
@@ -30,6 +52,18 @@ func NewKalanDTO(id int, entry string, pos string, gloss string, notes string) K
 	}
 }
 
+// newKalanDTO creates a KalanDTO with its senses
+// along with the pronunciation of its entry
+func (r *Router) newKalanDTO(id int, entry string, pos string, gloss string, notes string, senses []SenseDTO) KalanDTO {
+	kalanDTO := NewKalanDTO(id, entry, pos, gloss, notes)
+	kalanDTO.Senses = senses
+	if senses == nil {
+		kalanDTO.Senses = []SenseDTO{}
+	}
+	r.pronounce(&kalanDTO)
+	return kalanDTO
+}
+
 func validateKalanJson(kalan *KalanDTO) error {
 	if kalan.Entry == "" {
 		return ErrNoEntry
@@ -51,12 +85,21 @@ type KalanArrayDTO struct {
 	Page       int        `json:"page"`
 	KalanCount int        `json:"kalanCount"`
 	PageCount  int        `json:"pageCount"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	PrevCursor string     `json:"prevCursor,omitempty"`
+	// Suggestions holds words close to the search
+	// when a search has no results
+	Suggestions []SuggestionDTO `json:"suggestions,omitempty"`
 }
 
 func (arr *KalanArrayDTO) AddKalan(kalan KalanDTO) {
 	arr.Kalans = append(arr.Kalans, kalan)
 }
 
+type SortQueryDTO struct {
+	Sort string `query:"sort"`
+}
+
 type KalanIDParam struct {
 	ID int `param:"id"`
 }
@@ -66,6 +109,16 @@ type SearchQueryDTO struct {
 	Fields string `query:"fields"`
 	Sort   string `query:"sort"`
 	Page   int    `query:"page"`
+	Mode   string `query:"mode"`
+	Rank   bool   `query:"rank"`
+	Query  string `query:"q"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+
+	// Domain is a list of domain ids split by commas. Only
+	// words tagged with one of them, or with one of their
+	// subdomains, are found
+	Domain string `query:"domain"`
 }
 
 type Fields struct {
@@ -98,18 +151,59 @@ func NewFields(fieldsArray []string) Fields {
 	return fields
 }
 
+func (f Fields) searchFields() search.Fields {
+	var fields search.Fields
+	if f.IsEntry {
+		fields |= search.FIELD_ENTRY
+	}
+	if f.IsPos {
+		fields |= search.FIELD_POS
+	}
+	if f.IsGloss {
+		fields |= search.FIELD_GLOSS
+	}
+	if f.IsNotes {
+		fields |= search.FIELD_NOTES
+	}
+	return fields
+}
+
+// searchIndex returns the search index, reading every
+// kalan into it the first time it is used
+func (r *Router) searchIndex(ctx context.Context) (*search.Index, error) {
+	err := r.index.Ensure(ctx, r.kalanQueries.ReadKalan)
+	return r.index, err
+}
+
 // Define the Handlers for the kalan related routes
 
 func (r *Router) GetAllKalan(ctx echo.Context) error {
+	var sortQueryDTO SortQueryDTO
+	err := ctx.Bind(&sortQueryDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid sort"))
+	}
+
+	sortKeys, err := ParseSort(sortQueryDTO.Sort)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
 	var kalanArrayDTO KalanArrayDTO
 
-	kalans, err := r.kalanQueries.ReadKalan(r.ctx)
+	kalans, err := r.kalanQueries.ReadKalan(ctx.Request().Context())
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, NewErrorJson("Failed to fetch words"))
+		return serverError(ctx, err, "Failed to fetch words")
+	}
+	sortKalans(kalans, sortKeys, r.collator)
+
+	senses, err := r.readAllSenses(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Failed to fetch senses")
 	}
 
 	for _, kalan := range kalans {
-		kalanDTO := NewKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes)
+		kalanDTO := r.newKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes, senses[kalan.ID])
 		kalanArrayDTO.AddKalan(kalanDTO)
 	}
 
@@ -124,23 +218,34 @@ func (r *Router) GetKalanByID(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	kalan, err := r.kalanQueries.ReadKalanById(r.ctx, int32(kalanID.ID))
+	kalan, err := r.kalanQueries.ReadKalanById(ctx.Request().Context(), int32(kalanID.ID))
 	if err != nil {
-		var errJSON ErrorJson
-		var statusCode int
-
 		if errors.Is(err, sql.ErrNoRows) {
-			errJSON = NewErrorJson("invalid word, does not exist")
-			statusCode = http.StatusNotFound
-		} else {
-			errJSON = NewErrorJson("could not fetch word")
-			statusCode = http.StatusInternalServerError
+			errJSON := NewErrorJson("invalid word, does not exist")
+			return ctx.JSON(http.StatusNotFound, errJSON)
 		}
 
-		return ctx.JSON(statusCode, errJSON)
+		return serverError(ctx, err, "could not fetch word")
+	}
+
+	senses, err := readSenses(ctx.Request().Context(), r.kalanQueries, kalan.ID)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch senses")
+	}
+
+	examples, err := r.readKalanExamples(ctx.Request().Context(), kalan.ID)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch examples")
+	}
+
+	domains, err := r.readKalanDomains(ctx.Request().Context(), kalan.ID)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch domains")
 	}
 
-	kalanDTO := NewKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes)
+	kalanDTO := r.newKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes, senses)
+	kalanDTO.Examples = examples
+	kalanDTO.Domains = domains
 	return ctx.JSON(http.StatusOK, kalanDTO)
 }
 
@@ -151,6 +256,26 @@ func (r *Router) GetKalanBySearch(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid search"))
 	}
 
+	mode, err := search.ParseMode(searchQueryDTO.Mode)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	filter, err := search.ParseFilter(searchQueryDTO.Query)
+	if err != nil {
+		var parseErr *search.ParseError
+		if errors.As(err, &parseErr) {
+			errJSON := QueryErrorJson{Error: parseErr.Error(), Position: parseErr.Position}
+			return ctx.JSON(http.StatusBadRequest, errJSON)
+		}
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
+	sortKeys, err := ParseSort(searchQueryDTO.Sort)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+	}
+
 	fields := NewFields(splitQuery(searchQueryDTO.Fields))
 
 	// pages start at 1
@@ -159,45 +284,96 @@ func (r *Router) GetKalanBySearch(ctx echo.Context) error {
 		page = 0
 	}
 
-	searchParams := kalan.ReadKalanBySearchParams{
-		Search:  searchQueryDTO.Search,
-		Isentry: fields.IsEntry,
-		Ispos:   fields.IsPos,
-		Isgloss: fields.IsGloss,
-		Isnotes: fields.IsNotes,
-		Sort:    searchQueryDTO.Sort,
-		Limit:   PAGE_SIZE,
-		Offset:  int32(PAGE_SIZE * page),
+	limit := searchQueryDTO.Limit
+	if limit == 0 {
+		limit = PAGE_SIZE
+	}
+	if limit < 1 || limit > MAX_PAGE_SIZE {
+		errMsg := fmt.Sprintf("limit must be between 1 and %v", MAX_PAGE_SIZE)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
 	}
 
-	kalans, err := r.kalanQueries.ReadKalanBySearch(r.ctx, searchParams)
+	index, err := r.searchIndex(ctx.Request().Context())
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, NewErrorJson("Could not fetch words"))
+		return serverError(ctx, err, "Could not fetch words")
 	}
 
-	var kalanArrayDTO KalanArrayDTO
-	for _, kalan := range kalans {
-		kalanDTO := NewKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes)
-		kalanArrayDTO.AddKalan(kalanDTO)
+	query := search.Query{
+		Text:   searchQueryDTO.Search,
+		Mode:   mode,
+		Fields: fields.searchFields(),
+	}
+	results := index.Search(query)
+	if filter != nil {
+		ids, err := r.store.ReadKalanIDsByFilter(ctx.Request().Context(), filter)
+		if err != nil {
+			return serverError(ctx, err, "Could not fetch words")
+		}
+		results = slices.DeleteFunc(results, func(result search.Result) bool {
+			_, found := slices.BinarySearch(ids, result.Kalan.ID)
+			return !found
+		})
+	}
+	if searchQueryDTO.Domain != "" {
+		kalanIDs, err := r.domainKalanIDs(ctx.Request().Context(), searchQueryDTO.Domain)
+		if err != nil {
+			if errors.Is(err, ErrNoSuchDomain) {
+				return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+			}
+			return serverError(ctx, err, "Could not fetch domains")
+		}
+		results = slices.DeleteFunc(results, func(result search.Result) bool {
+			return !kalanIDs[result.Kalan.ID]
+		})
+	}
+	order := resultOrder{keys: sortKeys, rank: searchQueryDTO.Rank, collator: r.collator}
+	order.sort(results)
+
+	// a cursor takes the place of the page number when it is given
+	resultPage := order.pageAt(results, limit*page, limit)
+	if searchQueryDTO.Cursor != "" {
+		cursor, err := DecodeCursor(searchQueryDTO.Cursor)
+		if err == nil {
+			resultPage, err = order.pageAtCursor(results, cursor, limit)
+		}
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		}
 	}
 
-	searchCountParams := kalan.ReadKalanSearchCountParams{
-		Search:  searchParams.Search,
-		Isentry: searchParams.Isentry,
-		Ispos:   searchParams.Ispos,
-		Isgloss: searchParams.Isgloss,
-		Isnotes: searchParams.Isnotes,
+	senses := map[int32][]SenseDTO{}
+	if len(resultPage.results) > 0 {
+		ids := []int32{}
+		for _, result := range resultPage.results {
+			ids = append(ids, result.Kalan.ID)
+		}
+		senses, err = r.readSensesByKalanIDs(ctx.Request().Context(), ids)
+		if err != nil {
+			return serverError(ctx, err, "Failed to fetch senses")
+		}
 	}
-	kalanCount, err := r.kalanQueries.ReadKalanSearchCount(r.ctx, searchCountParams)
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, NewErrorJson("Could not fetch words"))
+
+	var kalanArrayDTO KalanArrayDTO
+	for _, result := range resultPage.results {
+		kalan := result.Kalan
+		kalanDTO := r.newKalanDTO(int(kalan.ID), kalan.Entry, kalan.Pos, kalan.Gloss, kalan.Notes, senses[kalan.ID])
+		kalanArrayDTO.AddKalan(kalanDTO)
 	}
 
-	pageCount := getPageCount(int(kalanCount), PAGE_SIZE)
+	kalanCount := len(results)
+	pageCount := getPageCount(kalanCount, limit)
 
 	kalanArrayDTO.Page = searchQueryDTO.Page
-	kalanArrayDTO.KalanCount = int(kalanCount)
+	kalanArrayDTO.KalanCount = kalanCount
 	kalanArrayDTO.PageCount = pageCount
+	kalanArrayDTO.NextCursor = resultPage.nextCursor
+	kalanArrayDTO.PrevCursor = resultPage.prevCursor
+
+	// a search too long to look for suggestions is answered without them
+	if kalanCount == 0 && utf8.RuneCountInString(searchQueryDTO.Search) <= MAX_SUGGESTION_QUERY_LENGTH {
+		suggestions := index.Suggest(searchQueryDTO.Search, DEFAULT_SUGGESTION_LIMIT)
+		kalanArrayDTO.Suggestions = newSuggestionDTOs(suggestions)
+	}
 
 	return ctx.JSON(http.StatusOK, kalanArrayDTO)
 }
@@ -210,12 +386,24 @@ func (r *Router) AddKalan(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
+	err = resolveSenses(&kalanDTO, nil)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
 	err = validateKalanJson(&kalanDTO)
 	if err != nil && !errors.Is(err, ErrNoId) {
 		errJSON := NewErrorJson(err.Error())
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
+	kalanDTO.Warnings, err = r.checkPhonology(kalanDTO.Entry)
+	if err != nil {
+		errJSON := NewPhonologyErrorJson(kalanDTO.Warnings)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
 	createParams := kalan.CreateKalanParams{
 		Entry: kalanDTO.Entry,
 		Pos:   kalanDTO.Pos,
@@ -223,18 +411,19 @@ func (r *Router) AddKalan(ctx echo.Context) error {
 		Notes: kalanDTO.Notes,
 	}
 
-	result, err := r.kalanQueries.CreateKalan(r.ctx, createParams)
-	if err != nil {
-		errJSON := NewErrorJson("could not add kalan to database")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
+	userID, _ := ctx.Get("userID").(int)
 
-	kalanID, err := result.LastInsertId()
+	var kalanID int32
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		kalanID, err = r.createKalan(ctx.Request().Context(), tx, userID, createParams, kalanDTO.Senses)
+		return err
+	})
 	if err != nil {
-		ctx.Logger().Errorf("error fetching result id: %v\n", err.Error())
+		return serverError(ctx, err, "could not add kalan to database")
 	}
 
 	kalanDTO.ID = int(kalanID)
+	r.pronounce(&kalanDTO)
 	return ctx.JSON(http.StatusCreated, kalanDTO)
 }
 
@@ -246,12 +435,31 @@ func (r *Router) UpdateKalan(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
+	// senses that are left out keep their values
+	// if their gloss is still part of the gloss
+	current, err := readSenses(ctx.Request().Context(), r.kalanQueries, int32(kalanDTO.ID))
+	if err != nil {
+		return serverError(ctx, err, "could not fetch senses")
+	}
+
+	err = resolveSenses(&kalanDTO, current)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
 	err = validateKalanJson(&kalanDTO)
 	if err != nil {
 		errJSON := NewErrorJson(err.Error())
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
+	kalanDTO.Warnings, err = r.checkPhonology(kalanDTO.Entry)
+	if err != nil {
+		errJSON := NewPhonologyErrorJson(kalanDTO.Warnings)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
 	updateParams := kalan.UpdateKalanParams{
 		Entry: kalanDTO.Entry,
 		Pos:   kalanDTO.Pos,
@@ -260,24 +468,21 @@ func (r *Router) UpdateKalan(ctx echo.Context) error {
 		ID:    int32(kalanDTO.ID),
 	}
 
-	result, err := r.kalanQueries.UpdateKalan(r.ctx, updateParams)
-	if err != nil {
-		errJSON := NewErrorJson("could not update kalan")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
+	userID, _ := ctx.Get("userID").(int)
 
-	rowsAffected, err := result.RowsAffected()
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		return r.updateKalan(ctx.Request().Context(), tx, userID, REVISION_ACTION_UPDATE, updateParams, kalanDTO.Senses)
+	})
 	if err != nil {
-		errJSON := NewErrorJson("could not update kalan")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
-
-	if rowsAffected < 1 {
-		errMsg := fmt.Sprintf("no kalan with id=%v", kalanDTO.ID)
-		errJSON := NewErrorJson(errMsg)
-		return ctx.JSON(http.StatusNotFound, errJSON)
+		if errors.Is(err, ErrKalanGone) {
+			errMsg := fmt.Sprintf("no kalan with id=%v", kalanDTO.ID)
+			errJSON := NewErrorJson(errMsg)
+			return ctx.JSON(http.StatusNotFound, errJSON)
+		}
+		return serverError(ctx, err, "could not update kalan")
 	}
 
+	r.pronounce(&kalanDTO)
 	return ctx.JSON(http.StatusOK, kalanDTO)
 }
 
@@ -289,22 +494,18 @@ func (r *Router) DeleteKalan(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	result, err := r.kalanQueries.DeleteKalan(r.ctx, int32(kalanIDParam.ID))
-	if err != nil {
-		errJSON := NewErrorJson("could not delete kalan")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
+	userID, _ := ctx.Get("userID").(int)
 
-	rowsAffected, err := result.RowsAffected()
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		return r.deleteKalan(ctx.Request().Context(), tx, userID, int32(kalanIDParam.ID))
+	})
 	if err != nil {
-		errJSON := NewErrorJson("could not delete kalan")
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
-	}
-
-	if rowsAffected < 1 {
-		errMsg := fmt.Sprintf("no kalan with id=%v", kalanIDParam.ID)
-		errJSON := NewErrorJson(errMsg)
-		return ctx.JSON(http.StatusNotFound, errJSON)
+		if errors.Is(err, ErrKalanGone) {
+			errMsg := fmt.Sprintf("no kalan with id=%v", kalanIDParam.ID)
+			errJSON := NewErrorJson(errMsg)
+			return ctx.JSON(http.StatusNotFound, errJSON)
+		}
+		return serverError(ctx, err, "could not delete kalan")
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
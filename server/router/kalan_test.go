@@ -0,0 +1,154 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/search"
+)
+
+func TestKalanRoutes(t *testing.T) {
+	forEachStore(t, testKalanRoutes)
+}
+
+func testKalanRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	s.addKalan(t, "wilin", "word")
+	s.addKalan(t, "jan", "person")
+
+	rec := s.request(t, http.MethodGet, "/", nil, "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET / = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	rec = s.request(t, http.MethodPost, "/kalan", router.KalanDTO{Entry: "soweli", Pos: "noun", Gloss: "animal"}, token)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /kalan = %v %v", rec.Code, rec.Body.String())
+	}
+	created := decode[router.KalanDTO](t, rec)
+
+	rec = s.request(t, http.MethodGet, "/kalan", nil, "")
+	all := decode[router.KalanArrayDTO](t, rec)
+	if len(all.Kalans) != 3 {
+		t.Errorf("GET /kalan returned %v words, want 3", len(all.Kalans))
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/paginated?search=AN&fields=entry,gloss&sort=entry", nil, "")
+	found := decode[router.KalanArrayDTO](t, rec)
+	if found.KalanCount != 2 || len(found.Kalans) != 2 || found.Kalans[0].Entry != "jan" {
+		t.Errorf("GET /kalan/paginated = %+v, want jan and soweli", found)
+	}
+
+	updated := created
+	updated.Gloss = "animal, beast"
+	rec = s.request(t, http.MethodPut, "/kalan", updated, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /kalan = %v %v", rec.Code, rec.Body.String())
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/3/history", nil, token)
+	history := decode[router.RevisionArrDTO](t, rec)
+	if len(history.Revisions) != 2 || history.Revisions[0].Action != router.REVISION_ACTION_UPDATE {
+		t.Fatalf("GET /kalan/3/history = %+v, want update then create", history)
+	}
+	firstRevision := history.Revisions[1].ID
+
+	routeValues := []RouteValue{
+		{"get missing word", http.MethodGet, "/kalan/99", nil, "", http.StatusNotFound},
+		{"get invalid id", http.MethodGet, "/kalan/abc", nil, "", http.StatusBadRequest},
+		{"update missing word", http.MethodPut, "/kalan", router.KalanDTO{ID: 99, Entry: "a", Pos: "b", Gloss: "c"}, token, http.StatusNotFound},
+		{"revert word", http.MethodPost, "/kalan/3/revert/" + strconv.Itoa(firstRevision), nil, token, http.StatusOK},
+		{"revert to revision of another word", http.MethodPost, "/kalan/1/revert/" + strconv.Itoa(firstRevision), nil, token, http.StatusNotFound},
+		{"restore existing word", http.MethodPost, "/kalan/3/restore", nil, token, http.StatusConflict},
+		{"delete word", http.MethodDelete, "/kalan/3", nil, token, http.StatusNoContent},
+		{"delete deleted word", http.MethodDelete, "/kalan/3", nil, token, http.StatusNotFound},
+		{"get deleted word", http.MethodGet, "/kalan/3", nil, "", http.StatusNotFound},
+		{"restore deleted word", http.MethodPost, "/kalan/3/restore", nil, token, http.StatusCreated},
+		{"get restored word", http.MethodGet, "/kalan/3", nil, "", http.StatusOK},
+		{"restore word without history", http.MethodPost, "/kalan/99/restore", nil, token, http.StatusNotFound},
+	}
+	runRoutes(t, s, routeValues)
+
+	rec = s.request(t, http.MethodGet, "/kalan/3", nil, "")
+	restored := decode[router.KalanDTO](t, rec)
+	if restored.Gloss != "animal" {
+		t.Errorf("restored gloss = %q, want the reverted %q", restored.Gloss, "animal")
+	}
+
+	// a restore racing another one is told the word already exists
+	_, err := s.store.Kalan().CreateKalanWithID(context.Background(), kalan.CreateKalanWithIDParams{ID: 3, Entry: "soweli", Pos: "noun", Gloss: "animal"})
+	if !database.IsDuplicateKey(err) {
+		t.Errorf("creating a word with a taken id = %v, want a duplicate key", err)
+	}
+}
+
+func TestSearchRoutes(t *testing.T) {
+	forEachStore(t, testSearchRoutes)
+}
+
+func testSearchRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	s.addKalan(t, "wilin", "word")
+	s.addKalan(t, "wilinka", "dictionary")
+	s.addKalan(t, "kán", "to eat")
+
+	searchValues := []SearchValue{
+		{"search=ili", []string{"wilin", "wilinka"}},
+		{"search=KAN", []string{"kán"}},
+		{"search=eat&mode=word", []string{"kán"}},
+		{"search=ea&mode=word", []string{}},
+		{"search=ea&mode=prefix", []string{"kán"}},
+		{"search=wilin&mode=word", []string{"wilin"}},
+		{"search=wilin&mode=prefix&sort=gloss", []string{"wilinka", "wilin"}},
+		{"search=wilin&mode=prefix&sort=gloss&rank=true", []string{"wilin", "wilinka"}},
+		{"search=wilin&mode=prefix&fields=gloss", []string{}},
+		{"q=" + url.QueryEscape(`gloss:"to eat"`), []string{"kán"}},
+		{"q=" + url.QueryEscape("entry:^wil* -gloss:word"), []string{"wilinka"}},
+		{"search=wilin&q=" + url.QueryEscape("gloss:word OR gloss:dictionary"), []string{"wilin", "wilinka"}},
+		{"q=" + url.QueryEscape(`gloss:"eat') OR 1=1 --"`), []string{}},
+		{"q=" + url.QueryEscape("gloss:eat?"), []string{"kán"}},
+	}
+	for _, test := range searchValues {
+		entries := s.searchEntries(t, test.query)
+		if !slices.Equal(entries, test.expected) {
+			t.Errorf("GET /kalan/paginated?%v = %v, want %v", test.query, entries, test.expected)
+		}
+	}
+
+	rec := s.request(t, http.MethodGet, "/kalan/paginated?q="+url.QueryEscape("pos:noun color:red"), nil, "")
+	queryErr := decode[router.QueryErrorJson](t, rec)
+	if rec.Code != http.StatusBadRequest || queryErr.Position != 10 {
+		t.Errorf("GET /kalan/paginated with an unknown field = %v %+v, want %v at position 10", rec.Code, queryErr, http.StatusBadRequest)
+	}
+
+	deep := strings.Repeat("(", search.MAX_FILTER_DEPTH+1) + "wilin"
+	rec = s.request(t, http.MethodGet, "/kalan/paginated?q="+url.QueryEscape(deep), nil, "")
+	queryErr = decode[router.QueryErrorJson](t, rec)
+	if rec.Code != http.StatusBadRequest || queryErr.Position != search.MAX_FILTER_DEPTH+1 {
+		t.Errorf("GET /kalan/paginated with a nested query = %v %+v, want %v at position %v", rec.Code, queryErr, http.StatusBadRequest, search.MAX_FILTER_DEPTH+1)
+	}
+
+	routeValues := []RouteValue{
+		{"unknown search mode", http.MethodGet, "/kalan/paginated?mode=fuzzy", nil, "", http.StatusBadRequest},
+		{"invalid rank", http.MethodGet, "/kalan/paginated?rank=maybe", nil, "", http.StatusBadRequest},
+		{"add word", http.MethodPost, "/kalan", router.KalanDTO{Entry: "wilinpa", Pos: "noun", Gloss: "speaker"}, token, http.StatusCreated},
+		{"delete word", http.MethodDelete, "/kalan/2", nil, token, http.StatusNoContent},
+	}
+	runRoutes(t, s, routeValues)
+
+	// the index follows the changes made through the api
+	entries := s.searchEntries(t, "search=wilin&mode=prefix&rank=true")
+	if !slices.Equal(entries, []string{"wilin", "wilinpa"}) {
+		t.Errorf("search after adding and deleting = %v, want [wilin wilinpa]", entries)
+	}
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/server/phonology"
+)
+
+const MAX_TRANSCRIPTION_LENGTH = 1000
+
+var ErrInvalidEntry = errors.New("entry does not follow the phonology")
+
+type PhonologyViolationDTO struct {
+	Reason string `json:"reason"`
+	Offset int    `json:"offset"`
+	Text   string `json:"text"`
+}
+
+func NewPhonologyViolationDTO(violation phonology.Violation) PhonologyViolationDTO {
+	return PhonologyViolationDTO{
+		Reason: violation.Reason,
+		Offset: violation.Offset,
+		Text:   violation.Text,
+	}
+}
+
+// PhonologyErrorJson is an entry that was rejected
+// along with every rule of the phonology it breaks
+type PhonologyErrorJson struct {
+	Error      string                  `json:"error"`
+	Violations []PhonologyViolationDTO `json:"violations"`
+}
+
+// checkPhonology validates entry against the phonology of the
+// language. It returns the rules entry breaks so they can be sent
+// back as warnings, or ErrInvalidEntry along with them if the
+// phonology rejects invalid entries
+func (r *Router) checkPhonology(entry string) ([]PhonologyViolationDTO, error) {
+	violations := r.phonology.Validate(entry)
+	if len(violations) < 1 {
+		return nil, nil
+	}
+
+	violationDTOs := []PhonologyViolationDTO{}
+	for _, violation := range violations {
+		violationDTOs = append(violationDTOs, NewPhonologyViolationDTO(violation))
+	}
+
+	if r.phonology.Mode() == phonology.MODE_REJECT {
+		return violationDTOs, ErrInvalidEntry
+	}
+	return violationDTOs, nil
+}
+
+func NewPhonologyErrorJson(violations []PhonologyViolationDTO) PhonologyErrorJson {
+	return PhonologyErrorJson{
+		Error:      ErrInvalidEntry.Error(),
+		Violations: violations,
+	}
+}
+
+// pronounce fills in the IPA and syllables of kalanDTO from its entry
+func (r *Router) pronounce(kalanDTO *KalanDTO) {
+	transcription := r.phonology.Transcribe(kalanDTO.Entry)
+	kalanDTO.IPA = transcription.IPA
+	kalanDTO.Syllables = transcription.Syllables
+}
+
+type TranscribeQueryDTO struct {
+	Text string `query:"text"`
+}
+
+type TranscriptionDTO struct {
+	Text      string   `json:"text"`
+	IPA       string   `json:"ipa"`
+	Syllables []string `json:"syllables"`
+}
+
+func (r *Router) Transcribe(ctx echo.Context) error {
+	var transcribeQueryDTO TranscribeQueryDTO
+	err := ctx.Bind(&transcribeQueryDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid query"))
+	}
+
+	text := transcribeQueryDTO.Text
+	if text == "" {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("no text"))
+	}
+	if utf8.RuneCountInString(text) > MAX_TRANSCRIPTION_LENGTH {
+		errMsg := fmt.Sprintf("text must be at most %v characters", MAX_TRANSCRIPTION_LENGTH)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	transcription := r.phonology.Transcribe(text)
+	transcriptionDTO := TranscriptionDTO{
+		Text:      text,
+		IPA:       transcription.IPA,
+		Syllables: transcription.Syllables,
+	}
+	return ctx.JSON(http.StatusOK, transcriptionDTO)
+}
@@ -0,0 +1,119 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+func TestPhonologyRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phonology.json")
+	config := `{
+		"mode": "reject",
+		"vowels": ["a", "e", "i", "o", "u"],
+		"consonants": ["j", "k", "l", "n", "p", "s", "w"],
+		"syllables": ["(C)V(C)"],
+		"forbidden": ["wu"]
+	}`
+	err := os.WriteFile(path, []byte(config), 0o644)
+	if err != nil {
+		t.Fatalf("could not write phonology: %v", err)
+	}
+	t.Setenv("PHONOLOGY_FILE", path)
+
+	forEachStore(t, testPhonologyRoutes)
+}
+
+func testPhonologyRoutes(t *testing.T, s *testServer) {
+	admin := s.addUser(t, "admin", services.ROLE_ADMIN, true)
+	user := s.addUser(t, "user", services.ROLE_USER, true)
+	id := s.addKalan(t, "wilin", "word")
+
+	routeValues := []RouteValue{
+		{"add valid word", http.MethodPost, "/kalan", router.KalanDTO{Entry: "jan", Pos: "noun", Gloss: "person"}, admin.AuthToken, http.StatusCreated},
+		{"add unknown letter", http.MethodPost, "/kalan", router.KalanDTO{Entry: "jab", Pos: "noun", Gloss: "person"}, admin.AuthToken, http.StatusBadRequest},
+		{"add forbidden cluster", http.MethodPost, "/kalan", router.KalanDTO{Entry: "wuna", Pos: "noun", Gloss: "person"}, admin.AuthToken, http.StatusBadRequest},
+		{"update to invalid syllable", http.MethodPut, "/kalan", router.KalanDTO{ID: int(id), Entry: "wilnsa", Pos: "noun", Gloss: "word"}, admin.AuthToken, http.StatusBadRequest},
+		{"propose invalid word", http.MethodPost, "/proposal", router.ProposalDTO{Entry: "sppa", Pos: "noun", Gloss: "spa"}, user.AuthToken, http.StatusBadRequest},
+		{"propose deletion", http.MethodPost, "/proposal", router.ProposalDTO{Kind: router.PROPOSAL_KIND_DELETE, KalanID: int(id)}, user.AuthToken, http.StatusCreated},
+	}
+	runRoutes(t, s, routeValues)
+
+	rec := s.request(t, http.MethodPut, "/kalan", router.KalanDTO{ID: int(id), Entry: "wilinsx", Pos: "noun", Gloss: "word"}, admin.AuthToken)
+	rejected := decode[router.PhonologyErrorJson](t, rec)
+	expected := []router.PhonologyViolationDTO{{Reason: phonology.REASON_UNKNOWN_SEGMENT, Offset: 6, Text: "x"}}
+	if rec.Code != http.StatusBadRequest || !slices.Equal(rejected.Violations, expected) {
+		t.Errorf("PUT /kalan = %v %+v, want %v", rec.Code, rejected.Violations, expected)
+	}
+}
+
+func TestPhonologyWarnings(t *testing.T) {
+	forEachStore(t, testPhonologyWarnings)
+}
+
+func testPhonologyWarnings(t *testing.T, s *testServer) {
+	admin := s.addUser(t, "admin", services.ROLE_ADMIN, true)
+
+	// the default phonology only warns about invalid entries
+	rec := s.request(t, http.MethodPost, "/kalan", router.KalanDTO{Entry: "strin", Pos: "noun", Gloss: "string"}, admin.AuthToken)
+	created := decode[router.KalanDTO](t, rec)
+	expected := []router.PhonologyViolationDTO{{Reason: phonology.REASON_INVALID_SYLLABLE, Offset: 0, Text: "s"}}
+	if rec.Code != http.StatusCreated || !slices.Equal(created.Warnings, expected) {
+		t.Errorf("POST /kalan = %v %+v, want %v", rec.Code, created.Warnings, expected)
+	}
+
+	rec = s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v", created.ID), nil, "")
+	if rec.Code != http.StatusOK || decode[router.KalanDTO](t, rec).Entry != "strin" {
+		t.Errorf("GET /kalan/%v = %v, want strin", created.ID, rec.Code)
+	}
+}
+
+func TestTranscriptionRoutes(t *testing.T) {
+	forEachStore(t, testTranscriptionRoutes)
+}
+
+func testTranscriptionRoutes(t *testing.T, s *testServer) {
+	admin := s.addUser(t, "admin", services.ROLE_ADMIN, true)
+	id := s.addKalan(t, "wilinka", "dictionary")
+
+	rec := s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v", id), nil, "")
+	found := decode[router.KalanDTO](t, rec)
+	if found.IPA != "ˈwi.liŋ.ka" || !slices.Equal(found.Syllables, []string{"wi", "lin", "ka"}) {
+		t.Errorf("GET /kalan/%v = %q %v, want ˈwi.liŋ.ka [wi lin ka]", id, found.IPA, found.Syllables)
+	}
+
+	// computed fields sent by clients are ignored
+	rec = s.request(t, http.MethodPost, "/kalan", router.KalanDTO{Entry: "jan", Pos: "noun", Gloss: "person", IPA: "dʒan"}, admin.AuthToken)
+	created := decode[router.KalanDTO](t, rec)
+	if created.IPA != "jan" || !slices.Equal(created.Syllables, []string{"jan"}) {
+		t.Errorf("POST /kalan = %q %v, want jan [jan]", created.IPA, created.Syllables)
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/paginated?search=wilin", nil, "")
+	all := decode[router.KalanArrayDTO](t, rec)
+	if len(all.Kalans) != 1 || all.Kalans[0].IPA != "ˈwi.liŋ.ka" {
+		t.Errorf("GET /kalan/paginated?search=wilin = %+v, want wilinka with its IPA", all.Kalans)
+	}
+
+	rec = s.request(t, http.MethodGet, "/phonology/transcribe?text="+url.QueryEscape("Kán wilin"), nil, "")
+	transcription := decode[router.TranscriptionDTO](t, rec)
+	expected := router.TranscriptionDTO{Text: "Kán wilin", IPA: "kán ˈwi.lin", Syllables: []string{"kán", "wi", "lin"}}
+	if transcription.IPA != expected.IPA || transcription.Text != expected.Text || !slices.Equal(transcription.Syllables, expected.Syllables) {
+		t.Errorf("GET /phonology/transcribe = %+v, want %+v", transcription, expected)
+	}
+
+	routeValues := []RouteValue{
+		{"transcribe nothing", http.MethodGet, "/phonology/transcribe", nil, "", http.StatusBadRequest},
+		{"transcribe long text", http.MethodGet, "/phonology/transcribe?text=" + strings.Repeat("a", router.MAX_TRANSCRIPTION_LENGTH+1), nil, "", http.StatusBadRequest},
+	}
+	runRoutes(t, s, routeValues)
+}
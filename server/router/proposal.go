@@ -1,20 +1,41 @@
 package router
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"wilin.info/api/database"
+	"wilin.info/api/database/kalan"
 	"wilin.info/api/database/proposal"
 	"wilin.info/api/server/services"
 )
 
+const (
+	PROPOSAL_STATUS_PENDING   = "pending"
+	PROPOSAL_STATUS_APPROVED  = "approved"
+	PROPOSAL_STATUS_REJECTED  = "rejected"
+	PROPOSAL_STATUS_WITHDRAWN = "withdrawn"
+)
+
+const (
+	PROPOSAL_KIND_NEW    = "new"
+	PROPOSAL_KIND_AMEND  = "amend"
+	PROPOSAL_KIND_DELETE = "delete"
+)
+
 type ProposalIDDTO struct {
 	ID int `param:"id"`
 }
 
+type RejectProposalDTO struct {
+	ID     int    `param:"id"`
+	Reason string `json:"reason" form:"reason"`
+}
+
 type ProposalDTO struct {
 	Id       int    `json:"id" form:"id"`
 	UserId   int    `json:"userId" form:"userId"`
@@ -23,16 +44,88 @@ type ProposalDTO struct {
 	Pos      string `json:"pos" form:"pos"`
 	Gloss    string `json:"gloss" form:"gloss"`
 	Notes    string `json:"notes" form:"notes"`
+	Status   string `json:"status" form:"status"`
+	Reason   string `json:"reason,omitempty" form:"reason"`
+	Kind     string `json:"kind" form:"kind"`
+	KalanID  int    `json:"kalanId,omitempty" form:"kalanId"`
+
+	Diff []FieldDiffDTO `json:"diff,omitempty"`
+
+	// Warnings holds the rules of the phonology that the
+	// proposed entry breaks, when they are not rejected
+	Warnings []PhonologyViolationDTO `json:"warnings,omitempty" form:"-"`
+}
+
+// FieldDiffDTO describes a single field that a proposal
+// would change on an existing kalan entry
+type FieldDiffDTO struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+}
+
+// diffProposal compares the values of a proposal against
+// the current kalan it refers to and returns the fields
+// that would change if the proposal were approved
+func diffProposal(current kalan.Kalan, dto *ProposalDTO) []FieldDiffDTO {
+	proposed := kalan.Kalan{
+		Entry: dto.Entry,
+		Pos:   dto.Pos,
+		Gloss: dto.Gloss,
+		Notes: dto.Notes,
+	}
+	if dto.Kind == PROPOSAL_KIND_DELETE {
+		proposed = kalan.Kalan{}
+	}
+
+	fields := []struct {
+		name     string
+		current  string
+		proposed string
+	}{
+		{"entry", current.Entry, proposed.Entry},
+		{"pos", current.Pos, proposed.Pos},
+		{"gloss", current.Gloss, proposed.Gloss},
+		{"notes", current.Notes, proposed.Notes},
+	}
+
+	diff := []FieldDiffDTO{}
+	for _, field := range fields {
+		if field.current == field.proposed {
+			continue
+		}
+		diff = append(diff, FieldDiffDTO{
+			Field:    field.name,
+			Current:  field.current,
+			Proposed: field.proposed,
+		})
+	}
+	return diff
 }
 
 func validateProposalJSON(dto *ProposalDTO) error {
-	if dto.Entry == "" {
+	if dto.Kind == "" {
+		dto.Kind = PROPOSAL_KIND_NEW
+	}
+
+	switch dto.Kind {
+	case PROPOSAL_KIND_NEW:
+		dto.KalanID = 0
+	case PROPOSAL_KIND_AMEND, PROPOSAL_KIND_DELETE:
+		if dto.KalanID == 0 {
+			return ErrNoKalanID
+		}
+	default:
+		return ErrInvalidKind
+	}
+
+	if dto.Kind != PROPOSAL_KIND_DELETE && dto.Entry == "" {
 		return ErrNoEntry
 	}
-	if dto.Pos == "" {
+	if dto.Kind != PROPOSAL_KIND_DELETE && dto.Pos == "" {
 		return ErrNoPos
 	}
-	if dto.Gloss == "" {
+	if dto.Kind != PROPOSAL_KIND_DELETE && dto.Gloss == "" {
 		return ErrNoGloss
 	}
 	if dto.Id == 0 {
@@ -50,10 +143,9 @@ func (pArr *ProposalArrDTO) AddProposal(p ProposalDTO) {
 }
 
 func (r *Router) GetAllProposals(ctx echo.Context) error {
-	proposals, err := r.proposalQueries.ReadAllProposalsWithUsername(r.ctx)
+	proposals, err := r.proposalQueries.ReadAllProposalsWithUsername(ctx.Request().Context())
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	proposalArrDTO := new(ProposalArrDTO)
@@ -67,6 +159,10 @@ func (r *Router) GetAllProposals(ctx echo.Context) error {
 			Pos:      p.Pos,
 			Gloss:    p.Gloss,
 			Notes:    p.Notes,
+			Status:   p.Status,
+			Reason:   p.Reason,
+			Kind:     p.Kind,
+			KalanID:  int(p.KalanID.Int32),
 		}
 		proposalArrDTO.AddProposal(proposalDto)
 	}
@@ -93,17 +189,50 @@ func (r *Router) PostProposal(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	createParams := proposal.CreateProposalParams{
-		UserID: sql.NullInt32{Int32: int32(userID), Valid: true},
-		Entry:  proposalDTO.Entry,
-		Pos:    proposalDTO.Pos,
-		Gloss:  proposalDTO.Gloss,
-		Notes:  proposalDTO.Notes,
+	// a deletion keeps the entry of the word it removes
+	if proposalDTO.Kind != PROPOSAL_KIND_DELETE {
+		proposalDTO.Warnings, err = r.checkPhonology(proposalDTO.Entry)
+		if err != nil {
+			errJSON := NewPhonologyErrorJson(proposalDTO.Warnings)
+			return ctx.JSON(http.StatusBadRequest, errJSON)
+		}
 	}
-	result, err := r.proposalQueries.CreateProposal(r.ctx, createParams)
+
+	kalanID := sql.NullInt32{}
+	if proposalDTO.Kind != PROPOSAL_KIND_NEW {
+		current, err := r.kalanQueries.ReadKalanById(ctx.Request().Context(), int32(proposalDTO.KalanID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				errJSON := NewErrorJson("invalid word, does not exist")
+				return ctx.JSON(http.StatusNotFound, errJSON)
+			}
+			return serverError(ctx, err, ServerError)
+		}
+
+		// a deletion carries the values of the word it removes
+		if proposalDTO.Kind == PROPOSAL_KIND_DELETE {
+			proposalDTO.Entry = current.Entry
+			proposalDTO.Pos = current.Pos
+			proposalDTO.Gloss = current.Gloss
+			proposalDTO.Notes = current.Notes
+		}
+
+		kalanID = sql.NullInt32{Int32: current.ID, Valid: true}
+		proposalDTO.Diff = diffProposal(current, proposalDTO)
+	}
+
+	createParams := proposal.CreateProposalParams{
+		UserID:  sql.NullInt32{Int32: int32(userID), Valid: true},
+		Entry:   proposalDTO.Entry,
+		Pos:     proposalDTO.Pos,
+		Gloss:   proposalDTO.Gloss,
+		Notes:   proposalDTO.Notes,
+		Kind:    proposalDTO.Kind,
+		KalanID: kalanID,
+	}
+	result, err := r.proposalQueries.CreateProposal(ctx.Request().Context(), createParams)
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	proposalID, err := result.LastInsertId()
@@ -113,6 +242,8 @@ func (r *Router) PostProposal(ctx echo.Context) error {
 
 	proposalDTO.Id = int(proposalID)
 	proposalDTO.UserId = userID
+	proposalDTO.Status = PROPOSAL_STATUS_PENDING
+	proposalDTO.Reason = ""
 
 	return ctx.JSON(http.StatusCreated, proposalDTO)
 }
@@ -123,10 +254,9 @@ func (r *Router) GetMyProposals(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	proposals, err := r.proposalQueries.ReadProposalsByUserIDWithUsername(r.ctx, int32(userID))
+	proposals, err := r.proposalQueries.ReadProposalsByUserIDWithUsername(ctx.Request().Context(), int32(userID))
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	proposalArrDTO := ProposalArrDTO{Proposals: []ProposalDTO{}}
@@ -139,6 +269,10 @@ func (r *Router) GetMyProposals(ctx echo.Context) error {
 			Pos:      proposal.Pos,
 			Gloss:    proposal.Gloss,
 			Notes:    proposal.Notes,
+			Status:   proposal.Status,
+			Reason:   proposal.Reason,
+			Kind:     proposal.Kind,
+			KalanID:  int(proposal.KalanID.Int32),
 		}
 		proposalArrDTO.AddProposal(proposalDTO)
 	}
@@ -159,14 +293,13 @@ func (r *Router) GetProposalByID(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	user, err := r.userQueries.ReadUserByID(r.ctx, int32(userID))
+	user, err := r.userQueries.ReadUserByID(ctx.Request().Context(), int32(userID))
 	if err != nil {
 		ctx.Logger().Errorf("could not fetch user: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	proposal, err := r.proposalQueries.ReadProposalByIDWithUsername(r.ctx, int32(params.ID))
+	proposal, err := r.proposalQueries.ReadProposalByIDWithUsername(ctx.Request().Context(), int32(params.ID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			msg := fmt.Sprintf("no proposal with id=%v", params.ID)
@@ -174,11 +307,10 @@ func (r *Router) GetProposalByID(ctx echo.Context) error {
 			return ctx.JSON(http.StatusNotFound, errJSON)
 		}
 		ctx.Logger().Errorf("could not fetch proposal: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	userRole := services.NewRole(user.Role)
+	userRole := services.NewUserRole(user.Role, user.Verified)
 	isUserOwner := userRole.Can(services.PERMISSION_VIEW_SELF_PROPOSAL) && user.ID == proposal.UserID.Int32
 	isUserAdmin := userRole.Can(services.PERMISSION_VIEW_ALL_PROPOSAL)
 
@@ -194,6 +326,21 @@ func (r *Router) GetProposalByID(ctx echo.Context) error {
 		Pos:      proposal.Pos,
 		Gloss:    proposal.Gloss,
 		Notes:    proposal.Notes,
+		Status:   proposal.Status,
+		Reason:   proposal.Reason,
+		Kind:     proposal.Kind,
+		KalanID:  int(proposal.KalanID.Int32),
+	}
+
+	if proposal.Kind != PROPOSAL_KIND_NEW && proposal.KalanID.Valid {
+		current, err := r.kalanQueries.ReadKalanById(ctx.Request().Context(), proposal.KalanID.Int32)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			ctx.Logger().Errorf("could not fetch kalan: %v", err.Error())
+			return serverError(ctx, err, ServerError)
+		}
+		if err == nil {
+			proposalDTO.Diff = diffProposal(current, &proposalDTO)
+		}
 	}
 
 	return ctx.JSON(http.StatusOK, proposalDTO)
@@ -212,14 +359,13 @@ func (r *Router) UpdateProposal(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	user, err := r.userQueries.ReadUserByID(r.ctx, int32(userID))
+	user, err := r.userQueries.ReadUserByID(ctx.Request().Context(), int32(userID))
 	if err != nil {
 		ctx.Logger().Errorf("could not fetch user: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	prop, err := r.proposalQueries.ReadProposalByIDWithUsername(r.ctx, int32(proposalDTO.Id))
+	prop, err := r.proposalQueries.ReadProposalByIDWithUsername(ctx.Request().Context(), int32(proposalDTO.Id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			msg := fmt.Sprintf("no proposal with id=%v", proposalDTO.Id)
@@ -227,11 +373,10 @@ func (r *Router) UpdateProposal(ctx echo.Context) error {
 			return ctx.JSON(http.StatusNotFound, errJSON)
 		}
 		ctx.Logger().Errorf("could not fetch proposal: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	userRole := services.NewRole(user.Role)
+	userRole := services.NewUserRole(user.Role, user.Verified)
 	isUserOwner := userRole.Can(services.PERMISSION_MODIFY_SELF_PROPOSAL) && user.ID == prop.UserID.Int32
 	isUserAdmin := userRole.Can(services.PERMISSION_MODIFY_ALL_PROPOSAL)
 
@@ -239,6 +384,33 @@ func (r *Router) UpdateProposal(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusForbidden)
 	}
 
+	if prop.Status != PROPOSAL_STATUS_PENDING {
+		errJSON := NewErrorJson(ErrProposalNotPending.Error())
+		return ctx.JSON(http.StatusConflict, errJSON)
+	}
+
+	// a deletion carries the values of the word it removes, so only
+	// its kind and word are the proposal
+	if prop.Kind == PROPOSAL_KIND_DELETE {
+		errJSON := NewErrorJson(ErrDeleteNotEditable.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	// the kind and word of a proposal are fixed once it is made
+	proposalDTO.Kind = prop.Kind
+	proposalDTO.KalanID = int(prop.KalanID.Int32)
+	err = validateProposalJSON(&proposalDTO)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	proposalDTO.Warnings, err = r.checkPhonology(proposalDTO.Entry)
+	if err != nil {
+		errJSON := NewPhonologyErrorJson(proposalDTO.Warnings)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
 	updateParams := proposal.UpdateParams{
 		UserID: sql.NullInt32{Int32: prop.UserID.Int32, Valid: true},
 		Entry:  proposalDTO.Entry,
@@ -248,15 +420,27 @@ func (r *Router) UpdateProposal(ctx echo.Context) error {
 		ID:     int32(proposalDTO.Id),
 	}
 
-	_, err = r.proposalQueries.Update(r.ctx, updateParams)
+	result, err := r.proposalQueries.Update(ctx.Request().Context(), updateParams)
 	if err != nil {
 		ctx.Logger().Errorf("could not update proposal: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
+	}
+
+	// the proposal may have been reviewed since it was read
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		ctx.Logger().Errorf("could not update proposal: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+	if rowsAffected < 1 {
+		errJSON := NewErrorJson(ErrProposalNotPending.Error())
+		return ctx.JSON(http.StatusConflict, errJSON)
 	}
 
 	proposalDTO.UserId = int(prop.UserID.Int32)
 	proposalDTO.Username = prop.Username
+	proposalDTO.Status = prop.Status
+	proposalDTO.Reason = prop.Reason
 
 	return ctx.JSON(http.StatusOK, proposalDTO)
 }
@@ -274,14 +458,13 @@ func (r *Router) DeleteProposal(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusUnauthorized)
 	}
 
-	user, err := r.userQueries.ReadUserByID(r.ctx, int32(userID))
+	user, err := r.userQueries.ReadUserByID(ctx.Request().Context(), int32(userID))
 	if err != nil {
 		ctx.Logger().Errorf("could not fetch user: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	proposal, err := r.proposalQueries.ReadProposalByIDWithUsername(r.ctx, int32(params.ID))
+	proposal, err := r.proposalQueries.ReadProposalByIDWithUsername(ctx.Request().Context(), int32(params.ID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			msg := fmt.Sprintf("no proposal with id=%v", params.ID)
@@ -289,11 +472,10 @@ func (r *Router) DeleteProposal(ctx echo.Context) error {
 			return ctx.JSON(http.StatusNotFound, errJSON)
 		}
 		ctx.Logger().Errorf("could not fetch proposal: %v", err.Error())
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	userRole := services.NewRole(user.Role)
+	userRole := services.NewUserRole(user.Role, user.Verified)
 	isUserOwner := userRole.Can(services.PERMISSION_DELETE_SELF_PROPOSAL) && user.ID == proposal.UserID.Int32
 	isUserAdmin := userRole.Can(services.PERMISSION_DELETE_ALL_PROPOSAL)
 
@@ -301,11 +483,250 @@ func (r *Router) DeleteProposal(ctx echo.Context) error {
 		return ctx.NoContent(http.StatusForbidden)
 	}
 
-	_, err = r.proposalQueries.Delete(r.ctx, int32(params.ID))
+	_, err = r.proposalQueries.Delete(ctx.Request().Context(), int32(params.ID))
 	if err != nil {
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// setProposalStatus moves a pending proposal to the given status.
+// It returns ErrProposalNotPending if the proposal has already
+// been reviewed or withdrawn
+func (r *Router) setProposalStatus(ctx context.Context, tx database.Store, id int32, status string, reason string) error {
+	updateParams := proposal.UpdateStatusParams{
+		Status: status,
+		Reason: reason,
+		ID:     id,
+	}
+	result, err := tx.Proposal().UpdateStatus(ctx, updateParams)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected < 1 {
+		return ErrProposalNotPending
+	}
+
+	return nil
+}
+
+// readPendingProposal fetches the proposal with the given id.
+// It returns the status code and error to respond with if the
+// proposal does not exist or is no longer pending
+func (r *Router) readPendingProposal(ctx context.Context, id int) (proposal.ReadProposalByIDWithUsernameRow, int, error) {
+	prop, err := r.proposalQueries.ReadProposalByIDWithUsername(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return prop, http.StatusNotFound, fmt.Errorf("no proposal with id=%v", id)
+		}
+		statusCode, ctxErr := requestEnded(err)
+		if ctxErr != nil {
+			return prop, statusCode, ctxErr
+		}
+		return prop, http.StatusInternalServerError, errors.New(ServerError)
+	}
+
+	if prop.Status != PROPOSAL_STATUS_PENDING {
+		return prop, http.StatusConflict, ErrProposalNotPending
+	}
+
+	return prop, http.StatusOK, nil
+}
+
+// applyProposal makes the change described by an approved
+// proposal to the kalan table on behalf of the reviewer. It
+// returns ErrKalanGone if the word an amendment or deletion
+// refers to no longer exists
+func (r *Router) applyProposal(ctx context.Context, tx database.Store, userID int, prop *proposal.ReadProposalByIDWithUsernameRow) error {
+	switch prop.Kind {
+	case PROPOSAL_KIND_NEW:
+		senses := sensesFromGloss(prop.Gloss, nil)
+		createParams := kalan.CreateKalanParams{
+			Entry: prop.Entry,
+			Pos:   prop.Pos,
+			Gloss: glossSummary(senses),
+			Notes: prop.Notes,
+		}
+		_, err := r.createKalan(ctx, tx, userID, createParams, senses)
+		return err
+	case PROPOSAL_KIND_AMEND:
+		if !prop.KalanID.Valid {
+			return ErrKalanGone
+		}
+		updateParams := kalan.UpdateKalanParams{
+			Entry: prop.Entry,
+			Pos:   prop.Pos,
+			Gloss: prop.Gloss,
+			Notes: prop.Notes,
+			ID:    prop.KalanID.Int32,
+		}
+
+		// proposals only have a gloss, so the senses
+		// whose gloss is unchanged keep their values
+		current, err := readSenses(ctx, tx.Kalan(), prop.KalanID.Int32)
+		if err != nil {
+			return err
+		}
+		senses := sensesFromGloss(prop.Gloss, current)
+		updateParams.Gloss = glossSummary(senses)
+		return r.updateKalan(ctx, tx, userID, REVISION_ACTION_UPDATE, updateParams, senses)
+	case PROPOSAL_KIND_DELETE:
+		if !prop.KalanID.Valid {
+			return ErrKalanGone
+		}
+		return r.deleteKalan(ctx, tx, userID, prop.KalanID.Int32)
+	default:
+		return ErrInvalidKind
+	}
+}
+
+func (r *Router) ApproveProposal(ctx echo.Context) error {
+	params := ProposalIDDTO{}
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	prop, statusCode, err := r.readPendingProposal(ctx.Request().Context(), params.ID)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(statusCode, errJSON)
+	}
+
+	userID, _ := ctx.Get("userID").(int)
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		err := r.applyProposal(ctx.Request().Context(), tx, userID, &prop)
+		if err != nil {
+			return err
+		}
+
+		return r.setProposalStatus(ctx.Request().Context(), tx, prop.ID, PROPOSAL_STATUS_APPROVED, "")
+	})
+	if err != nil {
+		if errors.Is(err, ErrProposalNotPending) || errors.Is(err, ErrKalanGone) {
+			errJSON := NewErrorJson(err.Error())
+			return ctx.JSON(http.StatusConflict, errJSON)
+		}
+		ctx.Logger().Errorf("could not approve proposal: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	proposalDTO := ProposalDTO{
+		Id:       int(prop.ID),
+		UserId:   int(prop.UserID.Int32),
+		Username: prop.Username,
+		Entry:    prop.Entry,
+		Pos:      prop.Pos,
+		Gloss:    prop.Gloss,
+		Notes:    prop.Notes,
+		Status:   PROPOSAL_STATUS_APPROVED,
+		Kind:     prop.Kind,
+		KalanID:  int(prop.KalanID.Int32),
+	}
+
+	return ctx.JSON(http.StatusOK, proposalDTO)
+}
+
+func (r *Router) RejectProposal(ctx echo.Context) error {
+	params := RejectProposalDTO{}
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	if params.Reason == "" {
+		errJSON := NewErrorJson(ErrNoReason.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	prop, statusCode, err := r.readPendingProposal(ctx.Request().Context(), params.ID)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(statusCode, errJSON)
+	}
+
+	err = r.setProposalStatus(ctx.Request().Context(), r.store, prop.ID, PROPOSAL_STATUS_REJECTED, params.Reason)
+	if err != nil {
+		if errors.Is(err, ErrProposalNotPending) {
+			errJSON := NewErrorJson(err.Error())
+			return ctx.JSON(http.StatusConflict, errJSON)
+		}
+		ctx.Logger().Errorf("could not reject proposal: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	proposalDTO := ProposalDTO{
+		Id:       int(prop.ID),
+		UserId:   int(prop.UserID.Int32),
+		Username: prop.Username,
+		Entry:    prop.Entry,
+		Pos:      prop.Pos,
+		Gloss:    prop.Gloss,
+		Notes:    prop.Notes,
+		Status:   PROPOSAL_STATUS_REJECTED,
+		Reason:   params.Reason,
+		Kind:     prop.Kind,
+		KalanID:  int(prop.KalanID.Int32),
+	}
+
+	return ctx.JSON(http.StatusOK, proposalDTO)
+}
+
+func (r *Router) WithdrawProposal(ctx echo.Context) error {
+	params := ProposalIDDTO{}
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	userID, ok := ctx.Get("userID").(int)
+	if !ok {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	prop, statusCode, err := r.readPendingProposal(ctx.Request().Context(), params.ID)
+	if err != nil {
+		errJSON := NewErrorJson(err.Error())
+		return ctx.JSON(statusCode, errJSON)
+	}
+
+	if prop.UserID.Int32 != int32(userID) {
+		return ctx.NoContent(http.StatusForbidden)
+	}
+
+	err = r.setProposalStatus(ctx.Request().Context(), r.store, prop.ID, PROPOSAL_STATUS_WITHDRAWN, "")
+	if err != nil {
+		if errors.Is(err, ErrProposalNotPending) {
+			errJSON := NewErrorJson(err.Error())
+			return ctx.JSON(http.StatusConflict, errJSON)
+		}
+		ctx.Logger().Errorf("could not withdraw proposal: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	proposalDTO := ProposalDTO{
+		Id:       int(prop.ID),
+		UserId:   int(prop.UserID.Int32),
+		Username: prop.Username,
+		Entry:    prop.Entry,
+		Pos:      prop.Pos,
+		Gloss:    prop.Gloss,
+		Notes:    prop.Notes,
+		Status:   PROPOSAL_STATUS_WITHDRAWN,
+		Kind:     prop.Kind,
+		KalanID:  int(prop.KalanID.Int32),
+	}
+
+	return ctx.JSON(http.StatusOK, proposalDTO)
+}
@@ -0,0 +1,82 @@
+package router_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"wilin.info/api/database/proposal"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+func TestProposalRoutes(t *testing.T) {
+	forEachStore(t, testProposalRoutes)
+}
+
+func testProposalRoutes(t *testing.T, s *testServer) {
+	admin := s.addUser(t, "admin", services.ROLE_ADMIN, true)
+	user := s.addUser(t, "user", services.ROLE_USER, true)
+	s.addKalan(t, "wilin", "word")
+
+	newWord := router.ProposalDTO{Entry: "jan", Pos: "noun", Gloss: "person"}
+	amendWord := router.ProposalDTO{Kind: router.PROPOSAL_KIND_AMEND, KalanID: 1, Entry: "wilin", Pos: "noun", Gloss: "word, speech"}
+	deleteWord := router.ProposalDTO{Kind: router.PROPOSAL_KIND_DELETE, KalanID: 1}
+
+	routeValues := []RouteValue{
+		{"propose new word", http.MethodPost, "/proposal", newWord, user.AuthToken, http.StatusCreated},
+		{"propose amendment", http.MethodPost, "/proposal", amendWord, user.AuthToken, http.StatusCreated},
+		{"propose deletion", http.MethodPost, "/proposal", deleteWord, user.AuthToken, http.StatusCreated},
+		{"propose without gloss", http.MethodPost, "/proposal", router.ProposalDTO{Entry: "a", Pos: "b"}, user.AuthToken, http.StatusBadRequest},
+		{"propose amending missing word", http.MethodPost, "/proposal", router.ProposalDTO{Kind: router.PROPOSAL_KIND_AMEND, KalanID: 99, Entry: "a", Pos: "b", Gloss: "c"}, user.AuthToken, http.StatusNotFound},
+		{"modify own proposal", http.MethodPut, "/proposal", router.ProposalDTO{Id: 1, Entry: "jan", Pos: "noun", Gloss: "human"}, user.AuthToken, http.StatusOK},
+		{"modify proposal without gloss", http.MethodPut, "/proposal", router.ProposalDTO{Id: 1, Entry: "jan", Pos: "noun"}, user.AuthToken, http.StatusBadRequest},
+		{"modify amendment without pos", http.MethodPut, "/proposal", router.ProposalDTO{Id: 2, Entry: "wilin", Gloss: "word"}, user.AuthToken, http.StatusBadRequest},
+		{"modify deletion", http.MethodPut, "/proposal", router.ProposalDTO{Id: 3, Entry: "jan", Pos: "noun", Gloss: "human"}, user.AuthToken, http.StatusBadRequest},
+		{"modify deletion into amendment", http.MethodPut, "/proposal", router.ProposalDTO{Id: 3, Kind: router.PROPOSAL_KIND_AMEND, KalanID: 1, Entry: "jan", Pos: "noun", Gloss: "human"}, user.AuthToken, http.StatusBadRequest},
+		{"view own proposal", http.MethodGet, "/proposal/2", nil, user.AuthToken, http.StatusOK},
+		{"view missing proposal", http.MethodGet, "/proposal/99", nil, admin.AuthToken, http.StatusNotFound},
+		{"approve new word", http.MethodPost, "/proposal/1/approve", nil, admin.AuthToken, http.StatusOK},
+		{"approve twice", http.MethodPost, "/proposal/1/approve", nil, admin.AuthToken, http.StatusConflict},
+		{"modify reviewed proposal", http.MethodPut, "/proposal", router.ProposalDTO{Id: 1, Entry: "jan", Pos: "noun", Gloss: "human"}, user.AuthToken, http.StatusConflict},
+		{"reject without reason", http.MethodPost, "/proposal/2/reject", map[string]string{}, admin.AuthToken, http.StatusBadRequest},
+		{"reject amendment", http.MethodPost, "/proposal/2/reject", map[string]string{"reason": "not needed"}, admin.AuthToken, http.StatusOK},
+		{"withdraw deletion", http.MethodPost, "/proposal/3/withdraw", nil, user.AuthToken, http.StatusOK},
+		{"approve withdrawn proposal", http.MethodPost, "/proposal/3/approve", nil, admin.AuthToken, http.StatusConflict},
+		{"delete own proposal", http.MethodDelete, "/proposal/3", nil, user.AuthToken, http.StatusNoContent},
+		{"view deleted proposal", http.MethodGet, "/proposal/3", nil, user.AuthToken, http.StatusNotFound},
+	}
+	runRoutes(t, s, routeValues)
+
+	rec := s.request(t, http.MethodGet, "/kalan/2", nil, "")
+	approved := decode[router.KalanDTO](t, rec)
+	if approved.Entry != "jan" || approved.Gloss != "human" {
+		t.Errorf("approved word = %+v, want jan meaning human", approved)
+	}
+
+	rec = s.request(t, http.MethodGet, "/proposal/me", nil, user.AuthToken)
+	mine := decode[router.ProposalArrDTO](t, rec)
+	if len(mine.Proposals) != 2 {
+		t.Fatalf("GET /proposal/me returned %v proposals, want 2", len(mine.Proposals))
+	}
+	if mine.Proposals[1].Status != router.PROPOSAL_STATUS_REJECTED || mine.Proposals[1].Reason != "not needed" {
+		t.Errorf("rejected proposal = %+v", mine.Proposals[1])
+	}
+
+	rec = s.request(t, http.MethodGet, "/proposal", nil, admin.AuthToken)
+	all := decode[router.ProposalArrDTO](t, rec)
+	if len(all.Proposals) != 2 {
+		t.Errorf("GET /proposal returned %v proposals, want 2", len(all.Proposals))
+	}
+
+	// an edit racing a review leaves the reviewed proposal alone
+	updateParams := proposal.UpdateParams{UserID: sql.NullInt32{Int32: 2, Valid: true}, Entry: "jan", Pos: "noun", Gloss: "people", ID: 1}
+	result, err := s.store.Proposal().Update(context.Background(), updateParams)
+	if err != nil {
+		t.Fatalf("could not update proposal: %v", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected != 0 {
+		t.Errorf("updating a reviewed proposal changed %v rows, want 0", rowsAffected)
+	}
+}
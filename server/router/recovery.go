@@ -3,6 +3,7 @@ package router
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -17,6 +18,12 @@ type RequestRecoveryDTO struct {
 	Email string `json:"email" form:"email"`
 }
 
+type RecoveryMailData struct {
+	Username      string
+	URL           string
+	ExpireMinutes int
+}
+
 type ChangePasswordDTO struct {
 	Password string `json:"password" form:"password"`
 	ID       string `param:"id"`
@@ -42,13 +49,13 @@ func isExpired(t *time.Time) bool {
 
 func (r *Router) createNewRecovery(ctx echo.Context, id string, userID int) error {
 	// verify if one for the user already exists
-	recoveries, err := r.recoveryQueries.ReadByUserID(r.ctx, int32(userID))
+	recoveries, err := r.recoveryQueries.ReadByUserID(ctx.Request().Context(), int32(userID))
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
 
 	for _, recovery := range recoveries {
-		_, err = r.recoveryQueries.DeleteByID(r.ctx, recovery.ID)
+		_, err = r.recoveryQueries.DeleteByID(ctx.Request().Context(), recovery.ID)
 		if err != nil {
 			ctx.Logger().Errorf("failed to delete recoveries: %v\n", err)
 		}
@@ -60,7 +67,7 @@ func (r *Router) createNewRecovery(ctx echo.Context, id string, userID int) erro
 		UserID:    int32(userID),
 		ExpiredAt: expiredAt,
 	}
-	_, err = r.recoveryQueries.Create(r.ctx, createParams)
+	_, err = r.recoveryQueries.Create(ctx.Request().Context(), createParams)
 	return err
 }
 
@@ -72,38 +79,51 @@ func (r *Router) RequestRecovery(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorJSON)
 	}
 
-	if !services.IsValidEmail(recoveryDTO.Email) {
+	recoveryDTO.Email, err = services.NormalizeEmail(recoveryDTO.Email)
+	if err != nil {
 		errorJSON := NewErrorJson("invalid email format")
 		return ctx.JSON(http.StatusBadRequest, errorJSON)
 	}
 
-	user, err := r.userQueries.ReadUserByEmail(r.ctx, recoveryDTO.Email)
+	user, err := r.userQueries.ReadUserByEmail(ctx.Request().Context(), recoveryDTO.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			errorJSON := NewErrorJson("invalid email")
 			return ctx.JSON(http.StatusNotFound, errorJSON)
 		}
 
-		errorJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errorJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	recoveryID, err := gonanoid.Generate(ID_ALPHABET, 64)
 	if err != nil {
 		ctx.Logger().Errorf("could not generate nanoid: %v\n", err)
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	err = r.createNewRecovery(ctx, recoveryID, int(user.ID))
 
 	if err != nil {
 		ctx.Logger().Errorf("could not generate recovery: %v", err)
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
+	}
+
+	mailData := RecoveryMailData{
+		Username:      user.Username,
+		URL:           fmt.Sprintf("%s/recovery/%s", services.GetSiteURL(), recoveryID),
+		ExpireMinutes: int(TIME_TO_EXPIRE.Minutes()),
+	}
+	msg, err := services.RenderMessage("recovery", user.Email, "Reset your wilin password", mailData)
+	if err != nil {
+		ctx.Logger().Errorf("could not render recovery mail: %v\n", err)
+		return serverError(ctx, err, ServerError)
 	}
 
-	// TODO: add logic to send recovery url to user
+	err = r.mailer.Send(ctx.Request().Context(), msg)
+	if err != nil {
+		ctx.Logger().Errorf("could not send recovery mail: %v\n", err)
+		return serverError(ctx, err, ServerError)
+	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
@@ -116,7 +136,7 @@ func (r *Router) ChangePassword(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errJSON)
 	}
 
-	recovery, err := r.recoveryQueries.ReadByID(r.ctx, newPasswordDTO.ID)
+	recovery, err := r.recoveryQueries.ReadByID(ctx.Request().Context(), newPasswordDTO.ID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			errJSON := NewErrorJson("invalid recovery id")
@@ -124,13 +144,12 @@ func (r *Router) ChangePassword(ctx echo.Context) error {
 		}
 
 		ctx.Logger().Errorf("could not find recovery item: %v\n", err)
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	if isExpired(&recovery.ExpiredAt) {
 		ctx.Logger().Infof("recovery id is expired. deleting %v...\n", recovery.ID)
-		_, _ = r.recoveryQueries.DeleteByID(r.ctx, recovery.ID)
+		_, _ = r.recoveryQueries.DeleteByID(ctx.Request().Context(), recovery.ID)
 		errJSON := NewErrorJson("invalid recovery id")
 		return ctx.JSON(http.StatusNotFound, errJSON)
 	}
@@ -144,29 +163,33 @@ func (r *Router) ChangePassword(ctx echo.Context) error {
 	passwordHash, err := services.GeneratePasswordHash(newPasswordDTO.Password)
 	if err != nil {
 		ctx.Logger().Errorf("could not generate password hash: %v\n", err)
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
 	updateParams := users.UpdatePasswordParams{
 		ID:       recovery.UserID,
 		Password: passwordHash,
 	}
-	_, err = r.userQueries.UpdatePassword(r.ctx, updateParams)
+	_, err = r.userQueries.UpdatePassword(ctx.Request().Context(), updateParams)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			errJSON := NewErrorJson("invalid recovery id")
 			return ctx.JSON(http.StatusNotFound, errJSON)
 		}
 
-		errJSON := NewErrorJson(ServerError)
-		return ctx.JSON(http.StatusInternalServerError, errJSON)
+		return serverError(ctx, err, ServerError)
 	}
 
-	_, err = r.recoveryQueries.DeleteByID(r.ctx, recovery.ID)
+	_, err = r.recoveryQueries.DeleteByID(ctx.Request().Context(), recovery.ID)
 	if err != nil {
 		ctx.Logger().Errorf("failed to delete recovery item: %v\n", err)
 	}
 
+	// log out every device that knew the old password
+	_, err = r.sessionQueries.RevokeSessionsByUserID(ctx.Request().Context(), recovery.UserID)
+	if err != nil {
+		ctx.Logger().Errorf("failed to revoke sessions: %v\n", err)
+	}
+
 	return ctx.NoContent(http.StatusNoContent)
 }
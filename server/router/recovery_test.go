@@ -0,0 +1,39 @@
+package router_test
+
+import (
+	"net/http"
+	"testing"
+
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+func TestRecoveryRoutes(t *testing.T) {
+	forEachStore(t, testRecoveryRoutes)
+}
+
+func testRecoveryRoutes(t *testing.T, s *testServer) {
+	user := s.addUser(t, "jan", services.ROLE_USER, true)
+
+	routeValues := []RouteValue{
+		{"recover unknown email", http.MethodPost, "/recovery", router.RequestRecoveryDTO{Email: "nobody@wilin.info"}, "", http.StatusNotFound},
+		{"recover account", http.MethodPost, "/recovery", router.RequestRecoveryDTO{Email: user.User.Email}, "", http.StatusNoContent},
+		{"change password with invalid id", http.MethodPost, "/recovery/abc", map[string]string{"password": "new-password"}, "", http.StatusNotFound},
+	}
+	runRoutes(t, s, routeValues)
+
+	recoveryID := s.mailer.link(t, user.User.Email, "/recovery")
+	rec := s.request(t, http.MethodPost, "/recovery/"+recoveryID, map[string]string{"password": "new-password"}, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("POST /recovery/:id = %v %v", rec.Code, rec.Body.String())
+	}
+
+	routeValues = []RouteValue{
+		{"reuse recovery", http.MethodPost, "/recovery/" + recoveryID, map[string]string{"password": "other-password"}, "", http.StatusNotFound},
+		{"log in with old password", http.MethodPost, "/login", router.LoginFields{Username: "jan", Password: TEST_PASSWORD}, "", http.StatusUnauthorized},
+		{"refresh session from before recovery", http.MethodPost, "/refresh", router.TokensDTO{RefreshToken: user.RefreshToken}, "", http.StatusUnauthorized},
+	}
+	runRoutes(t, s, routeValues)
+
+	s.login(t, "jan", "new-password")
+}
@@ -0,0 +1,354 @@
+package router
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/kalan"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	RELATION_SYNONYM      = "synonym"
+	RELATION_ANTONYM      = "antonym"
+	RELATION_DERIVED_FROM = "derived-from"
+	RELATION_COMPOUND_OF  = "compound-of"
+	RELATION_SEE_ALSO     = "see-also"
+)
+
+// RELATION_KINDS are every kind of relation between two words.
+// Relations that are not directed are stored with the smaller
+// id first, so that each pair of words has them only once
+var RELATION_KINDS = map[string]bool{
+	RELATION_SYNONYM:      false,
+	RELATION_ANTONYM:      false,
+	RELATION_DERIVED_FROM: true,
+	RELATION_COMPOUND_OF:  true,
+	RELATION_SEE_ALSO:     false,
+}
+
+const (
+	DEFAULT_RELATION_DEPTH = 1
+	MAX_RELATION_DEPTH     = 3
+)
+
+// MAX_NEIGHBOURHOOD_SIZE is the most words a neighbourhood has.
+// Words further away are left out once it is reached
+const MAX_NEIGHBOURHOOD_SIZE = 200
+
+var (
+	ErrInvalidRelationKind = errors.New("invalid relation kind")
+	ErrSelfRelation        = errors.New("a word cannot be related to itself")
+	ErrDuplicateRelation   = errors.New("the words are already related that way")
+	ErrCircularRelation    = errors.New("the related word is already derived from or made of this word")
+)
+
+type RelationDTO struct {
+	ID        int    `json:"id"`
+	KalanID   int    `json:"kalanId"`
+	RelatedID int    `json:"relatedId"`
+	Kind      string `json:"kind"`
+}
+
+func NewRelationDTO(relation kalan.KalanRelation) RelationDTO {
+	return RelationDTO{
+		ID:        int(relation.ID),
+		KalanID:   int(relation.KalanID),
+		RelatedID: int(relation.RelatedID),
+		Kind:      relation.Kind,
+	}
+}
+
+// AddRelationDTO relates the word in the path, which
+// is never read from the body, to the word RelatedID
+type AddRelationDTO struct {
+	ID        int    `param:"id" json:"-" form:"-"`
+	RelatedID int    `json:"relatedId" form:"relatedId"`
+	Kind      string `json:"kind" form:"kind"`
+}
+
+type RelationIDParam struct {
+	ID       int `param:"id"`
+	Relation int `param:"relation"`
+}
+
+type NeighbourhoodQueryDTO struct {
+	ID    int    `param:"id"`
+	Depth int    `query:"depth"`
+	Kinds string `query:"kinds"`
+}
+
+// RelationNodeDTO is a word of a neighbourhood. Depth
+// is how many relations away it is from the word asked for
+type RelationNodeDTO struct {
+	ID    int    `json:"id"`
+	Entry string `json:"entry"`
+	Pos   string `json:"pos"`
+	Gloss string `json:"gloss"`
+	Depth int    `json:"depth"`
+}
+
+// NeighbourhoodDTO is the words within a number of relations of
+// a word and every relation between them. Truncated is set if
+// words were left out because there were too many
+type NeighbourhoodDTO struct {
+	KalanID   int               `json:"kalanId"`
+	Depth     int               `json:"depth"`
+	Nodes     []RelationNodeDTO `json:"nodes"`
+	Relations []RelationDTO     `json:"relations"`
+	Truncated bool              `json:"truncated"`
+}
+
+// createRelation relates the kalan with kalanID to the kalan with
+// relatedID. It must be called inside of a transaction
+func createRelation(ctx context.Context, tx database.Store, kalanID int32, relatedID int32, kind string) (kalan.KalanRelation, error) {
+	kalanQueries := tx.Kalan()
+
+	_, err := kalanQueries.ReadKalanById(ctx, kalanID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return kalan.KalanRelation{}, ErrKalanGone
+		}
+		return kalan.KalanRelation{}, err
+	}
+	_, err = kalanQueries.ReadKalanById(ctx, relatedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return kalan.KalanRelation{}, fmt.Errorf("%w: id=%v", ErrNoSuchKalan, relatedID)
+		}
+		return kalan.KalanRelation{}, err
+	}
+
+	directed := RELATION_KINDS[kind]
+	if !directed && relatedID < kalanID {
+		kalanID, relatedID = relatedID, kalanID
+	}
+
+	// only a relation straight back is refused, a longer
+	// cycle through other words is not looked for
+	if directed {
+		reverseParams := kalan.ReadRelationByPairParams{KalanID: relatedID, RelatedID: kalanID, Kind: kind}
+		_, err = kalanQueries.ReadRelationByPair(ctx, reverseParams)
+		if err == nil {
+			return kalan.KalanRelation{}, ErrCircularRelation
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return kalan.KalanRelation{}, err
+		}
+	}
+
+	createParams := kalan.CreateRelationParams{KalanID: kalanID, RelatedID: relatedID, Kind: kind}
+	result, err := kalanQueries.CreateRelation(ctx, createParams)
+	if database.IsDuplicateKey(err) {
+		return kalan.KalanRelation{}, ErrDuplicateRelation
+	}
+	if err != nil {
+		return kalan.KalanRelation{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return kalan.KalanRelation{}, err
+	}
+
+	return kalan.KalanRelation{ID: int32(id), KalanID: kalanID, RelatedID: relatedID, Kind: kind}, nil
+}
+
+func (r *Router) AddRelation(ctx echo.Context) error {
+	var addRelationDTO AddRelationDTO
+	err := ctx.Bind(&addRelationDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	_, ok := RELATION_KINDS[addRelationDTO.Kind]
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidRelationKind.Error()))
+	}
+	if addRelationDTO.RelatedID == 0 {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrNoKalanID.Error()))
+	}
+	if addRelationDTO.RelatedID == addRelationDTO.ID {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrSelfRelation.Error()))
+	}
+
+	var relation kalan.KalanRelation
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		relation, err = createRelation(ctx.Request().Context(), tx, int32(addRelationDTO.ID), int32(addRelationDTO.RelatedID), addRelationDTO.Kind)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrKalanGone):
+			errMsg := fmt.Sprintf("no kalan with id=%v", addRelationDTO.ID)
+			return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+		case errors.Is(err, ErrNoSuchKalan):
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(err.Error()))
+		case errors.Is(err, ErrDuplicateRelation), errors.Is(err, ErrCircularRelation):
+			return ctx.JSON(http.StatusConflict, NewErrorJson(err.Error()))
+		}
+		return serverError(ctx, err, "could not add relation")
+	}
+
+	return ctx.JSON(http.StatusCreated, NewRelationDTO(relation))
+}
+
+func (r *Router) DeleteRelation(ctx echo.Context) error {
+	var params RelationIDParam
+	err := ctx.Bind(&params)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(ErrInvalidFormat.Error()))
+	}
+
+	relation, err := r.kalanQueries.ReadRelationByID(ctx.Request().Context(), int32(params.Relation))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return serverError(ctx, err, "could not fetch relation")
+	}
+
+	// a relation belongs to the words at both of its ends
+	if err != nil || (relation.KalanID != int32(params.ID) && relation.RelatedID != int32(params.ID)) {
+		errMsg := fmt.Sprintf("no relation with id=%v for kalan with id=%v", params.Relation, params.ID)
+		return ctx.JSON(http.StatusNotFound, NewErrorJson(errMsg))
+	}
+
+	_, err = r.kalanQueries.DeleteRelation(ctx.Request().Context(), relation.ID)
+	if err != nil {
+		return serverError(ctx, err, "could not delete relation")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// readRelations returns the relations of the given kinds, or of
+// every kind if there are none, that have one of ids at either end
+func readRelations(ctx context.Context, kalanQueries kalan.Querier, ids []int32, kinds []string) ([]kalan.KalanRelation, error) {
+	params := kalan.ReadRelationsByKalanIDsParams{KalanIds: ids, RelatedIds: ids}
+	allRelations, err := kalanQueries.ReadRelationsByKalanIDs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	relations := []kalan.KalanRelation{}
+	for _, relation := range allRelations {
+		if len(kinds) < 1 || slices.Contains(kinds, relation.Kind) {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+// neighbourhood walks relations of the given kinds out from id,
+// breadth first, and returns how far away every word within depth
+// relations is. The relations are read one depth at a time
+func neighbourhood(ctx context.Context, kalanQueries kalan.Querier, id int32, depth int, kinds []string) (map[int32]int, bool, error) {
+	depths := map[int32]int{id: 0}
+	frontier := []int32{id}
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		relations, err := readRelations(ctx, kalanQueries, frontier, kinds)
+		if err != nil {
+			return nil, false, err
+		}
+		neighbours := map[int32][]int32{}
+		for _, relation := range relations {
+			neighbours[relation.KalanID] = append(neighbours[relation.KalanID], relation.RelatedID)
+			neighbours[relation.RelatedID] = append(neighbours[relation.RelatedID], relation.KalanID)
+		}
+
+		next := []int32{}
+		for _, node := range frontier {
+			for _, neighbour := range neighbours[node] {
+				_, seen := depths[neighbour]
+				if seen {
+					continue
+				}
+				if len(depths) >= MAX_NEIGHBOURHOOD_SIZE {
+					return depths, true, nil
+				}
+				depths[neighbour] = d
+				next = append(next, neighbour)
+			}
+		}
+		frontier = next
+	}
+	return depths, false, nil
+}
+
+func (r *Router) GetKalanRelations(ctx echo.Context) error {
+	var neighbourhoodQueryDTO NeighbourhoodQueryDTO
+	err := ctx.Bind(&neighbourhoodQueryDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid query"))
+	}
+
+	depth := neighbourhoodQueryDTO.Depth
+	if depth == 0 {
+		depth = DEFAULT_RELATION_DEPTH
+	}
+	if depth < 1 || depth > MAX_RELATION_DEPTH {
+		errMsg := fmt.Sprintf("depth must be between 1 and %v", MAX_RELATION_DEPTH)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	kinds := splitQuery(neighbourhoodQueryDTO.Kinds)
+	for _, kind := range kinds {
+		_, ok := RELATION_KINDS[kind]
+		if !ok {
+			errMsg := fmt.Sprintf("%v: %q", ErrInvalidRelationKind, kind)
+			return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+		}
+	}
+
+	id := int32(neighbourhoodQueryDTO.ID)
+	_, err = r.kalanQueries.ReadKalanById(ctx.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.JSON(http.StatusNotFound, NewErrorJson("invalid word, does not exist"))
+		}
+		return serverError(ctx, err, "could not fetch word")
+	}
+
+	depths, truncated, err := neighbourhood(ctx.Request().Context(), r.kalanQueries, id, depth, kinds)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch relations")
+	}
+	ids := slices.Sorted(maps.Keys(depths))
+	relations, err := readRelations(ctx.Request().Context(), r.kalanQueries, ids, kinds)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch relations")
+	}
+	kalans, err := r.kalanQueries.ReadKalanByIDs(ctx.Request().Context(), ids)
+	if err != nil {
+		return serverError(ctx, err, "could not fetch words")
+	}
+
+	neighbourhoodDTO := NeighbourhoodDTO{
+		KalanID:   int(id),
+		Depth:     depth,
+		Nodes:     []RelationNodeDTO{},
+		Relations: []RelationDTO{},
+		Truncated: truncated,
+	}
+	for _, k := range kalans {
+		node := RelationNodeDTO{ID: int(k.ID), Entry: k.Entry, Pos: k.Pos, Gloss: k.Gloss, Depth: depths[k.ID]}
+		neighbourhoodDTO.Nodes = append(neighbourhoodDTO.Nodes, node)
+	}
+	slices.SortFunc(neighbourhoodDTO.Nodes, func(a RelationNodeDTO, b RelationNodeDTO) int {
+		return cmp.Or(cmp.Compare(a.Depth, b.Depth), cmp.Compare(a.ID, b.ID))
+	})
+
+	for _, relation := range relations {
+		_, fromIn := depths[relation.KalanID]
+		_, toIn := depths[relation.RelatedID]
+		if fromIn && toIn {
+			neighbourhoodDTO.Relations = append(neighbourhoodDTO.Relations, NewRelationDTO(relation))
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, neighbourhoodDTO)
+}
@@ -0,0 +1,138 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/router"
+)
+
+func TestRelationRoutes(t *testing.T) {
+	forEachStore(t, testRelationRoutes)
+}
+
+func testRelationRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	wilin := s.addKalan(t, "wilin", "word")
+	kan := s.addKalan(t, "kan", "sound")
+	wilinkan := s.addKalan(t, "wilinkan", "speech")
+	sona := s.addKalan(t, "sona", "noise")
+	ike := s.addKalan(t, "ike", "bad")
+	pona := s.addKalan(t, "pona", "good")
+
+	relate := func(from int32, to int32, kind string) router.RelationDTO {
+		t.Helper()
+		path := fmt.Sprintf("/kalan/%v/relations", from)
+		rec := s.request(t, http.MethodPost, path, router.AddRelationDTO{RelatedID: int(to), Kind: kind}, token)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST %v = %v %v", path, rec.Code, rec.Body.String())
+		}
+		return decode[router.RelationDTO](t, rec)
+	}
+	relate(wilinkan, wilin, router.RELATION_COMPOUND_OF)
+	relate(wilinkan, kan, router.RELATION_COMPOUND_OF)
+	relate(sona, kan, router.RELATION_SYNONYM)
+	relate(wilin, kan, router.RELATION_SEE_ALSO)
+	antonym := relate(pona, ike, router.RELATION_ANTONYM)
+	if antonym.KalanID != int(ike) || antonym.RelatedID != int(pona) {
+		t.Errorf("antonym = %+v, want the smaller id first", antonym)
+	}
+
+	nodes := func(query string) []string {
+		t.Helper()
+		path := fmt.Sprintf("/kalan/%v/relations?%v", wilinkan, query)
+		rec := s.request(t, http.MethodGet, path, nil, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %v = %v %v", path, rec.Code, rec.Body.String())
+		}
+		entries := []string{}
+		for _, node := range decode[router.NeighbourhoodDTO](t, rec).Nodes {
+			entries = append(entries, fmt.Sprintf("%v:%v", node.Entry, node.Depth))
+		}
+		return entries
+	}
+
+	neighbourValues := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"wilinkan:0", "wilin:1", "kan:1"}},
+		{"depth=2", []string{"wilinkan:0", "wilin:1", "kan:1", "sona:2"}},
+		{"depth=3&kinds=compound-of", []string{"wilinkan:0", "wilin:1", "kan:1"}},
+		{"depth=2&kinds=synonym", []string{"wilinkan:0"}},
+	}
+	for _, test := range neighbourValues {
+		got := nodes(test.query)
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("neighbourhood ?%v = %v, want %v", test.query, got, test.expected)
+		}
+	}
+
+	// relations between the furthest words are kept too
+	rec := s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v/relations", wilinkan), nil, "")
+	if found := decode[router.NeighbourhoodDTO](t, rec); len(found.Relations) != 3 {
+		t.Errorf("neighbourhood relations = %+v, want 3", found.Relations)
+	}
+
+	rec = s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v/relations?depth=2", wilinkan), nil, "")
+	if found := decode[router.NeighbourhoodDTO](t, rec); len(found.Relations) != 4 {
+		t.Errorf("neighbourhood relations = %+v, want 4", found.Relations)
+	}
+
+	path := fmt.Sprintf("/kalan/%v/relations", wilin)
+	routeValues := []RouteValue{
+		{"invalid kind", http.MethodPost, path, router.AddRelationDTO{RelatedID: int(kan), Kind: "cousin"}, token, http.StatusBadRequest},
+		{"related to itself", http.MethodPost, path, router.AddRelationDTO{RelatedID: int(wilin), Kind: router.RELATION_SEE_ALSO}, token, http.StatusBadRequest},
+		{"related to missing word", http.MethodPost, path, router.AddRelationDTO{RelatedID: 99, Kind: router.RELATION_SEE_ALSO}, token, http.StatusBadRequest},
+		{"relation of missing word", http.MethodPost, "/kalan/99/relations", router.AddRelationDTO{RelatedID: int(wilin), Kind: router.RELATION_SEE_ALSO}, token, http.StatusNotFound},
+		{"duplicate synonym", http.MethodPost, fmt.Sprintf("/kalan/%v/relations", kan), router.AddRelationDTO{RelatedID: int(sona), Kind: router.RELATION_SYNONYM}, token, http.StatusConflict},
+		{"circular compound", http.MethodPost, path, router.AddRelationDTO{RelatedID: int(wilinkan), Kind: router.RELATION_COMPOUND_OF}, token, http.StatusConflict},
+		{"depth too deep", http.MethodGet, path + "?depth=4", nil, "", http.StatusBadRequest},
+		{"invalid kinds", http.MethodGet, path + "?kinds=cousin", nil, "", http.StatusBadRequest},
+		{"relations of missing word", http.MethodGet, "/kalan/99/relations", nil, "", http.StatusNotFound},
+		{"delete relation of another word", http.MethodDelete, fmt.Sprintf("%v/%v", path, antonym.ID), nil, token, http.StatusNotFound},
+		{"delete relation from its other end", http.MethodDelete, fmt.Sprintf("/kalan/%v/relations/%v", pona, antonym.ID), nil, token, http.StatusNoContent},
+		{"delete deleted relation", http.MethodDelete, fmt.Sprintf("/kalan/%v/relations/%v", ike, antonym.ID), nil, token, http.StatusNotFound},
+		{"delete related word", http.MethodDelete, fmt.Sprintf("/kalan/%v", kan), nil, token, http.StatusNoContent},
+	}
+	runRoutes(t, s, routeValues)
+
+	got := nodes("depth=3")
+	if !slices.Equal(got, []string{"wilinkan:0", "wilin:1"}) {
+		t.Errorf("neighbourhood after deleting kan = %v, want only wilin", got)
+	}
+}
+
+func TestRelationNeighbourhoodSize(t *testing.T) {
+	forEachStore(t, testRelationNeighbourhoodSize)
+}
+
+func testRelationNeighbourhoodSize(t *testing.T, s *testServer) {
+	ctx := context.Background()
+	center := s.addKalan(t, "wilin", "word")
+	for i := range router.MAX_NEIGHBOURHOOD_SIZE {
+		params := kalan.CreateKalanParams{Entry: fmt.Sprintf("wilin%v", i), Pos: "noun", Gloss: "word"}
+		result, err := s.store.Kalan().CreateKalan(ctx, params)
+		if err != nil {
+			t.Fatalf("could not add word: %v", err)
+		}
+		id, _ := result.LastInsertId()
+		relationParams := kalan.CreateRelationParams{KalanID: int32(id), RelatedID: center, Kind: router.RELATION_DERIVED_FROM}
+		_, err = s.store.Kalan().CreateRelation(ctx, relationParams)
+		if err != nil {
+			t.Fatalf("could not add relation: %v", err)
+		}
+	}
+
+	rec := s.request(t, http.MethodGet, fmt.Sprintf("/kalan/%v/relations", center), nil, "")
+	found := decode[router.NeighbourhoodDTO](t, rec)
+	if !found.Truncated || len(found.Nodes) != router.MAX_NEIGHBOURHOOD_SIZE || len(found.Relations) != router.MAX_NEIGHBOURHOOD_SIZE-1 {
+		t.Errorf("neighbourhood of %v nodes and %v relations truncated=%v, want %v nodes and truncated",
+			len(found.Nodes), len(found.Relations), found.Truncated, router.MAX_NEIGHBOURHOOD_SIZE)
+	}
+}
@@ -0,0 +1,498 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/database"
+	"wilin.info/api/database/domain"
+	"wilin.info/api/database/example"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/database/revision"
+)
+
+// Every revision stores a snapshot of the kalan as it stood
+// once the action was done, except for deletions which store
+// the values that were removed
+const (
+	REVISION_ACTION_CREATE  = "create"
+	REVISION_ACTION_UPDATE  = "update"
+	REVISION_ACTION_DELETE  = "delete"
+	REVISION_ACTION_REVERT  = "revert"
+	REVISION_ACTION_RESTORE = "restore"
+)
+
+type RevisionDTO struct {
+	ID        int        `json:"id"`
+	KalanID   int        `json:"kalanId"`
+	Action    string     `json:"action"`
+	Entry     string     `json:"entry"`
+	Pos       string     `json:"pos"`
+	Gloss     string     `json:"gloss"`
+	Notes     string     `json:"notes"`
+	Senses    []SenseDTO `json:"senses"`
+	UserID    int        `json:"userId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func NewRevisionDTO(rev revision.KalanRevision) RevisionDTO {
+	return RevisionDTO{
+		ID:        int(rev.ID),
+		KalanID:   int(rev.KalanID),
+		Action:    rev.Action,
+		Entry:     rev.Entry,
+		Pos:       rev.Pos,
+		Gloss:     rev.Gloss,
+		Notes:     rev.Notes,
+		Senses:    revisionSenses(rev),
+		UserID:    int(rev.UserID.Int32),
+		CreatedAt: rev.CreatedAt,
+	}
+}
+
+type RevisionArrDTO struct {
+	Revisions []RevisionDTO `json:"revisions"`
+}
+
+func (arr *RevisionArrDTO) AddRevision(rev RevisionDTO) {
+	arr.Revisions = append(arr.Revisions, rev)
+}
+
+type RevertKalanParam struct {
+	ID       int `param:"id"`
+	Revision int `param:"rev"`
+}
+
+var ErrKalanExists = errors.New("word already exists")
+
+func nullUserID(userID int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(userID), Valid: userID != 0}
+}
+
+// revisionLinks are the examples, relations and domains of a kalan,
+// which are removed along with it. Deletions keep them so that they
+// can be put back when the kalan is restored
+type revisionLinks struct {
+	Examples  []int32               `json:"examples"`
+	Relations []kalan.KalanRelation `json:"relations"`
+	Domains   []int32               `json:"domains"`
+}
+
+// readLinks returns the links of the kalan with id. It
+// must be called inside of a transaction
+func readLinks(ctx context.Context, tx database.Store, id int32) (*revisionLinks, error) {
+	links := &revisionLinks{Examples: []int32{}, Relations: []kalan.KalanRelation{}, Domains: []int32{}}
+
+	examples, err := tx.Example().ReadExamplesByKalanID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range examples {
+		links.Examples = append(links.Examples, e.ID)
+	}
+
+	relations, err := tx.Kalan().ReadRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if relation.KalanID == id || relation.RelatedID == id {
+			links.Relations = append(links.Relations, relation)
+		}
+	}
+
+	domains, err := tx.Domain().ReadDomainsByKalanID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range domains {
+		links.Domains = append(links.Domains, d.ID)
+	}
+	return links, nil
+}
+
+// encodeLinks stores links in a revision as JSON
+func encodeLinks(links *revisionLinks) sql.NullString {
+	if links == nil {
+		return sql.NullString{}
+	}
+	data, err := json.Marshal(links)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// restoreLinks puts back the links kept in rev. Links to examples,
+// words or domains that have since been deleted are left out. It
+// must be called inside of a transaction
+func restoreLinks(ctx context.Context, tx database.Store, rev revision.KalanRevision) error {
+	if !rev.Links.Valid {
+		return nil
+	}
+	links := revisionLinks{}
+	err := json.Unmarshal([]byte(rev.Links.String), &links)
+	if err != nil {
+		return err
+	}
+
+	for _, exampleID := range links.Examples {
+		_, err := tx.Example().ReadExampleByID(ctx, exampleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Example().CreateKalanExample(ctx, example.CreateKalanExampleParams{KalanID: rev.KalanID, ExampleID: exampleID})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, relation := range links.Relations {
+		otherID := relation.RelatedID
+		if otherID == rev.KalanID {
+			otherID = relation.KalanID
+		}
+		_, err := tx.Kalan().ReadKalanById(ctx, otherID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		createParams := kalan.CreateRelationParams{KalanID: relation.KalanID, RelatedID: relation.RelatedID, Kind: relation.Kind}
+		_, err = tx.Kalan().CreateRelation(ctx, createParams)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, domainID := range links.Domains {
+		_, err := tx.Domain().ReadDomainByID(ctx, domainID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Domain().CreateKalanDomain(ctx, domain.CreateKalanDomainParams{KalanID: rev.KalanID, DomainID: domainID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordRevision stores a snapshot of k, its senses and its links in
+// the history of the kalan and marks it as changed in tx. Only
+// deletions need links, so the other actions pass nil. Every change
+// to a kalan goes through here, which is what keeps the search index
+// in step with the database once tx is committed
+func (r *Router) recordRevision(ctx context.Context, tx database.Store, action string, k kalan.Kalan, senses []SenseDTO, links *revisionLinks, userID int) error {
+	createParams := revision.CreateRevisionParams{
+		KalanID: k.ID,
+		Action:  action,
+		Entry:   k.Entry,
+		Pos:     k.Pos,
+		Gloss:   k.Gloss,
+		Notes:   k.Notes,
+		UserID:  nullUserID(userID),
+		Senses:  encodeSenses(senses),
+		Links:   encodeLinks(links),
+	}
+	_, err := tx.Revision().CreateRevision(ctx, createParams)
+	if err != nil {
+		return err
+	}
+
+	return markChanged(tx, k.ID)
+}
+
+// createKalan adds a new kalan with its senses and records it in
+// its history. It must be called inside of a transaction
+func (r *Router) createKalan(ctx context.Context, tx database.Store, userID int, params kalan.CreateKalanParams, senses []SenseDTO) (int32, error) {
+	result, err := tx.Kalan().CreateKalan(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+
+	kalanID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeSenses(ctx, tx, int32(kalanID), senses)
+	if err != nil {
+		return 0, err
+	}
+
+	k := kalan.Kalan{
+		ID:    int32(kalanID),
+		Entry: params.Entry,
+		Pos:   params.Pos,
+		Gloss: params.Gloss,
+		Notes: params.Notes,
+	}
+	return k.ID, r.recordRevision(ctx, tx, REVISION_ACTION_CREATE, k, senses, nil, userID)
+}
+
+// updateKalan modifies an existing kalan, replaces its senses and
+// records the change in its history. It returns ErrKalanGone if there
+// is no kalan to update. It must be called inside of a transaction
+func (r *Router) updateKalan(ctx context.Context, tx database.Store, userID int, action string, params kalan.UpdateKalanParams, senses []SenseDTO) error {
+	result, err := tx.Kalan().UpdateKalan(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected < 1 {
+		return ErrKalanGone
+	}
+
+	err = writeSenses(ctx, tx, params.ID, senses)
+	if err != nil {
+		return err
+	}
+
+	k := kalan.Kalan{
+		ID:    params.ID,
+		Entry: params.Entry,
+		Pos:   params.Pos,
+		Gloss: params.Gloss,
+		Notes: params.Notes,
+	}
+	return r.recordRevision(ctx, tx, action, k, senses, nil, userID)
+}
+
+// deleteKalan removes a kalan and keeps its last values in its
+// history so that it may be restored later. It returns ErrKalanGone
+// if there is no kalan to delete. It must be called inside of a
+// transaction
+func (r *Router) deleteKalan(ctx context.Context, tx database.Store, userID int, id int32) error {
+	kalanQueries := tx.Kalan()
+
+	k, err := kalanQueries.ReadKalanById(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrKalanGone
+		}
+		return err
+	}
+
+	senses, err := readSenses(ctx, kalanQueries, id)
+	if err != nil {
+		return err
+	}
+
+	links, err := readLinks(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = kalanQueries.DeleteKalan(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return r.recordRevision(ctx, tx, REVISION_ACTION_DELETE, k, senses, links, userID)
+}
+
+// restoreKalan sets the kalan with the id of rev back to the values
+// stored in rev, recreating the kalan if it has since been deleted
+func (r *Router) restoreKalan(ctx context.Context, tx database.Store, userID int, rev revision.KalanRevision) error {
+	kalanQueries := tx.Kalan()
+	senses := revisionSenses(rev)
+
+	_, err := kalanQueries.ReadKalanById(ctx, rev.KalanID)
+	if err == nil {
+		updateParams := kalan.UpdateKalanParams{
+			Entry: rev.Entry,
+			Pos:   rev.Pos,
+			Gloss: rev.Gloss,
+			Notes: rev.Notes,
+			ID:    rev.KalanID,
+		}
+		return r.updateKalan(ctx, tx, userID, REVISION_ACTION_REVERT, updateParams, senses)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	createParams := kalan.CreateKalanWithIDParams{
+		ID:    rev.KalanID,
+		Entry: rev.Entry,
+		Pos:   rev.Pos,
+		Gloss: rev.Gloss,
+		Notes: rev.Notes,
+	}
+	_, err = kalanQueries.CreateKalanWithID(ctx, createParams)
+	if err != nil {
+		return err
+	}
+
+	err = writeSenses(ctx, tx, rev.KalanID, senses)
+	if err != nil {
+		return err
+	}
+
+	// the links were kept by the deletion, which is the latest revision
+	// of a kalan that does not exist
+	deletion, err := tx.Revision().ReadLatestRevisionByKalanID(ctx, rev.KalanID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		err = restoreLinks(ctx, tx, deletion)
+		if err != nil {
+			return err
+		}
+	}
+
+	k := kalan.Kalan{
+		ID:    rev.KalanID,
+		Entry: rev.Entry,
+		Pos:   rev.Pos,
+		Gloss: rev.Gloss,
+		Notes: rev.Notes,
+	}
+	return r.recordRevision(ctx, tx, REVISION_ACTION_RESTORE, k, senses, nil, userID)
+}
+
+func (r *Router) GetKalanHistory(ctx echo.Context) error {
+	var kalanID KalanIDParam
+	err := ctx.Bind(&kalanID)
+	if err != nil {
+		errJSON := NewErrorJson("invalid id")
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	revisions, err := r.revisionQueries.ReadRevisionsByKalanID(ctx.Request().Context(), int32(kalanID.ID))
+	if err != nil {
+		ctx.Logger().Errorf("could not fetch revisions: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	if len(revisions) < 1 {
+		errMsg := fmt.Sprintf("no history for kalan with id=%v", kalanID.ID)
+		errJSON := NewErrorJson(errMsg)
+		return ctx.JSON(http.StatusNotFound, errJSON)
+	}
+
+	revisionArrDTO := RevisionArrDTO{Revisions: []RevisionDTO{}}
+	for _, rev := range revisions {
+		revisionArrDTO.AddRevision(NewRevisionDTO(rev))
+	}
+
+	return ctx.JSON(http.StatusOK, revisionArrDTO)
+}
+
+func (r *Router) RevertKalan(ctx echo.Context) error {
+	var params RevertKalanParam
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(ErrInvalidFormat.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	userID, _ := ctx.Get("userID").(int)
+
+	rev, err := r.revisionQueries.ReadRevisionByID(ctx.Request().Context(), int32(params.Revision))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ctx.Logger().Errorf("could not fetch revision: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	if err != nil || rev.KalanID != int32(params.ID) {
+		errMsg := fmt.Sprintf("no revision with id=%v for kalan with id=%v", params.Revision, params.ID)
+		errJSON := NewErrorJson(errMsg)
+		return ctx.JSON(http.StatusNotFound, errJSON)
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		return r.restoreKalan(ctx.Request().Context(), tx, userID, rev)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrKalanGone):
+			errMsg := fmt.Sprintf("no kalan with id=%v", params.ID)
+			errJSON := NewErrorJson(errMsg)
+			return ctx.JSON(http.StatusNotFound, errJSON)
+		case database.IsDuplicateKey(err):
+			errJSON := NewErrorJson(ErrKalanExists.Error())
+			return ctx.JSON(http.StatusConflict, errJSON)
+		}
+		ctx.Logger().Errorf("could not revert kalan: %v", err.Error())
+		return serverError(ctx, err, "could not revert kalan")
+	}
+
+	kalanDTO := r.newKalanDTO(int(rev.KalanID), rev.Entry, rev.Pos, rev.Gloss, rev.Notes, revisionSenses(rev))
+	return ctx.JSON(http.StatusOK, kalanDTO)
+}
+
+func (r *Router) RestoreKalan(ctx echo.Context) error {
+	var kalanID KalanIDParam
+	err := ctx.Bind(&kalanID)
+	if err != nil {
+		errJSON := NewErrorJson(ErrInvalidFormat.Error())
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	userID, _ := ctx.Get("userID").(int)
+
+	_, err = r.kalanQueries.ReadKalanById(ctx.Request().Context(), int32(kalanID.ID))
+	if err == nil {
+		errJSON := NewErrorJson(ErrKalanExists.Error())
+		return ctx.JSON(http.StatusConflict, errJSON)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.Logger().Errorf("could not fetch kalan: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	rev, err := r.revisionQueries.ReadLatestRevisionByKalanID(ctx.Request().Context(), int32(kalanID.ID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			errMsg := fmt.Sprintf("no history for kalan with id=%v", kalanID.ID)
+			errJSON := NewErrorJson(errMsg)
+			return ctx.JSON(http.StatusNotFound, errJSON)
+		}
+		ctx.Logger().Errorf("could not fetch revision: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	err = r.withTx(ctx.Request().Context(), func(tx database.Store) error {
+		// the word may have come back since it was looked up
+		_, err := tx.Kalan().ReadKalanById(ctx.Request().Context(), rev.KalanID)
+		if err == nil {
+			return ErrKalanExists
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return r.restoreKalan(ctx.Request().Context(), tx, userID, rev)
+	})
+	if err != nil {
+		if errors.Is(err, ErrKalanExists) || database.IsDuplicateKey(err) {
+			errJSON := NewErrorJson(ErrKalanExists.Error())
+			return ctx.JSON(http.StatusConflict, errJSON)
+		}
+		ctx.Logger().Errorf("could not restore kalan: %v", err.Error())
+		return serverError(ctx, err, "could not restore kalan")
+	}
+
+	kalanDTO := r.newKalanDTO(int(rev.KalanID), rev.Entry, rev.Pos, rev.Gloss, rev.Notes, revisionSenses(rev))
+	return ctx.JSON(http.StatusCreated, kalanDTO)
+}
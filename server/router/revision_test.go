@@ -0,0 +1,68 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestRestoreLinks(t *testing.T) {
+	forEachStore(t, testRestoreLinks)
+}
+
+func testRestoreLinks(t *testing.T, s *testServer) {
+	ctx := context.Background()
+	token := s.addAdmin(t)
+
+	mi := s.addKalan(t, "mi", "I")
+	moku := s.addKalan(t, "moku", "eat")
+	kala := s.addKalan(t, "kala", "fish")
+
+	exampleDTO := router.ExampleDTO{Sentence: "mi moku", Gloss: "1SG eat", Translation: "I eat", KalanIDs: []int{int(mi), int(moku)}}
+	domainDTO := router.DomainDTO{Code: "1", Name: "Food"}
+	routeValues := []RouteValue{
+		{"add example", http.MethodPost, "/example", exampleDTO, token, http.StatusCreated},
+		{"add relation", http.MethodPost, fmt.Sprintf("/kalan/%v/relations", kala), router.AddRelationDTO{RelatedID: int(moku), Kind: router.RELATION_SYNONYM}, token, http.StatusCreated},
+		{"add domain", http.MethodPost, "/domains", domainDTO, token, http.StatusCreated},
+		{"tag word", http.MethodPost, fmt.Sprintf("/kalan/%v/domains", moku), router.TagKalanDTO{DomainID: 1}, token, http.StatusCreated},
+		{"delete word", http.MethodDelete, fmt.Sprintf("/kalan/%v", moku), nil, token, http.StatusNoContent},
+		{"restore word", http.MethodPost, fmt.Sprintf("/kalan/%v/restore", moku), nil, token, http.StatusCreated},
+	}
+	runRoutes(t, s, routeValues)
+
+	examples, err := s.store.Example().ReadExamplesByKalanID(ctx, moku)
+	if err != nil || len(examples) != 1 {
+		t.Errorf("examples of the restored word = %+v %v, want the one example", examples, err)
+	}
+
+	relations, err := s.store.Kalan().ReadRelations(ctx)
+	if err != nil || len(relations) != 1 || relations[0].Kind != router.RELATION_SYNONYM {
+		t.Errorf("relations after restoring = %+v %v, want the synonym", relations, err)
+	}
+
+	domains, err := s.store.Domain().ReadDomainsByKalanID(ctx, moku)
+	if err != nil || len(domains) != 1 || domains[0].Name != "Food" {
+		t.Errorf("domains of the restored word = %+v %v, want Food", domains, err)
+	}
+
+	// links to what was deleted in the meantime are left out
+	routeValues = []RouteValue{
+		{"delete word again", http.MethodDelete, fmt.Sprintf("/kalan/%v", moku), nil, token, http.StatusNoContent},
+		{"delete related word", http.MethodDelete, fmt.Sprintf("/kalan/%v", kala), nil, token, http.StatusNoContent},
+		{"delete domain", http.MethodDelete, "/domains/1", nil, token, http.StatusNoContent},
+		{"restore word again", http.MethodPost, fmt.Sprintf("/kalan/%v/restore", moku), nil, token, http.StatusCreated},
+	}
+	runRoutes(t, s, routeValues)
+
+	relations, err = s.store.Kalan().ReadRelations(ctx)
+	if err != nil || len(relations) != 0 {
+		t.Errorf("relations after restoring again = %+v %v, want none", relations, err)
+	}
+	domains, err = s.store.Domain().ReadDomainsByKalanID(ctx, moku)
+	if err != nil || len(domains) != 0 {
+		t.Errorf("domains of the word restored again = %+v %v, want none", domains, err)
+	}
+}
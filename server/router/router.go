@@ -2,13 +2,25 @@ package router
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"strings"
 
+	"wilin.info/api/database"
+	"wilin.info/api/database/domain"
+	"wilin.info/api/database/example"
 	"wilin.info/api/database/kalan"
 	"wilin.info/api/database/proposal"
 	"wilin.info/api/database/recovery"
+	"wilin.info/api/database/revision"
+	"wilin.info/api/database/session"
 	"wilin.info/api/database/users"
+	"wilin.info/api/database/verification"
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/morphology"
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/search"
+	"wilin.info/api/server/services"
 )
 
 type ErrorJson struct {
@@ -22,6 +34,13 @@ var (
 	ErrNoPos         = errors.New("no pos")
 	ErrNoGloss       = errors.New("no gloss")
 	ErrNoUserID      = errors.New("no user id")
+	ErrNoReason      = errors.New("no reason")
+	ErrNoKalanID     = errors.New("no kalan id")
+	ErrInvalidKind   = errors.New("invalid kind")
+	ErrKalanGone     = errors.New("word no longer exists")
+
+	ErrProposalNotPending = errors.New("proposal is not pending")
+	ErrDeleteNotEditable  = errors.New("the content of a delete proposal cannot be edited")
 
 	ErrNoUserFromCtx = errors.New("user could not be fetched")
 )
@@ -30,6 +49,13 @@ func NewErrorJson(message string) ErrorJson {
 	return ErrorJson{Error: message}
 }
 
+// QueryErrorJson is an error in a search query
+// along with where in the query it was found
+type QueryErrorJson struct {
+	Error    string `json:"error"`
+	Position int    `json:"position"`
+}
+
 // splitQuery takes a string and returns a slice
 // with the string split by commas (,).
 // If the string is empty, it will return an
@@ -47,25 +73,110 @@ func splitQuery(query string) []string {
 }
 
 type Router struct {
-	ctx             context.Context
-	kalanQueries    *kalan.Queries
-	userQueries     *users.Queries
-	proposalQueries *proposal.Queries
-	recoveryQueries *recovery.Queries
+	store               database.Store
+	kalanQueries        kalan.Querier
+	userQueries         users.Querier
+	proposalQueries     proposal.Querier
+	recoveryQueries     recovery.Querier
+	revisionQueries     revision.Querier
+	sessionQueries      session.Querier
+	verificationQueries verification.Querier
+	exampleQueries      example.Querier
+	domainQueries       domain.Querier
+	mailer              services.Mailer
+	index               *search.Index
+	collator            *collation.Collator
+	phonology           *phonology.Validator
+	morphology          *morphology.Morphology
 }
 
-func New(
-	ctx context.Context,
-	kalanQueries *kalan.Queries,
-	userQueries *users.Queries,
-	proposalQueries *proposal.Queries,
-	recoveryQueries *recovery.Queries,
-) *Router {
+func New(store database.Store, mailer services.Mailer, collator *collation.Collator, validator *phonology.Validator, analyzer *morphology.Morphology) *Router {
 	return &Router{
-		ctx:             ctx,
-		kalanQueries:    kalanQueries,
-		userQueries:     userQueries,
-		proposalQueries: proposalQueries,
-		recoveryQueries: recoveryQueries,
+		store:               store,
+		kalanQueries:        store.Kalan(),
+		userQueries:         store.Users(),
+		proposalQueries:     store.Proposal(),
+		recoveryQueries:     store.Recovery(),
+		revisionQueries:     store.Revision(),
+		sessionQueries:      store.Session(),
+		verificationQueries: store.Verification(),
+		exampleQueries:      store.Example(),
+		domainQueries:       store.Domain(),
+		mailer:              mailer,
+		index:               newIndex(validator),
+		collator:            collator,
+		phonology:           validator,
+		morphology:          analyzer,
+	}
+}
+
+// newIndex returns a search index that suggests entries that sound
+// like the search text in the configured phonology
+func newIndex(validator *phonology.Validator) *search.Index {
+	index := search.New()
+	index.SetSound(validator.Sound)
+	return index
+}
+
+var ErrNotInTx = errors.New("kalan changed outside of a transaction")
+
+// routerTx is the store given to the functions run by withTx. It
+// keeps the ids of the kalans changed in the transaction, so that
+// they are only updated in the search index once it is committed
+type routerTx struct {
+	database.Store
+	changed map[int32]bool
+}
+
+func (tx *routerTx) InTx(ctx context.Context, fn func(tx database.Store) error) error {
+	return tx.Store.InTx(ctx, func(inner database.Store) error {
+		return fn(&routerTx{Store: inner, changed: tx.changed})
+	})
+}
+
+// markChanged notes that the kalan with id was changed in tx
+func markChanged(tx database.Store, id int32) error {
+	t, ok := tx.(*routerTx)
+	if !ok {
+		return ErrNotInTx
+	}
+	t.changed[id] = true
+	return nil
+}
+
+// withTx runs fn inside of a database transaction.
+// The transaction is committed if fn returns nil
+// and rolled back otherwise
+func (r *Router) withTx(ctx context.Context, fn func(tx database.Store) error) error {
+	changed := map[int32]bool{}
+	err := r.store.InTx(ctx, func(tx database.Store) error {
+		return fn(&routerTx{Store: tx, changed: changed})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.refreshIndex(ctx, changed)
+	return nil
+}
+
+// refreshIndex puts the kalans with the given ids into the search
+// index as they are stored now. Reading them again, rather than
+// keeping what the transaction wrote, means that the index ends up
+// with the latest values when transactions commit close together.
+// The index is emptied if a kalan cannot be read, to be loaded
+// again the next time it is used
+func (r *Router) refreshIndex(ctx context.Context, ids map[int32]bool) {
+	for id := range ids {
+		k, err := r.kalanQueries.ReadKalanById(ctx, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			r.index.Delete(id)
+		case err != nil:
+			r.index.Invalidate()
+			return
+		default:
+			r.index.Put(k)
+		}
 	}
 }
@@ -0,0 +1,312 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/database"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/database/memory"
+	"wilin.info/api/database/migrate"
+	"wilin.info/api/database/proposal"
+	"wilin.info/api/database/users"
+	"wilin.info/api/server"
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/morphology"
+	"wilin.info/api/server/phonology"
+	"wilin.info/api/server/router"
+	"wilin.info/api/server/services"
+)
+
+const TEST_SECRET = "test-secret"
+
+const TEST_PASSWORD = "password123"
+
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []services.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg services.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// link returns the id at the end of the last link sent to
+// the given address that starts with path
+func (m *recordingMailer) link(t *testing.T, to string, path string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	linkRegex := regexp.MustCompile(path + `/([A-Za-z0-9]+)`)
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To != to {
+			continue
+		}
+		match := linkRegex.FindStringSubmatch(m.messages[i].Text)
+		if match != nil {
+			return match[1]
+		}
+	}
+
+	t.Fatalf("no %v link was sent to %v", path, to)
+	return ""
+}
+
+type testServer struct {
+	echo   *echo.Echo
+	store  database.Store
+	mailer *recordingMailer
+}
+
+// newSQLiteStore migrates a new database file in a temporary
+// directory that is removed when the test ends
+func newSQLiteStore(t *testing.T) database.Store {
+	t.Helper()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "wilin.db"))
+	if err != nil {
+		t.Fatalf("could not open sqlite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, database.DRIVER_SQLITE)
+	if err != nil {
+		t.Fatalf("could not load migrations: %v", err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("could not migrate sqlite database: %v", err)
+	}
+
+	return database.NewSQLStore(db)
+}
+
+// forEachStore runs test against a server backed by the
+// in-memory store and one backed by a SQLite database
+func forEachStore(t *testing.T, test func(t *testing.T, s *testServer)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newTestServer(t, memory.New()))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, newTestServer(t, newSQLiteStore(t)))
+	})
+}
+
+func newTestServer(t *testing.T, store database.Store) *testServer {
+	t.Helper()
+	t.Setenv("SECRET_KEY", TEST_SECRET)
+
+	collator, err := collation.NewCollator()
+	if err != nil {
+		t.Fatalf("could not load alphabet: %v", err)
+	}
+	validator, err := phonology.NewValidator()
+	if err != nil {
+		t.Fatalf("could not load phonology: %v", err)
+	}
+	analyzer, err := morphology.NewMorphology()
+	if err != nil {
+		t.Fatalf("could not load morphology: %v", err)
+	}
+
+	mailer := &recordingMailer{}
+	return &testServer{
+		echo:   server.New(store, mailer, collator, validator, analyzer),
+		store:  store,
+		mailer: mailer,
+	}
+}
+
+func (s *testServer) request(t *testing.T, method string, path string, body any, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&reqBody).Encode(body)
+		if err != nil {
+			t.Fatalf("could not encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &reqBody)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+// addUser creates a user with the given role and logs them in
+func (s *testServer) addUser(t *testing.T, username string, role services.Role, verified bool) router.LoginReturnDTO {
+	t.Helper()
+	ctx := context.Background()
+
+	passwordHash, err := services.GeneratePasswordHash(TEST_PASSWORD)
+	if err != nil {
+		t.Fatalf("could not hash password: %v", err)
+	}
+
+	createParams := users.CreateUserParams{
+		Email:    username + "@wilin.info",
+		Username: username,
+		Password: passwordHash,
+		Role:     role.String(),
+	}
+	result, err := s.store.Users().CreateUser(ctx, createParams)
+	if err != nil {
+		t.Fatalf("could not create user: %v", err)
+	}
+
+	if verified {
+		userID, _ := result.LastInsertId()
+		_, err = s.store.Users().VerifyUser(ctx, int32(userID))
+		if err != nil {
+			t.Fatalf("could not verify user: %v", err)
+		}
+	}
+
+	return s.login(t, username, TEST_PASSWORD)
+}
+
+func (s *testServer) login(t *testing.T, username string, password string) router.LoginReturnDTO {
+	t.Helper()
+
+	loginFields := router.LoginFields{Username: username, Password: password}
+	rec := s.request(t, http.MethodPost, "/login", loginFields, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("could not log in as %v: %v %v", username, rec.Code, rec.Body.String())
+	}
+	return decode[router.LoginReturnDTO](t, rec)
+}
+
+// addAdmin creates a verified admin and returns their auth token
+func (s *testServer) addAdmin(t *testing.T) string {
+	t.Helper()
+	return s.addUser(t, "admin", services.ROLE_ADMIN, true).AuthToken
+}
+
+// addKalans creates words straight in the store and returns their
+// ids. Words without a part of speech are nouns
+func (s *testServer) addKalans(t *testing.T, words ...router.KalanDTO) []int32 {
+	t.Helper()
+
+	ids := []int32{}
+	for _, word := range words {
+		if word.Pos == "" {
+			word.Pos = "noun"
+		}
+		createParams := kalan.CreateKalanParams{Entry: word.Entry, Pos: word.Pos, Gloss: word.Gloss, Notes: word.Notes}
+		result, err := s.store.Kalan().CreateKalan(context.Background(), createParams)
+		if err != nil {
+			t.Fatalf("could not create kalan: %v", err)
+		}
+		id, _ := result.LastInsertId()
+		ids = append(ids, int32(id))
+	}
+	return ids
+}
+
+// addKalan creates a noun straight in the store and returns its id
+func (s *testServer) addKalan(t *testing.T, entry string, gloss string) int32 {
+	t.Helper()
+	return s.addKalans(t, router.KalanDTO{Entry: entry, Gloss: gloss})[0]
+}
+
+func (s *testServer) addProposal(t *testing.T, userID int) int32 {
+	t.Helper()
+
+	createParams := proposal.CreateProposalParams{
+		UserID: sql.NullInt32{Int32: int32(userID), Valid: true},
+		Entry:  "jan",
+		Pos:    "noun",
+		Gloss:  "person",
+		Kind:   router.PROPOSAL_KIND_NEW,
+	}
+	result, err := s.store.Proposal().CreateProposal(context.Background(), createParams)
+	if err != nil {
+		t.Fatalf("could not create proposal: %v", err)
+	}
+	id, _ := result.LastInsertId()
+	return int32(id)
+}
+
+// searchEntries returns the entries found by a search on /kalan/paginated
+func (s *testServer) searchEntries(t *testing.T, query string) []string {
+	t.Helper()
+
+	rec := s.request(t, http.MethodGet, "/kalan/paginated?"+query, nil, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /kalan/paginated?%v = %v %v", query, rec.Code, rec.Body.String())
+	}
+
+	entries := []string{}
+	for _, k := range decode[router.KalanArrayDTO](t, rec).Kalans {
+		entries = append(entries, k.Entry)
+	}
+	return entries
+}
+
+// searchCursor returns the cursor to the page after the
+// one found by a search on /kalan/paginated
+func (s *testServer) searchCursor(t *testing.T, query string) string {
+	t.Helper()
+
+	rec := s.request(t, http.MethodGet, "/kalan/paginated?"+query, nil, "")
+	cursor := decode[router.KalanArrayDTO](t, rec).NextCursor
+	if cursor == "" {
+		t.Fatalf("GET /kalan/paginated?%v has no next cursor", query)
+	}
+	return cursor
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var value T
+	err := json.Unmarshal(rec.Body.Bytes(), &value)
+	if err != nil {
+		t.Fatalf("could not decode %q: %v", rec.Body.String(), err)
+	}
+	return value
+}
+
+type RouteValue struct {
+	name   string
+	method string
+	path   string
+	body   any
+	token  string
+	status int
+}
+
+func runRoutes(t *testing.T, s *testServer, routeValues []RouteValue) {
+	t.Helper()
+
+	for _, test := range routeValues {
+		rec := s.request(t, test.method, test.path, test.body, test.token)
+		if rec.Code != test.status {
+			t.Errorf("%v: %v %v = %v %v, want %v", test.name, test.method, test.path, rec.Code, rec.Body.String(), test.status)
+		}
+	}
+}
+
+type SearchValue struct {
+	query    string
+	expected []string
+}
@@ -0,0 +1,206 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"wilin.info/api/database"
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/database/revision"
+)
+
+const MAX_SENSES = 50
+
+// MAX_GLOSS_LENGTH is the size of the gloss column
+// that the glosses of every sense are joined into
+const MAX_GLOSS_LENGTH = 255
+
+// SENSE_SEPARATOR is put between the glosses of the senses of
+// a kalan in its gloss. Clients that only send a gloss get one
+// sense for each part of it between separators
+const SENSE_SEPARATOR = ";"
+
+var (
+	ErrNoSenseGloss      = errors.New("sense has no gloss")
+	ErrInvalidSenseGloss = fmt.Errorf("sense gloss cannot contain %q", SENSE_SEPARATOR)
+	ErrTooManySenses     = fmt.Errorf("a word can have at most %v senses", MAX_SENSES)
+	ErrGlossTooLong      = fmt.Errorf("glosses must be at most %v characters together", MAX_GLOSS_LENGTH)
+)
+
+type SenseDTO struct {
+	Gloss      string `json:"gloss"`
+	Definition string `json:"definition"`
+	UsageLabel string `json:"usageLabel"`
+	Register   string `json:"register"`
+}
+
+func NewSenseDTO(sense kalan.KalanSense) SenseDTO {
+	return SenseDTO{
+		Gloss:      sense.Gloss,
+		Definition: sense.Definition,
+		UsageLabel: sense.UsageLabel,
+		Register:   sense.Register,
+	}
+}
+
+// glossSummary joins the glosses of senses into the gloss of the
+// kalan they belong to, which is what searches and sorts go by
+func glossSummary(senses []SenseDTO) string {
+	glosses := []string{}
+	for _, sense := range senses {
+		glosses = append(glosses, sense.Gloss)
+	}
+	return strings.Join(glosses, SENSE_SEPARATOR+" ")
+}
+
+// sensesFromGloss splits gloss into one sense for each of its
+// parts. Senses in current with the same gloss as a part keep the
+// rest of their values, so that changing a gloss without sending
+// senses does not lose the definitions of the others
+func sensesFromGloss(gloss string, current []SenseDTO) []SenseDTO {
+	senses := []SenseDTO{}
+	for _, part := range strings.Split(gloss, SENSE_SEPARATOR) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		sense := SenseDTO{Gloss: part}
+		for _, c := range current {
+			if c.Gloss == part {
+				sense = c
+				break
+			}
+		}
+		senses = append(senses, sense)
+	}
+	return senses
+}
+
+// resolveSenses makes the gloss and the senses of kalanDTO agree.
+// The senses are split from the gloss if none were sent, and the
+// gloss is joined from the senses otherwise
+func resolveSenses(kalanDTO *KalanDTO, current []SenseDTO) error {
+	if len(kalanDTO.Senses) < 1 {
+		kalanDTO.Senses = sensesFromGloss(kalanDTO.Gloss, current)
+	}
+
+	if len(kalanDTO.Senses) > MAX_SENSES {
+		return ErrTooManySenses
+	}
+	for i := range kalanDTO.Senses {
+		sense := &kalanDTO.Senses[i]
+		sense.Gloss = strings.TrimSpace(sense.Gloss)
+		if sense.Gloss == "" {
+			return ErrNoSenseGloss
+		}
+		if strings.Contains(sense.Gloss, SENSE_SEPARATOR) {
+			return ErrInvalidSenseGloss
+		}
+	}
+
+	kalanDTO.Gloss = glossSummary(kalanDTO.Senses)
+	if utf8.RuneCountInString(kalanDTO.Gloss) > MAX_GLOSS_LENGTH {
+		return ErrGlossTooLong
+	}
+	return nil
+}
+
+func newSenseDTOs(senses []kalan.KalanSense) []SenseDTO {
+	senseDTOs := []SenseDTO{}
+	for _, sense := range senses {
+		senseDTOs = append(senseDTOs, NewSenseDTO(sense))
+	}
+	return senseDTOs
+}
+
+// readSenses returns the senses of the kalan with id, in order
+func readSenses(ctx context.Context, kalanQueries kalan.Querier, id int32) ([]SenseDTO, error) {
+	senses, err := kalanQueries.ReadSensesByKalanID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newSenseDTOs(senses), nil
+}
+
+// readAllSenses returns the senses of every kalan by kalan id
+func (r *Router) readAllSenses(ctx context.Context) (map[int32][]SenseDTO, error) {
+	senses, err := r.kalanQueries.ReadSenses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return groupSenses(senses), nil
+}
+
+// readSensesByKalanIDs returns the senses of the kalans with ids
+// by kalan id, so that a page of results reads only its own senses
+func (r *Router) readSensesByKalanIDs(ctx context.Context, ids []int32) (map[int32][]SenseDTO, error) {
+	senses, err := r.kalanQueries.ReadSensesByKalanIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return groupSenses(senses), nil
+}
+
+// groupSenses sorts senses by the kalan they belong to
+func groupSenses(senses []kalan.KalanSense) map[int32][]SenseDTO {
+	sensesByKalan := map[int32][]SenseDTO{}
+	for _, sense := range senses {
+		sensesByKalan[sense.KalanID] = append(sensesByKalan[sense.KalanID], NewSenseDTO(sense))
+	}
+	return sensesByKalan
+}
+
+// writeSenses replaces the senses of the kalan with id.
+// It must be called inside of a transaction
+func writeSenses(ctx context.Context, tx database.Store, id int32, senses []SenseDTO) error {
+	kalanQueries := tx.Kalan()
+
+	_, err := kalanQueries.DeleteSensesByKalanID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for i, sense := range senses {
+		createParams := kalan.CreateSenseParams{
+			KalanID:    id,
+			Position:   int32(i),
+			Gloss:      sense.Gloss,
+			Definition: sense.Definition,
+			UsageLabel: sense.UsageLabel,
+			Register:   sense.Register,
+		}
+		_, err = kalanQueries.CreateSense(ctx, createParams)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSenses stores senses in a revision as JSON
+func encodeSenses(senses []SenseDTO) sql.NullString {
+	data, err := json.Marshal(senses)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// revisionSenses returns the senses stored in rev. Revisions
+// made before words had senses get theirs from their gloss
+func revisionSenses(rev revision.KalanRevision) []SenseDTO {
+	if rev.Senses.Valid {
+		senses := []SenseDTO{}
+		err := json.Unmarshal([]byte(rev.Senses.String), &senses)
+		if err == nil {
+			return senses
+		}
+	}
+	return sensesFromGloss(rev.Gloss, nil)
+}
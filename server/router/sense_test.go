@@ -0,0 +1,91 @@
+package router_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestSenseRoutes(t *testing.T) {
+	forEachStore(t, testSenseRoutes)
+}
+
+func testSenseRoutes(t *testing.T, s *testServer) {
+	token := s.addAdmin(t)
+
+	senses := []router.SenseDTO{
+		{Gloss: "water", Definition: "the clear liquid of rivers and rain", Register: "neutral"},
+		{Gloss: "to drink", Definition: "to take in a liquid", UsageLabel: "verb"},
+	}
+	rec := s.request(t, http.MethodPost, "/kalan", router.KalanDTO{Entry: "telo", Pos: "noun", Senses: senses}, token)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /kalan = %v %v", rec.Code, rec.Body.String())
+	}
+	created := decode[router.KalanDTO](t, rec)
+	if created.Gloss != "water; to drink" || len(created.Senses) != 2 {
+		t.Fatalf("POST /kalan = %+v, want the glosses of both senses", created)
+	}
+	path := "/kalan/" + strconv.Itoa(created.ID)
+
+	rec = s.request(t, http.MethodGet, path, nil, "")
+	got := decode[router.KalanDTO](t, rec)
+	if len(got.Senses) != 2 || got.Senses[0] != senses[0] || got.Senses[1] != senses[1] {
+		t.Errorf("GET %v senses = %+v, want %+v", path, got.Senses, senses)
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/paginated?search=drink&fields=gloss", nil, "")
+	found := decode[router.KalanArrayDTO](t, rec)
+	if len(found.Kalans) != 1 || len(found.Kalans[0].Senses) != 2 {
+		t.Errorf("GET /kalan/paginated = %+v, want telo with its senses", found)
+	}
+
+	s.addKalan(t, "wilin", "word")
+	rec = s.request(t, http.MethodGet, "/kalan", nil, "")
+	all := decode[router.KalanArrayDTO](t, rec)
+	for _, k := range all.Kalans {
+		if k.Senses == nil {
+			t.Errorf("GET /kalan: %v has null senses", k.Entry)
+		}
+	}
+
+	updated := created
+	updated.Senses = nil
+	updated.Gloss = "to drink; liquid"
+	rec = s.request(t, http.MethodPut, "/kalan", updated, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /kalan = %v %v", rec.Code, rec.Body.String())
+	}
+	got = decode[router.KalanDTO](t, rec)
+	if len(got.Senses) != 2 || got.Senses[0] != senses[1] || got.Senses[1].Gloss != "liquid" {
+		t.Errorf("PUT /kalan with a gloss = %+v, want the definition of to drink kept", got.Senses)
+	}
+
+	rec = s.request(t, http.MethodGet, path+"/history", nil, token)
+	history := decode[router.RevisionArrDTO](t, rec)
+	if len(history.Revisions) != 2 || len(history.Revisions[1].Senses) != 2 || history.Revisions[1].Senses[0] != senses[0] {
+		t.Fatalf("GET %v/history = %+v, want the senses of each revision", path, history)
+	}
+	firstRevision := history.Revisions[1].ID
+
+	tooMany := []router.SenseDTO{}
+	for range router.MAX_SENSES + 1 {
+		tooMany = append(tooMany, router.SenseDTO{Gloss: "a"})
+	}
+	routeValues := []RouteValue{
+		{"sense without gloss", http.MethodPost, "/kalan", router.KalanDTO{Entry: "a", Pos: "b", Senses: []router.SenseDTO{{Definition: "c"}}}, token, http.StatusBadRequest},
+		{"sense gloss with separator", http.MethodPost, "/kalan", router.KalanDTO{Entry: "a", Pos: "b", Senses: []router.SenseDTO{{Gloss: "c; d"}}}, token, http.StatusBadRequest},
+		{"too many senses", http.MethodPost, "/kalan", router.KalanDTO{Entry: "a", Pos: "b", Senses: tooMany}, token, http.StatusBadRequest},
+		{"revert senses", http.MethodPost, path + "/revert/" + strconv.Itoa(firstRevision), nil, token, http.StatusOK},
+		{"delete word with senses", http.MethodDelete, path, nil, token, http.StatusNoContent},
+		{"restore word with senses", http.MethodPost, path + "/restore", nil, token, http.StatusCreated},
+	}
+	runRoutes(t, s, routeValues)
+
+	rec = s.request(t, http.MethodGet, path, nil, "")
+	restored := decode[router.KalanDTO](t, rec)
+	if restored.Gloss != "water; to drink" || len(restored.Senses) != 2 || restored.Senses[0] != senses[0] || restored.Senses[1] != senses[1] {
+		t.Errorf("restored word = %+v, want the reverted senses", restored)
+	}
+}
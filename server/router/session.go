@@ -0,0 +1,231 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"wilin.info/api/database"
+	"wilin.info/api/database/session"
+	"wilin.info/api/server/services"
+)
+
+const MAX_USER_AGENT_LENGTH = 255
+
+var ErrTokenReused = errors.New("refresh token reused")
+
+type SessionIDParam struct {
+	ID string `param:"id"`
+}
+
+type SessionDTO struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiredAt  time.Time `json:"expiredAt"`
+	Current    bool      `json:"current"`
+}
+
+type SessionArrDTO struct {
+	Sessions []SessionDTO `json:"sessions"`
+}
+
+func (arr *SessionArrDTO) AddSession(s SessionDTO) {
+	arr.Sessions = append(arr.Sessions, s)
+}
+
+func refreshExpiry() time.Time {
+	return time.Now().Add(time.Minute * TIME_TO_REFRESH_EXPIRE_MINUTES)
+}
+
+// createSession starts a new login session for the user
+// and returns the tokens that belong to it
+func (r *Router) createSession(ctx context.Context, tx database.Store, userID int32, userAgent string) (TokensDTO, error) {
+	sessionID, err := gonanoid.Generate(ID_ALPHABET, 64)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	if len(userAgent) > MAX_USER_AGENT_LENGTH {
+		userAgent = userAgent[:MAX_USER_AGENT_LENGTH]
+	}
+
+	createParams := session.CreateSessionParams{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: userAgent,
+		ExpiredAt: refreshExpiry(),
+	}
+	_, err = tx.Session().CreateSession(ctx, createParams)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	return r.issueTokens(ctx, tx, userID, sessionID)
+}
+
+// issueTokens generates a new pair of tokens for a session. The
+// refresh token is stored so that it can only be used once
+func (r *Router) issueTokens(ctx context.Context, tx database.Store, userID int32, sessionID string) (TokensDTO, error) {
+	tokenID, err := gonanoid.Generate(ID_ALPHABET, 64)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	createParams := session.CreateRefreshTokenParams{
+		ID:        tokenID,
+		SessionID: sessionID,
+		ExpiredAt: refreshExpiry(),
+	}
+	_, err = tx.Session().CreateRefreshToken(ctx, createParams)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	subject := strconv.Itoa(int(userID))
+
+	authToken, err := services.GenerateSessionToken(services.TOKEN_TYPE_AUTH, subject, sessionID, "", TIME_TO_AUTH_EXPIRE_MINUTES)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	refreshToken, err := services.GenerateSessionToken(services.TOKEN_TYPE_REFRESH, subject, sessionID, tokenID, TIME_TO_REFRESH_EXPIRE_MINUTES)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	return TokensDTO{AuthToken: authToken, RefreshToken: refreshToken}, nil
+}
+
+// rotateTokens exchanges a refresh token for a new pair of tokens.
+// A refresh token that has already been used means it was stolen,
+// so ErrTokenReused is returned and the caller must end the session
+func (r *Router) rotateTokens(ctx context.Context, tx database.Store, refreshToken session.RefreshToken, userID int32) (TokensDTO, error) {
+	sessionQueries := tx.Session()
+
+	result, err := sessionQueries.UseRefreshToken(ctx, refreshToken.ID)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	if rowsAffected < 1 {
+		return TokensDTO{}, ErrTokenReused
+	}
+
+	touchParams := session.TouchSessionParams{
+		ExpiredAt: refreshExpiry(),
+		ID:        refreshToken.SessionID,
+	}
+	_, err = sessionQueries.TouchSession(ctx, touchParams)
+	if err != nil {
+		return TokensDTO{}, err
+	}
+
+	return r.issueTokens(ctx, tx, userID, refreshToken.SessionID)
+}
+
+func (r *Router) Logout(ctx echo.Context) error {
+	tokens := new(TokensDTO)
+	err := ctx.Bind(tokens)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	claims, err := services.ParseRefreshToken(tokens.RefreshToken)
+	if err != nil {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	refreshToken, err := r.sessionQueries.ReadRefreshTokenByID(ctx.Request().Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.NoContent(http.StatusNoContent)
+		}
+		return serverError(ctx, err, ServerError)
+	}
+
+	_, err = r.sessionQueries.RevokeSession(ctx.Request().Context(), refreshToken.SessionID)
+	if err != nil {
+		ctx.Logger().Errorf("could not revoke session: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (r *Router) GetSessions(ctx echo.Context) error {
+	userID, ok := ctx.Get("userID").(int)
+	if !ok {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	currentSessionID, _ := ctx.Get("sessionID").(string)
+
+	sessions, err := r.sessionQueries.ReadActiveSessionsByUserID(ctx.Request().Context(), int32(userID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return serverError(ctx, err, ServerError)
+	}
+
+	sessionArrDTO := SessionArrDTO{Sessions: []SessionDTO{}}
+	for _, s := range sessions {
+		if isExpired(&s.ExpiredAt) {
+			continue
+		}
+
+		sessionDTO := SessionDTO{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiredAt:  s.ExpiredAt,
+			Current:    s.ID == currentSessionID,
+		}
+		sessionArrDTO.AddSession(sessionDTO)
+	}
+
+	return ctx.JSON(http.StatusOK, sessionArrDTO)
+}
+
+func (r *Router) DeleteSession(ctx echo.Context) error {
+	params := SessionIDParam{}
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	userID, ok := ctx.Get("userID").(int)
+	if !ok {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	s, err := r.sessionQueries.ReadSessionByID(ctx.Request().Context(), params.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return serverError(ctx, err, ServerError)
+	}
+
+	if err != nil || s.UserID != int32(userID) || s.Revoked {
+		errJSON := NewErrorJson("invalid session")
+		return ctx.JSON(http.StatusNotFound, errJSON)
+	}
+
+	_, err = r.sessionQueries.RevokeSession(ctx.Request().Context(), s.ID)
+	if err != nil {
+		ctx.Logger().Errorf("could not revoke session: %v", err.Error())
+		return serverError(ctx, err, ServerError)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
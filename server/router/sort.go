@@ -0,0 +1,115 @@
+package router
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/search"
+)
+
+var ErrUnknownSortKey = errors.New("unknown sort key")
+
+var SORT_FIELDS = []string{"entry", "pos", "gloss", "notes", "id"}
+
+// SortKey is one of the comma separated keys of the sort parameter.
+// A key that starts with - sorts in descending order
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+func (k SortKey) String() string {
+	if k.Descending {
+		return "-" + k.Field
+	}
+	return k.Field
+}
+
+// ParseSort reads a sort parameter such as "pos,-entry".
+// Kalans are ordered by id once every key has been compared
+func ParseSort(sort string) ([]SortKey, error) {
+	keys := []SortKey{}
+	for _, field := range splitQuery(sort) {
+		key := SortKey{Field: field}
+		if strings.HasPrefix(field, "-") {
+			key = SortKey{Field: field[1:], Descending: true}
+		}
+
+		if !slices.Contains(SORT_FIELDS, key.Field) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSortKey, field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortString writes keys back into the form of a sort parameter
+func sortString(keys []SortKey) string {
+	fields := []string{}
+	for _, key := range keys {
+		fields = append(fields, key.String())
+	}
+	return strings.Join(fields, ",")
+}
+
+// kalanSortValue returns the value of k that field orders by,
+// folded so that case and diacritics do not change the order.
+// Entries are ordered by the alphabet of the collator. Ids are
+// compared as numbers and have no value
+func kalanSortValue(k kalan.Kalan, field string, collator *collation.Collator) string {
+	switch field {
+	case "entry":
+		return collator.Key(k.Entry)
+	case "pos":
+		return search.Fold(k.Pos)
+	case "gloss":
+		return search.Fold(k.Gloss)
+	case "notes":
+		return search.Fold(k.Notes)
+	default:
+		return ""
+	}
+}
+
+func kalanSortValues(k kalan.Kalan, keys []SortKey, collator *collation.Collator) []string {
+	values := []string{}
+	for _, key := range keys {
+		values = append(values, kalanSortValue(k, key.Field, collator))
+	}
+	return values
+}
+
+// compareSortValues compares two kalans by the values kalanSortValues
+// returned for them, and then by id
+func compareSortValues(aValues []string, aID int32, bValues []string, bID int32, keys []SortKey) int {
+	for i, key := range keys {
+		c := cmp.Compare(aValues[i], bValues[i])
+		if key.Field == "id" {
+			c = cmp.Compare(aID, bID)
+		}
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(aID, bID)
+}
+
+// sortKalans orders kalans by keys
+func sortKalans(kalans []kalan.Kalan, keys []SortKey, collator *collation.Collator) {
+	values := map[int32][]string{}
+	for _, k := range kalans {
+		values[k.ID] = kalanSortValues(k, keys, collator)
+	}
+
+	slices.SortFunc(kalans, func(a kalan.Kalan, b kalan.Kalan) int {
+		return compareSortValues(values[a.ID], a.ID, values[b.ID], b.ID, keys)
+	})
+}
@@ -0,0 +1,95 @@
+package router_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestSortRoutes(t *testing.T) {
+	forEachStore(t, testSortRoutes)
+}
+
+func testSortRoutes(t *testing.T, s *testServer) {
+	s.addKalans(t,
+		router.KalanDTO{Entry: "kan", Pos: "verb", Gloss: "eat"},
+		router.KalanDTO{Entry: "bel", Pos: "noun", Gloss: "bell"},
+		router.KalanDTO{Entry: "Ada", Pos: "verb", Gloss: "add"},
+		router.KalanDTO{Entry: "zuma", Pos: "noun", Gloss: "zoo"},
+	)
+
+	sortValues := []SearchValue{
+		{"sort=entry", []string{"Ada", "bel", "kan", "zuma"}},
+		{"sort=-entry", []string{"zuma", "kan", "bel", "Ada"}},
+		{"sort=pos,-entry", []string{"zuma", "bel", "kan", "Ada"}},
+		{"sort=-pos,entry", []string{"Ada", "kan", "bel", "zuma"}},
+		{"sort=-id", []string{"zuma", "Ada", "bel", "kan"}},
+		{"sort=pos,-entry&limit=2&cursor=" + s.searchCursor(t, "sort=pos,-entry&limit=2"), []string{"kan", "Ada"}},
+	}
+	for _, test := range sortValues {
+		entries := s.searchEntries(t, test.query)
+		if !slices.Equal(entries, test.expected) {
+			t.Errorf("GET /kalan/paginated?%v = %v, want %v", test.query, entries, test.expected)
+		}
+	}
+
+	rec := s.request(t, http.MethodGet, "/kalan?sort=pos,-entry", nil, "")
+	all := decode[router.KalanArrayDTO](t, rec)
+	entries := []string{}
+	for _, k := range all.Kalans {
+		entries = append(entries, k.Entry)
+	}
+	if !slices.Equal(entries, []string{"zuma", "bel", "kan", "Ada"}) {
+		t.Errorf("GET /kalan?sort=pos,-entry = %v, want [zuma bel kan Ada]", entries)
+	}
+
+	routeValues := []RouteValue{
+		{"unknown sort key", http.MethodGet, "/kalan/paginated?sort=color", nil, "", http.StatusBadRequest},
+		{"unknown descending sort key", http.MethodGet, "/kalan/paginated?sort=entry,-color", nil, "", http.StatusBadRequest},
+		{"empty sort key", http.MethodGet, "/kalan/paginated?sort=entry,,pos", nil, "", http.StatusBadRequest},
+		{"unknown sort key on all words", http.MethodGet, "/kalan?sort=color", nil, "", http.StatusBadRequest},
+		{"no sort on all words", http.MethodGet, "/kalan", nil, "", http.StatusOK},
+	}
+	runRoutes(t, s, routeValues)
+}
+
+func TestCollationRoutes(t *testing.T) {
+	// ng is a letter of its own that comes after n,
+	// and k comes before every other letter
+	path := filepath.Join(t.TempDir(), "alphabet.json")
+	alphabet := `{"letters": ["k", "a", "e", "g", "i", "n", "ng", "o", "u"], "ignore": ["-"]}`
+	err := os.WriteFile(path, []byte(alphabet), 0o644)
+	if err != nil {
+		t.Fatalf("could not write alphabet: %v", err)
+	}
+	t.Setenv("ALPHABET_FILE", path)
+
+	forEachStore(t, testCollationRoutes)
+}
+
+func testCollationRoutes(t *testing.T, s *testServer) {
+	for _, entry := range []string{"nga", "nu", "ani", "kan", "na-i"} {
+		s.addKalan(t, entry, "word")
+	}
+
+	expected := []string{"kan", "ani", "na-i", "nu", "nga"}
+	entries := s.searchEntries(t, "sort=entry")
+	if !slices.Equal(entries, expected) {
+		t.Errorf("GET /kalan/paginated?sort=entry = %v, want %v", entries, expected)
+	}
+
+	rec := s.request(t, http.MethodGet, "/kalan?sort=-entry", nil, "")
+	all := decode[router.KalanArrayDTO](t, rec)
+	entries = []string{}
+	for _, k := range all.Kalans {
+		entries = append(entries, k.Entry)
+	}
+	slices.Reverse(expected)
+	if !slices.Equal(entries, expected) {
+		t.Errorf("GET /kalan?sort=-entry = %v, want %v", entries, expected)
+	}
+}
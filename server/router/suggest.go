@@ -0,0 +1,87 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"wilin.info/api/server/search"
+)
+
+const (
+	DEFAULT_SUGGESTION_LIMIT = 10
+	MAX_SUGGESTION_LIMIT     = 50
+	// MAX_SUGGESTION_QUERY_LENGTH is the longest text in runes
+	// that suggestions are looked for, since it is compared
+	// against every entry and gloss word
+	MAX_SUGGESTION_QUERY_LENGTH = 64
+)
+
+type SuggestionDTO struct {
+	Text     string `json:"text"`
+	Field    string `json:"field"`
+	KalanID  int    `json:"kalanId,omitempty"`
+	Distance int    `json:"distance"`
+}
+
+func NewSuggestionDTO(suggestion search.Suggestion) SuggestionDTO {
+	return SuggestionDTO{
+		Text:     suggestion.Text,
+		Field:    suggestion.Field,
+		KalanID:  int(suggestion.KalanID),
+		Distance: suggestion.Distance,
+	}
+}
+
+type SuggestionArrDTO struct {
+	Suggestions []SuggestionDTO `json:"suggestions"`
+}
+
+func (arr *SuggestionArrDTO) AddSuggestion(suggestion SuggestionDTO) {
+	arr.Suggestions = append(arr.Suggestions, suggestion)
+}
+
+type SuggestQueryDTO struct {
+	Query string `query:"q"`
+	Limit int    `query:"limit"`
+}
+
+func newSuggestionDTOs(suggestions []search.Suggestion) []SuggestionDTO {
+	suggestionDTOs := []SuggestionDTO{}
+	for _, suggestion := range suggestions {
+		suggestionDTOs = append(suggestionDTOs, NewSuggestionDTO(suggestion))
+	}
+	return suggestionDTOs
+}
+
+func (r *Router) GetKalanSuggestions(ctx echo.Context) error {
+	var suggestQueryDTO SuggestQueryDTO
+	err := ctx.Bind(&suggestQueryDTO)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson("Invalid query"))
+	}
+
+	limit := suggestQueryDTO.Limit
+	if limit == 0 {
+		limit = DEFAULT_SUGGESTION_LIMIT
+	}
+	if limit < 1 || limit > MAX_SUGGESTION_LIMIT {
+		errMsg := fmt.Sprintf("limit must be between 1 and %v", MAX_SUGGESTION_LIMIT)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	if utf8.RuneCountInString(suggestQueryDTO.Query) > MAX_SUGGESTION_QUERY_LENGTH {
+		errMsg := fmt.Sprintf("q must be at most %v characters", MAX_SUGGESTION_QUERY_LENGTH)
+		return ctx.JSON(http.StatusBadRequest, NewErrorJson(errMsg))
+	}
+
+	index, err := r.searchIndex(ctx.Request().Context())
+	if err != nil {
+		return serverError(ctx, err, "Could not fetch suggestions")
+	}
+
+	suggestions := index.Suggest(suggestQueryDTO.Query, limit)
+	suggestionArrDTO := SuggestionArrDTO{Suggestions: newSuggestionDTOs(suggestions)}
+	return ctx.JSON(http.StatusOK, suggestionArrDTO)
+}
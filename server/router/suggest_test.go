@@ -0,0 +1,68 @@
+package router_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"wilin.info/api/server/router"
+)
+
+func TestSuggestionRoutes(t *testing.T) {
+	forEachStore(t, testSuggestionRoutes)
+}
+
+func testSuggestionRoutes(t *testing.T, s *testServer) {
+	s.addKalan(t, "wilin", "word")
+	s.addKalan(t, "wilinka", "dictionary")
+	s.addKalan(t, "kán", "to eat")
+
+	rec := s.request(t, http.MethodGet, "/kalan/paginated?search=wilim", nil, "")
+	found := decode[router.KalanArrayDTO](t, rec)
+	if found.KalanCount != 0 || len(found.Suggestions) < 1 {
+		t.Fatalf("GET /kalan/paginated?search=wilim = %+v, want suggestions and no words", found)
+	}
+	if found.Suggestions[0].Text != "wilin" || found.Suggestions[0].Distance != 1 || found.Suggestions[0].KalanID != 1 {
+		t.Errorf("first suggestion = %+v, want the entry wilin", found.Suggestions[0])
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/paginated?search=wilin", nil, "")
+	found = decode[router.KalanArrayDTO](t, rec)
+	if len(found.Suggestions) != 0 {
+		t.Errorf("search with results has suggestions: %+v", found.Suggestions)
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/suggest?q=Wil&limit=1", nil, "")
+	suggested := decode[router.SuggestionArrDTO](t, rec)
+	if len(suggested.Suggestions) != 1 || suggested.Suggestions[0].Text != "wilin" {
+		t.Errorf("GET /kalan/suggest?q=Wil&limit=1 = %+v, want wilin", suggested)
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/suggest?q=eet", nil, "")
+	suggested = decode[router.SuggestionArrDTO](t, rec)
+	if len(suggested.Suggestions) != 1 || suggested.Suggestions[0].Field != "gloss" {
+		t.Errorf("GET /kalan/suggest?q=eet = %+v, want the gloss word eat", suggested)
+	}
+
+	// q is said as k, but c is said as t͡ʃ
+	rec = s.request(t, http.MethodGet, "/kalan/suggest?q=qan", nil, "")
+	suggested = decode[router.SuggestionArrDTO](t, rec)
+	if len(suggested.Suggestions) < 1 || suggested.Suggestions[0].Text != "kán" || suggested.Suggestions[0].Distance != 0 {
+		t.Errorf("GET /kalan/suggest?q=qan = %+v, want kán as it sounds the same", suggested)
+	}
+
+	rec = s.request(t, http.MethodGet, "/kalan/suggest?q=can", nil, "")
+	suggested = decode[router.SuggestionArrDTO](t, rec)
+	if len(suggested.Suggestions) < 1 || suggested.Suggestions[0].Text != "kán" || suggested.Suggestions[0].Distance != 1 {
+		t.Errorf("GET /kalan/suggest?q=can = %+v, want kán one edit away", suggested)
+	}
+
+	routeValues := []RouteValue{
+		{"suggest nothing", http.MethodGet, "/kalan/suggest", nil, "", http.StatusOK},
+		{"suggest too many", http.MethodGet, "/kalan/suggest?q=wil&limit=100", nil, "", http.StatusBadRequest},
+		{"suggest invalid limit", http.MethodGet, "/kalan/suggest?q=wil&limit=abc", nil, "", http.StatusBadRequest},
+		{"suggest longest text", http.MethodGet, "/kalan/suggest?q=" + strings.Repeat("á", router.MAX_SUGGESTION_QUERY_LENGTH), nil, "", http.StatusOK},
+		{"suggest too long text", http.MethodGet, "/kalan/suggest?q=" + strings.Repeat("a", router.MAX_SUGGESTION_QUERY_LENGTH+1), nil, "", http.StatusBadRequest},
+	}
+	runRoutes(t, s, routeValues)
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"wilin.info/api/database/verification"
+	"wilin.info/api/server/services"
+)
+
+var TIME_TO_VERIFY_EXPIRE = time.Hour * 24
+
+var ErrAlreadyVerified = errors.New("email already verified")
+
+type VerificationIDParam struct {
+	ID string `param:"token"`
+}
+
+type VerificationMailData struct {
+	Username    string
+	URL         string
+	ExpireHours int
+}
+
+// sendVerification replaces any pending verification of the
+// user with a new one and emails its link to the user
+func (r *Router) sendVerification(ctx context.Context, userID int32, email string, username string) error {
+	verificationID, err := gonanoid.Generate(ID_ALPHABET, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.verificationQueries.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	createParams := verification.CreateParams{
+		ID:        verificationID,
+		UserID:    userID,
+		ExpiredAt: time.Now().Add(TIME_TO_VERIFY_EXPIRE),
+	}
+	_, err = r.verificationQueries.Create(ctx, createParams)
+	if err != nil {
+		return err
+	}
+
+	mailData := VerificationMailData{
+		Username:    username,
+		URL:         fmt.Sprintf("%s/verify/%s", services.GetSiteURL(), verificationID),
+		ExpireHours: int(TIME_TO_VERIFY_EXPIRE.Hours()),
+	}
+	msg, err := services.RenderMessage("verification", email, "Verify your wilin account", mailData)
+	if err != nil {
+		return err
+	}
+
+	return r.mailer.Send(ctx, msg)
+}
+
+func (r *Router) ResendVerification(ctx echo.Context) error {
+	userID, ok := ctx.Get("userID").(int)
+	if !ok {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	user, err := r.userQueries.ReadUserByID(ctx.Request().Context(), int32(userID))
+	if err != nil {
+		return serverError(ctx, err, ServerError)
+	}
+
+	if user.Verified {
+		errJSON := NewErrorJson(ErrAlreadyVerified.Error())
+		return ctx.JSON(http.StatusConflict, errJSON)
+	}
+
+	err = r.sendVerification(ctx.Request().Context(), user.ID, user.Email, user.Username)
+	if err != nil {
+		ctx.Logger().Errorf("could not send verification mail: %v\n", err)
+		return serverError(ctx, err, ServerError)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (r *Router) VerifyEmail(ctx echo.Context) error {
+	params := VerificationIDParam{}
+	err := ctx.Bind(&params)
+	if err != nil {
+		errJSON := NewErrorJson(InvalidForm)
+		return ctx.JSON(http.StatusBadRequest, errJSON)
+	}
+
+	v, err := r.verificationQueries.ReadByID(ctx.Request().Context(), params.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			errJSON := NewErrorJson("invalid verification token")
+			return ctx.JSON(http.StatusNotFound, errJSON)
+		}
+
+		ctx.Logger().Errorf("could not find verification item: %v\n", err)
+		return serverError(ctx, err, ServerError)
+	}
+
+	if isExpired(&v.ExpiredAt) {
+		ctx.Logger().Infof("verification token is expired. deleting %v...\n", v.ID)
+		_, _ = r.verificationQueries.DeleteByID(ctx.Request().Context(), v.ID)
+		errJSON := NewErrorJson("invalid verification token")
+		return ctx.JSON(http.StatusNotFound, errJSON)
+	}
+
+	_, err = r.userQueries.VerifyUser(ctx.Request().Context(), v.UserID)
+	if err != nil {
+		return serverError(ctx, err, ServerError)
+	}
+
+	// the token is single use
+	_, err = r.verificationQueries.DeleteByUserID(ctx.Request().Context(), v.UserID)
+	if err != nil {
+		ctx.Logger().Errorf("failed to delete verification items: %v\n", err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,616 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"wilin.info/api/database/kalan"
+)
+
+// A filter narrows a search down with a small query language:
+//
+//	kan                  any field has the word kan
+//	gloss:eat            the gloss has the word eat
+//	gloss:"to eat"       the gloss has the words to eat in a row
+//	entry:ka*            the entry has a word starting with ka
+//	entry:^ka*           the entry starts with ka
+//	entry:^kan$          the entry is exactly kan
+//	-notes:archaic       the notes do not have the word archaic
+//	pos:verb OR pos:noun either one matches
+//	(a OR b) c           terms next to each other must all match
+//
+// AND, OR and NOT must be written in capitals, and AND binds
+// tighter than OR. Words of a field are split at spaces and at
+// WORD_SEPARATORS.
+//
+// A filter is run by the database as a parameterized condition on
+// the kalan table, so values ignore case and diacritics as far as
+// the collation of the database does. Match follows MySQL, which
+// ignores both.
+type Filter interface {
+	// Match reports whether k meets the filter
+	Match(k kalan.Kalan) bool
+	// SQL returns the filter as a condition on the columns of kalan,
+	// with a ? in place of every value in args. Nothing written in
+	// the filter ever becomes part of the condition itself
+	SQL() (string, []any)
+}
+
+const (
+	MAX_FILTER_LENGTH = 500
+	MAX_FILTER_DEPTH  = 20
+	MAX_FILTER_TERMS  = 20
+)
+
+// WORD_SEPARATORS split the words of a field along with spaces
+const WORD_SEPARATORS = ",;.:!?()\"\t\n"
+
+// LIKE_ESCAPE escapes the wildcards of LIKE in patterns. A backslash
+// is not used since MySQL and SQLite read it differently in literals
+const LIKE_ESCAPE = '!'
+
+// ParseError is returned for malformed filters. Position counts
+// the characters of the filter from 1
+type ParseError struct {
+	Position int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid query at position %v: %v", e.Position, e.Message)
+}
+
+var filterFields = map[string]Fields{
+	"entry": FIELD_ENTRY,
+	"pos":   FIELD_POS,
+	"gloss": FIELD_GLOSS,
+	"notes": FIELD_NOTES,
+}
+
+var fieldColumns = map[Fields]string{
+	FIELD_ENTRY: "entry",
+	FIELD_POS:   "pos",
+	FIELD_GLOSS: "gloss",
+	FIELD_NOTES: "notes",
+}
+
+type andFilter struct {
+	left  Filter
+	right Filter
+}
+
+func (f andFilter) Match(k kalan.Kalan) bool {
+	return f.left.Match(k) && f.right.Match(k)
+}
+
+func (f andFilter) SQL() (string, []any) {
+	left, leftArgs := f.left.SQL()
+	right, rightArgs := f.right.SQL()
+	return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+}
+
+type orFilter struct {
+	left  Filter
+	right Filter
+}
+
+func (f orFilter) Match(k kalan.Kalan) bool {
+	return f.left.Match(k) || f.right.Match(k)
+}
+
+func (f orFilter) SQL() (string, []any) {
+	left, leftArgs := f.left.SQL()
+	right, rightArgs := f.right.SQL()
+	return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f notFilter) Match(k kalan.Kalan) bool {
+	return !f.filter.Match(k)
+}
+
+func (f notFilter) SQL() (string, []any) {
+	condition, args := f.filter.SQL()
+	return "(NOT " + condition + ")", args
+}
+
+// termFilter matches a field that is like one of patterns,
+// the way the LIKE operator of SQL does
+type termFilter struct {
+	fields   Fields
+	patterns []string
+}
+
+func (f termFilter) Match(k kalan.Kalan) bool {
+	for _, field := range fieldOrder {
+		if f.fields&field.field == 0 {
+			continue
+		}
+		text := splitWords(Fold(fieldText(k, field.field)))
+		for _, pattern := range f.patterns {
+			if like(text, Fold(pattern)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f termFilter) SQL() (string, []any) {
+	conditions := []string{}
+	args := []any{}
+	for _, field := range fieldOrder {
+		if f.fields&field.field == 0 {
+			continue
+		}
+		column, separators := wordsSQL(fieldColumns[field.field])
+		for _, pattern := range f.patterns {
+			conditions = append(conditions, fmt.Sprintf("%v LIKE ? ESCAPE '%c'", column, LIKE_ESCAPE))
+			args = append(args, separators...)
+			args = append(args, pattern)
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(WORD_SEPARATORS, r)
+}
+
+// splitWords replaces every word separator of text with a space
+func splitWords(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isWordSeparator(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// wordsSQL is the SQL for the text of column with every one of
+// WORD_SEPARATORS replaced by a space, the way splitWords does it.
+// The separators are passed as args since drivers that fill in
+// placeholders themselves would take a quoted ? for one
+func wordsSQL(column string) (string, []any) {
+	expr := column
+	args := []any{}
+	for _, separator := range WORD_SEPARATORS {
+		expr = fmt.Sprintf("REPLACE(%v, ?, ' ')", expr)
+		args = append(args, string(separator))
+	}
+	return expr, args
+}
+
+// like reports whether text matches pattern, in which % stands
+// for any run of characters and LIKE_ESCAPE makes the character
+// after it stand for itself
+func like(text string, pattern string) bool {
+	t, p := []rune(text), []rune(pattern)
+	ti, pi := 0, 0
+	// where the last % was seen and how much text it had taken
+	starP, starT := -1, 0
+	for ti < len(t) {
+		switch {
+		case pi < len(p) && p[pi] == '%':
+			starP, starT = pi, ti
+			pi++
+		case pi+1 < len(p) && p[pi] == LIKE_ESCAPE && p[pi+1] == t[ti]:
+			pi += 2
+			ti++
+		case pi < len(p) && p[pi] != LIKE_ESCAPE && p[pi] == t[ti]:
+			pi++
+			ti++
+		case starP >= 0:
+			starT++
+			pi, ti = starP+1, starT
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// newPatterns turns the value of a term into LIKE patterns, one of
+// which a field must match. Wildcards and the escape character in
+// the value are escaped, so only * ^ and $ have a meaning of their own
+func newPatterns(value string, phrase bool) ([]string, error) {
+	anchorStart := !phrase && strings.HasPrefix(value, "^")
+	anchorEnd := !phrase && strings.HasSuffix(value, "$") && len(value) > 1
+	if anchorStart {
+		value = value[1:]
+	}
+	if anchorEnd {
+		value = value[:len(value)-1]
+	}
+
+	words := strings.FieldsFunc(value, isWordSeparator)
+	if len(words) < 1 || strings.Trim(strings.Join(words, ""), "*") == "" {
+		return nil, fmt.Errorf("%q has nothing to search for", value)
+	}
+
+	for i, word := range words {
+		var b strings.Builder
+		for _, r := range word {
+			switch r {
+			case '*':
+				b.WriteRune('%')
+			case '%', '_', LIKE_ESCAPE:
+				b.WriteRune(LIKE_ESCAPE)
+				b.WriteRune(r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		words[i] = b.String()
+	}
+	body := strings.Join(words, " ")
+
+	// the words must start and end at the edges of the
+	// field or next to a space, unless they are anchored
+	switch {
+	case anchorStart && anchorEnd:
+		return []string{body}, nil
+	case anchorStart:
+		return []string{body, body + " %"}, nil
+	case anchorEnd:
+		return []string{body, "% " + body}, nil
+	default:
+		return []string{body, body + " %", "% " + body, "% " + body + " %"}, nil
+	}
+}
+
+type tokenKind int
+
+const (
+	TOKEN_TERM tokenKind = iota
+	TOKEN_AND
+	TOKEN_OR
+	TOKEN_NOT
+	TOKEN_LPAREN
+	TOKEN_RPAREN
+)
+
+type token struct {
+	kind     tokenKind
+	position int
+	field    string
+	value    string
+	phrase   bool
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case TOKEN_AND:
+		return "AND"
+	case TOKEN_OR:
+		return "OR"
+	case TOKEN_NOT:
+		return "-"
+	case TOKEN_LPAREN:
+		return "("
+	case TOKEN_RPAREN:
+		return ")"
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits a filter into tokens, keeping track of
+// the character position that each one starts at
+type lexer struct {
+	input    string
+	offset   int
+	position int
+}
+
+func (l *lexer) peek() (rune, bool) {
+	if l.offset >= len(l.input) {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.offset:])
+	return r, true
+}
+
+func (l *lexer) next() rune {
+	r, size := utf8.DecodeRuneInString(l.input[l.offset:])
+	l.offset += size
+	l.position++
+	return r
+}
+
+func isWordEnd(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+// readPhrase reads a quoted phrase, the opening quote of
+// which starts at the given position
+func (l *lexer) readPhrase(position int) (string, error) {
+	l.next()
+
+	var b strings.Builder
+	for {
+		r, ok := l.peek()
+		if !ok {
+			return "", &ParseError{Position: position, Message: "unterminated quote"}
+		}
+		l.next()
+		if r == '"' {
+			return b.String(), nil
+		}
+		b.WriteRune(r)
+	}
+}
+
+func (l *lexer) tokens() ([]token, error) {
+	tokens := []token{}
+	for {
+		r, ok := l.peek()
+		if !ok {
+			return tokens, nil
+		}
+		position := l.position + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			l.next()
+		case r == '(':
+			l.next()
+			tokens = append(tokens, token{kind: TOKEN_LPAREN, position: position})
+		case r == ')':
+			l.next()
+			tokens = append(tokens, token{kind: TOKEN_RPAREN, position: position})
+		case r == '-':
+			l.next()
+			next, ok := l.peek()
+			if !ok || unicode.IsSpace(next) {
+				return nil, &ParseError{Position: position, Message: "expected a term after -"}
+			}
+			tokens = append(tokens, token{kind: TOKEN_NOT, position: position})
+		case r == '"':
+			phrase, err := l.readPhrase(position)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: TOKEN_TERM, position: position, value: phrase, phrase: true})
+		default:
+			t, err := l.readTerm(position)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+		}
+	}
+}
+
+// readTerm reads a word, which may be an operator or
+// a field name followed by a colon and a value
+func (l *lexer) readTerm(position int) (token, error) {
+	start := l.offset
+	for {
+		r, ok := l.peek()
+		if !ok || isWordEnd(r) {
+			break
+		}
+		l.next()
+		if r == ':' {
+			break
+		}
+	}
+	word := l.input[start:l.offset]
+
+	switch word {
+	case "AND":
+		return token{kind: TOKEN_AND, position: position}, nil
+	case "OR":
+		return token{kind: TOKEN_OR, position: position}, nil
+	case "NOT":
+		return token{kind: TOKEN_NOT, position: position}, nil
+	}
+
+	field, ok := strings.CutSuffix(word, ":")
+	if !ok {
+		return token{kind: TOKEN_TERM, position: position, value: word}, nil
+	}
+
+	_, ok = filterFields[field]
+	if !ok {
+		return token{}, &ParseError{Position: position, Message: fmt.Sprintf("unknown field %q", field)}
+	}
+
+	r, ok := l.peek()
+	if !ok || isWordEnd(r) && r != '"' {
+		return token{}, &ParseError{Position: position, Message: fmt.Sprintf("expected a value after %v:", field)}
+	}
+
+	if r == '"' {
+		phrase, err := l.readPhrase(l.position + 1)
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: TOKEN_TERM, position: position, field: field, value: phrase, phrase: true}, nil
+	}
+
+	valueStart := l.offset
+	for {
+		r, ok := l.peek()
+		if !ok || isWordEnd(r) {
+			break
+		}
+		l.next()
+	}
+	value := l.input[valueStart:l.offset]
+	return token{kind: TOKEN_TERM, position: position, field: field, value: value}, nil
+}
+
+type parser struct {
+	tokens []token
+	index  int
+	length int
+	// depth is how many negations and parentheses
+	// the token being parsed is inside of
+	depth int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.index >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.index], true
+}
+
+func (p *parser) unexpected() error {
+	t, ok := p.peek()
+	if !ok {
+		return &ParseError{Position: p.length + 1, Message: "unexpected end of query"}
+	}
+	return &ParseError{Position: t.position, Message: fmt.Sprintf("unexpected %v", t)}
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != TOKEN_OR {
+			return left, nil
+		}
+		p.index++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == TOKEN_OR || t.kind == TOKEN_RPAREN {
+			return left, nil
+		}
+		if t.kind == TOKEN_AND {
+			p.index++
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Filter, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, p.unexpected()
+	}
+
+	if (t.kind == TOKEN_NOT || t.kind == TOKEN_LPAREN) && p.depth >= MAX_FILTER_DEPTH {
+		errMsg := fmt.Sprintf("query nests more than %v negations and parentheses", MAX_FILTER_DEPTH)
+		return nil, &ParseError{Position: t.position, Message: errMsg}
+	}
+
+	switch t.kind {
+	case TOKEN_NOT:
+		p.index++
+		p.depth++
+		filter, err := p.parseUnary()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{filter: filter}, nil
+	case TOKEN_LPAREN:
+		p.index++
+		p.depth++
+		filter, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != TOKEN_RPAREN {
+			return nil, &ParseError{Position: t.position, Message: "missing closing parenthesis"}
+		}
+		p.index++
+		return filter, nil
+	case TOKEN_TERM:
+		p.index++
+		return newTermFilter(t)
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func newTermFilter(t token) (Filter, error) {
+	fields := ALL_FIELDS
+	if t.field != "" {
+		fields = filterFields[t.field]
+	}
+
+	patterns, err := newPatterns(t.value, t.phrase)
+	if err != nil {
+		return nil, &ParseError{Position: t.position, Message: err.Error()}
+	}
+	return termFilter{fields: fields, patterns: patterns}, nil
+}
+
+// ParseFilter reads a filter written in the query language.
+// An empty filter is nil and matches every kalan
+func ParseFilter(input string) (Filter, error) {
+	length := utf8.RuneCountInString(input)
+	if length > MAX_FILTER_LENGTH {
+		errMsg := fmt.Sprintf("query is longer than %v characters", MAX_FILTER_LENGTH)
+		return nil, &ParseError{Position: MAX_FILTER_LENGTH + 1, Message: errMsg}
+	}
+
+	l := lexer{input: input}
+	tokens, err := l.tokens()
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 1 {
+		return nil, nil
+	}
+
+	terms := 0
+	for _, t := range tokens {
+		if t.kind != TOKEN_TERM {
+			continue
+		}
+		terms++
+		if terms > MAX_FILTER_TERMS {
+			errMsg := fmt.Sprintf("query has more than %v terms", MAX_FILTER_TERMS)
+			return nil, &ParseError{Position: t.position, Message: errMsg}
+		}
+	}
+
+	p := parser{tokens: tokens, length: length}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.index < len(p.tokens) {
+		return nil, p.unexpected()
+	}
+	return filter, nil
+}
@@ -0,0 +1,127 @@
+package search_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"wilin.info/api/server/search"
+)
+
+type FilterValue struct {
+	filter   string
+	expected []int32
+}
+
+// matchIDs returns the ids of the test kalans that filter matches
+func matchIDs(filter search.Filter) []int32 {
+	ids := []int32{}
+	for _, k := range testKalans {
+		if filter == nil || filter.Match(k) {
+			ids = append(ids, k.ID)
+		}
+	}
+	return ids
+}
+
+func TestFilter(t *testing.T) {
+	filterValues := []FilterValue{
+		{"", []int32{1, 2, 3, 4, 5}},
+		{"wilin", []int32{1, 2}},
+		{"entry:wilin", []int32{1}},
+		{"pos:verb", []int32{3}},
+		{"gloss:eat", []int32{3}},
+		{`gloss:"to eat"`, []int32{3}},
+		{`gloss:"consume to"`, []int32{}},
+		{`"is eaten"`, []int32{4}},
+		{"pos:noun -notes:archaic", []int32{1, 2, 4}},
+		{"pos:noun NOT notes:archaic", []int32{1, 2, 4}},
+		{"pos:noun AND notes:archaic", []int32{5}},
+		{"entry:ka*", []int32{3, 4}},
+		{"entry:*ka", []int32{2}},
+		{"entry:^wil*", []int32{1, 2}},
+		{"entry:^kan$", []int32{3}},
+		{"entry:^k*o$", []int32{4}},
+		{"gloss:^to*", []int32{3}},
+		{"entry:SOWE", []int32{5}},
+		{"pos:verb OR gloss:food", []int32{3, 4}},
+		{"pos:noun gloss:food OR pos:verb", []int32{3, 4}},
+		{"pos:noun (gloss:food OR gloss:word)", []int32{1, 4}},
+		{"-(pos:noun)", []int32{3}},
+		{"gloss:a.*", []int32{}},
+	}
+
+	for _, test := range filterValues {
+		filter, err := search.ParseFilter(test.filter)
+		if err != nil {
+			t.Errorf("%q: could not parse: %v", test.filter, err)
+			continue
+		}
+
+		ids := matchIDs(filter)
+		if !slices.Equal(ids, test.expected) {
+			t.Errorf("%q: got %v, want %v", test.filter, ids, test.expected)
+		}
+	}
+}
+
+func TestFilterSQL(t *testing.T) {
+	filter, err := search.ParseFilter(`pos:verb -entry:"50%_off'"`)
+	if err != nil {
+		t.Fatalf("could not parse: %v", err)
+	}
+
+	condition, args := filter.SQL()
+	if strings.Contains(condition, "verb") || strings.Contains(condition, "off") {
+		t.Errorf("values were written into the condition: %v", condition)
+	}
+	if strings.Count(condition, "?") != len(args) {
+		t.Errorf("condition has %v placeholders for %v args", strings.Count(condition, "?"), len(args))
+	}
+
+	for _, expected := range []string{"verb", "verb %", "% verb", "% verb %", "50!%!_off'"} {
+		if !slices.Contains(args, any(expected)) {
+			t.Errorf("args %q do not have %q", args, expected)
+		}
+	}
+}
+
+type FilterErrorValue struct {
+	filter   string
+	position int
+}
+
+func TestFilterErrors(t *testing.T) {
+	filterErrorValues := []FilterErrorValue{
+		{"color:red", 1},
+		{"pos:verb color:red", 10},
+		{`gloss:"to eat`, 7},
+		{`"to eat`, 1},
+		{"entry:", 1},
+		{"pos:verb -", 10},
+		{"(pos:verb", 1},
+		{"pos:verb)", 9},
+		{"pos:verb OR", 12},
+		{"OR pos:verb", 1},
+		{"entry:^*", 1},
+		{"wílín AND )", 11},
+		{strings.Repeat("(", search.MAX_FILTER_DEPTH+1) + "kan", search.MAX_FILTER_DEPTH + 1},
+		{strings.Repeat("-", search.MAX_FILTER_DEPTH+1) + "kan", search.MAX_FILTER_DEPTH + 1},
+		{strings.Repeat("a ", search.MAX_FILTER_TERMS+1), search.MAX_FILTER_TERMS*2 + 1},
+		{strings.Repeat("a", search.MAX_FILTER_LENGTH+1), search.MAX_FILTER_LENGTH + 1},
+	}
+
+	for _, test := range filterErrorValues {
+		_, err := search.ParseFilter(test.filter)
+
+		var parseErr *search.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got error %v, want a parse error", test.filter, err)
+			continue
+		}
+		if parseErr.Position != test.position {
+			t.Errorf("%q: got position %v, want %v (%v)", test.filter, parseErr.Position, test.position, parseErr)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Package search keeps an inverted index of the dictionary in
+// memory so that words can be looked up by whole words or word
+// prefixes and ranked by how well they match.
+//
+// Text is folded before it is indexed or searched, so that case
+// and diacritics are ignored: "Kalán" and "kalan" are the same word.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lowercases s and strips the diacritics from its letters
+func Fold(s string) string {
+	// transformers keep state between calls, so a new chain
+	// is made every time to stay safe for concurrent use
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(folder, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// Tokenize folds s and splits it into the words it is made of.
+// Anything that is not a letter or a digit separates words
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
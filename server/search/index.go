@@ -0,0 +1,422 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"wilin.info/api/database/kalan"
+)
+
+type Mode string
+
+const (
+	MODE_CONTAINS Mode = "contains"
+	MODE_WORD     Mode = "word"
+	MODE_PREFIX   Mode = "prefix"
+)
+
+var ErrUnknownMode = errors.New("unknown search mode")
+
+// ParseMode reads the mode of a search query.
+// Searches match substrings if no mode is given
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case "":
+		return MODE_CONTAINS, nil
+	case MODE_CONTAINS, MODE_WORD, MODE_PREFIX:
+		return Mode(mode), nil
+	default:
+		return "", ErrUnknownMode
+	}
+}
+
+// Fields is a set of the kalan columns a search looks in
+type Fields uint8
+
+const (
+	FIELD_ENTRY Fields = 1 << iota
+	FIELD_POS
+	FIELD_GLOSS
+	FIELD_NOTES
+
+	ALL_FIELDS = FIELD_ENTRY | FIELD_POS | FIELD_GLOSS | FIELD_NOTES
+)
+
+// fieldOrder lists every field with the weight a match in it adds
+// to the score of a result, so that a word matching in its entry
+// ranks above one that only mentions the search in its notes
+var fieldOrder = []struct {
+	field  Fields
+	weight int
+}{
+	{FIELD_ENTRY, 8},
+	{FIELD_GLOSS, 4},
+	{FIELD_POS, 2},
+	{FIELD_NOTES, 1},
+}
+
+// An entry that is exactly the search, or starts with it,
+// ranks above any other kind of match
+const (
+	EXACT_ENTRY_SCORE  = 1000
+	PREFIX_ENTRY_SCORE = 100
+)
+
+func fieldText(k kalan.Kalan, field Fields) string {
+	switch field {
+	case FIELD_ENTRY:
+		return k.Entry
+	case FIELD_POS:
+		return k.Pos
+	case FIELD_GLOSS:
+		return k.Gloss
+	case FIELD_NOTES:
+		return k.Notes
+	default:
+		return ""
+	}
+}
+
+type Query struct {
+	Text   string
+	Mode   Mode
+	Fields Fields
+}
+
+type Result struct {
+	Kalan kalan.Kalan
+	Score int
+}
+
+type document struct {
+	kalan  kalan.Kalan
+	folded map[Fields]string
+	terms  map[string]Fields
+	// sound is how the entry sounds, if the index was given a
+	// way to tell
+	sound string
+}
+
+func newDocument(k kalan.Kalan) *document {
+	doc := &document{
+		kalan:  k,
+		folded: map[Fields]string{},
+		terms:  map[string]Fields{},
+	}
+	for _, f := range fieldOrder {
+		text := fieldText(k, f.field)
+		doc.folded[f.field] = Fold(text)
+		for _, term := range Tokenize(text) {
+			doc.terms[term] |= f.field
+		}
+	}
+	return doc
+}
+
+// Index is an inverted index of every kalan. It is loaded from
+// the database the first time it is used and then kept up to
+// date by calling Put and Delete whenever a kalan changes
+type Index struct {
+	loadMu sync.Mutex
+
+	mu       sync.RWMutex
+	loaded   bool
+	docs     map[int32]*document
+	postings map[string]map[int32]Fields
+	// terms holds the keys of postings in order so that the
+	// terms starting with a prefix can be found by binary search
+	terms []string
+	sound func(text string) string
+}
+
+func New() *Index {
+	return &Index{
+		docs:     map[int32]*document{},
+		postings: map[string]map[int32]Fields{},
+	}
+}
+
+// SetSound makes Suggest also compare entries by how they sound,
+// as returned by sound, so that words spelt differently but said
+// the same way are suggested. It is called before the index is
+// loaded, since the sound of an entry is kept when it is put
+func (idx *Index) SetSound(sound func(text string) string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.sound = sound
+}
+
+// Load replaces the contents of the index with kalans
+func (idx *Index) Load(kalans []kalan.Kalan) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loaded = false
+	idx.docs = map[int32]*document{}
+	idx.postings = map[string]map[int32]Fields{}
+	for _, k := range kalans {
+		idx.put(k)
+	}
+	idx.terms = slices.Sorted(maps.Keys(idx.postings))
+	idx.loaded = true
+}
+
+// Ensure loads the index with the kalans returned by load
+// unless it has already been loaded
+func (idx *Index) Ensure(ctx context.Context, load func(ctx context.Context) ([]kalan.Kalan, error)) error {
+	idx.loadMu.Lock()
+	defer idx.loadMu.Unlock()
+
+	idx.mu.RLock()
+	loaded := idx.loaded
+	idx.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	kalans, err := load(ctx)
+	if err != nil {
+		return err
+	}
+	idx.Load(kalans)
+	return nil
+}
+
+// Invalidate empties the index so that it is loaded again the
+// next time it is used. It is called when the index may no longer
+// match the database, such as when a changed kalan cannot be read
+func (idx *Index) Invalidate() {
+	idx.loadMu.Lock()
+	defer idx.loadMu.Unlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loaded = false
+	idx.docs = map[int32]*document{}
+	idx.postings = map[string]map[int32]Fields{}
+	idx.terms = nil
+}
+
+// Put adds k to the index, replacing the kalan with the same id.
+// Nothing is done if the index has not been loaded yet, since it
+// will read k from the database when it is. Put waits for a load
+// that is under way, which may have read the kalans before k was
+// stored
+func (idx *Index) Put(k kalan.Kalan) {
+	idx.loadMu.Lock()
+	defer idx.loadMu.Unlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.loaded {
+		idx.put(k)
+	}
+}
+
+// Delete removes the kalan with the given id from the index.
+// Like Put, it waits for a load that is under way
+func (idx *Index) Delete(id int32) {
+	idx.loadMu.Lock()
+	defer idx.loadMu.Unlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+func (idx *Index) put(k kalan.Kalan) {
+	idx.remove(k.ID)
+
+	doc := newDocument(k)
+	if idx.sound != nil {
+		doc.sound = idx.sound(k.Entry)
+	}
+	idx.docs[k.ID] = doc
+	for term, fields := range doc.terms {
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = map[int32]Fields{}
+			idx.postings[term] = posting
+			// a loading index sorts its terms once it is done
+			if idx.loaded {
+				i, _ := slices.BinarySearch(idx.terms, term)
+				idx.terms = slices.Insert(idx.terms, i, term)
+			}
+		}
+		posting[k.ID] = fields
+	}
+}
+
+func (idx *Index) remove(id int32) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	delete(idx.docs, id)
+	for term := range doc.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			i, found := slices.BinarySearch(idx.terms, term)
+			if found {
+				idx.terms = slices.Delete(idx.terms, i, i+1)
+			}
+		}
+	}
+}
+
+// Search returns every kalan that matches q ordered by id.
+// Nothing matches if q has no fields to look in
+func (idx *Index) Search(q Query) []Result {
+	if q.Fields == 0 {
+		return []Result{}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var results []Result
+	switch q.Mode {
+	case MODE_WORD, MODE_PREFIX:
+		results = idx.searchTerms(q)
+	default:
+		results = idx.searchContains(q)
+	}
+
+	folded := strings.TrimSpace(Fold(q.Text))
+	if folded != "" && q.Fields&FIELD_ENTRY != 0 {
+		for i := range results {
+			entry := Fold(results[i].Kalan.Entry)
+			if entry == folded {
+				results[i].Score += EXACT_ENTRY_SCORE
+			} else if strings.HasPrefix(entry, folded) {
+				results[i].Score += PREFIX_ENTRY_SCORE
+			}
+		}
+	}
+
+	slices.SortFunc(results, func(a Result, b Result) int {
+		return cmp.Compare(a.Kalan.ID, b.Kalan.ID)
+	})
+	return results
+}
+
+// searchContains finds the kalans with a field that
+// contains the whole search, like a LIKE query would
+func (idx *Index) searchContains(q Query) []Result {
+	folded := strings.TrimSpace(Fold(q.Text))
+
+	results := []Result{}
+	for _, doc := range idx.docs {
+		score := 0
+		matched := false
+		for _, f := range fieldOrder {
+			if q.Fields&f.field == 0 {
+				continue
+			}
+			if strings.Contains(doc.folded[f.field], folded) {
+				matched = true
+				score += f.weight
+			}
+		}
+
+		if matched {
+			results = append(results, Result{Kalan: doc.kalan, Score: score})
+		}
+	}
+	return results
+}
+
+// searchTerms finds the kalans that have every word of the search
+// in one of their fields. In prefix mode a word of the search only
+// has to be the start of a word of the kalan
+func (idx *Index) searchTerms(q Query) []Result {
+	terms := Tokenize(q.Text)
+	if len(terms) < 1 {
+		results := []Result{}
+		for _, doc := range idx.docs {
+			results = append(results, Result{Kalan: doc.kalan})
+		}
+		return results
+	}
+
+	var scores map[int32]int
+	for _, term := range terms {
+		termScores := map[int32]int{}
+		for _, match := range idx.lookup(term, q.Mode) {
+			for id, fields := range idx.postings[match] {
+				fields &= q.Fields
+				if fields == 0 {
+					continue
+				}
+
+				// whole words count for more than prefixes
+				multiplier := 1
+				if match == term {
+					multiplier = 2
+				}
+				termScores[id] = max(termScores[id], fieldScore(fields)*multiplier)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			termScore, ok := termScores[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += termScore
+		}
+	}
+
+	results := []Result{}
+	for id, score := range scores {
+		results = append(results, Result{Kalan: idx.docs[id].kalan, Score: score})
+	}
+	return results
+}
+
+// lookup returns the indexed terms that match term
+func (idx *Index) lookup(term string, mode Mode) []string {
+	if mode != MODE_PREFIX {
+		_, ok := idx.postings[term]
+		if !ok {
+			return nil
+		}
+		return []string{term}
+	}
+
+	var matches []string
+	start, _ := slices.BinarySearch(idx.terms, term)
+	for _, t := range idx.terms[start:] {
+		if !strings.HasPrefix(t, term) {
+			break
+		}
+		matches = append(matches, t)
+	}
+	return matches
+}
+
+func fieldScore(fields Fields) int {
+	score := 0
+	for _, f := range fieldOrder {
+		if fields&f.field != 0 {
+			score += f.weight
+		}
+	}
+	return score
+}
@@ -0,0 +1,227 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"wilin.info/api/database/kalan"
+	"wilin.info/api/server/search"
+)
+
+type FoldValue struct {
+	text     string
+	expected string
+}
+
+func TestFold(t *testing.T) {
+	foldValues := []FoldValue{
+		{"kalan", "kalan"},
+		{"KALAN", "kalan"},
+		{"Kalán", "kalan"},
+		{"wílín", "wilin"},
+		{"naïve café", "naive cafe"},
+		{"", ""},
+	}
+
+	for _, test := range foldValues {
+		folded := search.Fold(test.text)
+		if folded != test.expected {
+			t.Errorf("Fold(%q) = %q, want %q", test.text, folded, test.expected)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := search.Tokenize("To eat, (food) DRÍNK-water")
+	expected := []string{"to", "eat", "food", "drink", "water"}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("got tokens %v, want %v", tokens, expected)
+	}
+}
+
+var testKalans = []kalan.Kalan{
+	{ID: 1, Entry: "wilin", Pos: "noun", Gloss: "word", Notes: ""},
+	{ID: 2, Entry: "wilinka", Pos: "noun", Gloss: "dictionary", Notes: "from wilin"},
+	{ID: 3, Entry: "kan", Pos: "verb", Gloss: "to eat, to consume", Notes: ""},
+	{ID: 4, Entry: "kanso", Pos: "noun", Gloss: "food", Notes: "what is eaten"},
+	{ID: 5, Entry: "sowé", Pos: "noun", Gloss: "animal", Notes: "archaic"},
+}
+
+func newIndex() *search.Index {
+	idx := search.New()
+	idx.Load(testKalans)
+	return idx
+}
+
+func resultIDs(results []search.Result) []int32 {
+	ids := []int32{}
+	for _, result := range results {
+		ids = append(ids, result.Kalan.ID)
+	}
+	return ids
+}
+
+type SearchValue struct {
+	name     string
+	query    search.Query
+	expected []int32
+}
+
+func TestSearch(t *testing.T) {
+	idx := newIndex()
+
+	searchValues := []SearchValue{
+		{"empty search matches everything", search.Query{Text: "", Mode: search.MODE_CONTAINS, Fields: search.ALL_FIELDS}, []int32{1, 2, 3, 4, 5}},
+		{"no fields match nothing", search.Query{Text: "wilin", Mode: search.MODE_CONTAINS}, []int32{}},
+		{"contains matches substrings", search.Query{Text: "ilin", Mode: search.MODE_CONTAINS, Fields: search.FIELD_ENTRY}, []int32{1, 2}},
+		{"contains ignores case and accents", search.Query{Text: "SOWE", Mode: search.MODE_CONTAINS, Fields: search.FIELD_ENTRY}, []int32{5}},
+		{"contains only looks in fields", search.Query{Text: "wilin", Mode: search.MODE_CONTAINS, Fields: search.FIELD_NOTES}, []int32{2}},
+		{"word matches whole words", search.Query{Text: "eat", Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}, []int32{3}},
+		{"word needs every word", search.Query{Text: "to consume", Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}, []int32{3}},
+		{"word does not match prefixes", search.Query{Text: "kan", Mode: search.MODE_WORD, Fields: search.FIELD_ENTRY}, []int32{3}},
+		{"word with a missing word", search.Query{Text: "to sleep", Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}, []int32{}},
+		{"prefix matches word starts", search.Query{Text: "kan", Mode: search.MODE_PREFIX, Fields: search.FIELD_ENTRY}, []int32{3, 4}},
+		{"prefix across fields", search.Query{Text: "ea", Mode: search.MODE_PREFIX, Fields: search.ALL_FIELDS}, []int32{3, 4}},
+		{"prefix ignores accents", search.Query{Text: "sowe", Mode: search.MODE_PREFIX, Fields: search.ALL_FIELDS}, []int32{5}},
+	}
+
+	for _, test := range searchValues {
+		ids := resultIDs(idx.Search(test.query))
+		if !slices.Equal(ids, test.expected) {
+			t.Errorf("%v: got %v, want %v", test.name, ids, test.expected)
+		}
+	}
+}
+
+func TestSearchScore(t *testing.T) {
+	idx := newIndex()
+
+	results := idx.Search(search.Query{Text: "wilin", Mode: search.MODE_PREFIX, Fields: search.ALL_FIELDS})
+	if len(results) != 2 {
+		t.Fatalf("got %v results, want 2", len(results))
+	}
+	if results[0].Score < search.EXACT_ENTRY_SCORE {
+		t.Errorf("exact entry match scored %v", results[0].Score)
+	}
+	if results[1].Score >= results[0].Score {
+		t.Errorf("prefix entry match scored %v, above the exact match at %v", results[1].Score, results[0].Score)
+	}
+
+	results = idx.Search(search.Query{Text: "eat", Mode: search.MODE_PREFIX, Fields: search.ALL_FIELDS})
+	if len(results) != 2 {
+		t.Fatalf("got %v results, want 2", len(results))
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("whole word in gloss scored %v, not above the prefix in notes at %v", results[0].Score, results[1].Score)
+	}
+}
+
+func TestPutAndDelete(t *testing.T) {
+	idx := newIndex()
+
+	idx.Put(kalan.Kalan{ID: 6, Entry: "kanta", Pos: "verb", Gloss: "to sing"})
+	idx.Put(kalan.Kalan{ID: 3, Entry: "kan", Pos: "verb", Gloss: "to devour"})
+	idx.Delete(4)
+
+	ids := resultIDs(idx.Search(search.Query{Text: "kan", Mode: search.MODE_PREFIX, Fields: search.FIELD_ENTRY}))
+	if !slices.Equal(ids, []int32{3, 6}) {
+		t.Errorf("got %v, want [3 6]", ids)
+	}
+
+	ids = resultIDs(idx.Search(search.Query{Text: "eat", Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}))
+	if len(ids) != 0 {
+		t.Errorf("old gloss of an updated kalan is still indexed: %v", ids)
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	idx := search.New()
+	ctx := context.Background()
+
+	// kalans put before the index is loaded are read by the load
+	idx.Put(kalan.Kalan{ID: 9, Entry: "lost"})
+
+	loads := 0
+	load := func(ctx context.Context) ([]kalan.Kalan, error) {
+		loads++
+		return testKalans, nil
+	}
+
+	for range 2 {
+		err := idx.Ensure(ctx, load)
+		if err != nil {
+			t.Fatalf("could not load index: %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("index was loaded %v times, want 1", loads)
+	}
+
+	ids := resultIDs(idx.Search(search.Query{Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}))
+	if !slices.Equal(ids, []int32{1, 2, 3, 4, 5}) {
+		t.Errorf("got %v, want every test kalan", ids)
+	}
+
+	idx.Invalidate()
+	errLoad := errors.New("load failed")
+	err := idx.Ensure(ctx, func(ctx context.Context) ([]kalan.Kalan, error) {
+		return nil, errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Errorf("got error %v, want %v", err, errLoad)
+	}
+
+	err = idx.Ensure(ctx, load)
+	if err != nil || loads != 2 {
+		t.Errorf("invalidated index was not loaded again: %v", err)
+	}
+}
+
+func TestPutDuringLoad(t *testing.T) {
+	idx := search.New()
+	ctx := context.Background()
+
+	// the load reads the kalans before the put is committed
+	loading := make(chan bool)
+	loaded := make(chan error)
+	go func() {
+		loaded <- idx.Ensure(ctx, func(ctx context.Context) ([]kalan.Kalan, error) {
+			loading <- true
+			<-loading
+			return testKalans, nil
+		})
+	}()
+
+	<-loading
+	put := make(chan bool)
+	go func() {
+		idx.Put(kalan.Kalan{ID: 9, Entry: "late"})
+		put <- true
+	}()
+	loading <- true
+
+	err := <-loaded
+	if err != nil {
+		t.Fatalf("could not load index: %v", err)
+	}
+	<-put
+
+	ids := resultIDs(idx.Search(search.Query{Text: "late", Mode: search.MODE_WORD, Fields: search.ALL_FIELDS}))
+	if !slices.Equal(ids, []int32{9}) {
+		t.Errorf("got %v, want the kalan put while the index was loading", ids)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := search.ParseMode("")
+	if err != nil || mode != search.MODE_CONTAINS {
+		t.Errorf("empty mode = %v %v, want %v", mode, err, search.MODE_CONTAINS)
+	}
+
+	_, err = search.ParseMode("fuzzy")
+	if !errors.Is(err, search.ErrUnknownMode) {
+		t.Errorf("got error %v, want %v", err, search.ErrUnknownMode)
+	}
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+const (
+	SUGGESTION_FIELD_ENTRY = "entry"
+	SUGGESTION_FIELD_GLOSS = "gloss"
+)
+
+type Suggestion struct {
+	Text  string
+	Field string
+	// KalanID is the kalan that an entry suggestion belongs to.
+	// It is 0 for gloss words, which many kalans may share
+	KalanID  int32
+	Distance int
+}
+
+// MaxDistance is how many edits two words may be apart, where
+// length is the length of the shorter one. Short words allow fewer
+// edits so that they are not close to everything
+func MaxDistance(length int) int {
+	switch {
+	case length <= 2:
+		return 0
+	case length <= 4:
+		return 1
+	case length <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Distance is the number of insertions, deletions, substitutions
+// and swaps of neighbouring letters it takes to turn a into b
+func Distance(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	// only the last three rows are needed to find swaps
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// Suggest returns up to limit entries and gloss words that are
+// close to text, closest first. Words that start with text are
+// always suggested so that the suggestions work for type-ahead
+func (idx *Index) Suggest(text string, limit int) []Suggestion {
+	folded := strings.TrimSpace(Fold(text))
+	suggestions := []Suggestion{}
+	if folded == "" || limit < 1 {
+		return suggestions
+	}
+
+	length := len([]rune(folded))
+	// candidate is the folded form of the suggested text
+	consider := func(candidate string, distance int, text string, field string, kalanID int32) {
+		maxDistance := MaxDistance(min(length, len([]rune(candidate))))
+		if distance > maxDistance && !strings.HasPrefix(candidate, folded) {
+			return
+		}
+		suggestions = append(suggestions, Suggestion{
+			Text:     text,
+			Field:    field,
+			KalanID:  kalanID,
+			Distance: distance,
+		})
+	}
+
+	idx.mu.RLock()
+	// entries are also compared by how they sound, gloss words
+	// are not written in the language and only by their spelling
+	sound := ""
+	if idx.sound != nil {
+		sound = idx.sound(text)
+	}
+	for _, doc := range idx.docs {
+		entry := doc.folded[FIELD_ENTRY]
+		distance := Distance(folded, entry)
+		if idx.sound != nil {
+			distance = min(distance, Distance(sound, doc.sound))
+		}
+		consider(entry, distance, doc.kalan.Entry, SUGGESTION_FIELD_ENTRY, doc.kalan.ID)
+	}
+	for _, term := range idx.terms {
+		for _, fields := range idx.postings[term] {
+			if fields&FIELD_GLOSS != 0 {
+				consider(term, Distance(folded, term), term, SUGGESTION_FIELD_GLOSS, 0)
+				break
+			}
+		}
+	}
+	idx.mu.RUnlock()
+
+	slices.SortFunc(suggestions, func(a Suggestion, b Suggestion) int {
+		return cmp.Or(
+			cmp.Compare(a.Distance, b.Distance),
+			cmp.Compare(a.Text, b.Text),
+			cmp.Compare(a.Field, b.Field),
+			cmp.Compare(a.KalanID, b.KalanID),
+		)
+	})
+
+	return suggestions[:min(limit, len(suggestions))]
+}
@@ -0,0 +1,93 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+
+	"wilin.info/api/server/search"
+)
+
+type DistanceValue struct {
+	a        string
+	b        string
+	expected int
+}
+
+func TestDistance(t *testing.T) {
+	distanceValues := []DistanceValue{
+		{"", "", 0},
+		{"kan", "kan", 0},
+		{"kan", "", 3},
+		{"", "kan", 3},
+		{"kan", "kin", 1},
+		{"kan", "kano", 1},
+		{"kano", "kan", 1},
+		{"wilin", "wlin", 1},
+		{"wilin", "iwlin", 1},
+		{"kitten", "sitting", 3},
+		{"sowé", "sowe", 1},
+	}
+
+	for _, test := range distanceValues {
+		distance := search.Distance(test.a, test.b)
+		if distance != test.expected {
+			t.Errorf("Distance(%q, %q) = %v, want %v", test.a, test.b, distance, test.expected)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	idx := newIndex()
+
+	suggestions := idx.Suggest("wilim", 10)
+	if len(suggestions) < 1 || suggestions[0].Text != "wilin" || suggestions[0].Distance != 1 {
+		t.Fatalf("got %+v, want wilin first", suggestions)
+	}
+	if suggestions[0].Field != search.SUGGESTION_FIELD_ENTRY || suggestions[0].KalanID != 1 {
+		t.Errorf("wilin should be suggested as the entry of kalan 1: %+v", suggestions[0])
+	}
+
+	suggestions = idx.Suggest("fod", 10)
+	if len(suggestions) != 1 || suggestions[0].Text != "food" || suggestions[0].Field != search.SUGGESTION_FIELD_GLOSS {
+		t.Errorf("got %+v, want the gloss word food", suggestions)
+	}
+
+	// words starting with the text are suggested for type-ahead
+	suggestions = idx.Suggest("wil", 10)
+	if len(suggestions) != 2 || suggestions[0].Text != "wilin" || suggestions[1].Text != "wilinka" {
+		t.Errorf("got %+v, want wilin and wilinka", suggestions)
+	}
+
+	suggestions = idx.Suggest("wil", 1)
+	if len(suggestions) != 1 {
+		t.Errorf("got %v suggestions, want the limit of 1", len(suggestions))
+	}
+
+	suggestions = idx.Suggest("sowa", 10)
+	if len(suggestions) != 1 || suggestions[0].Text != "sowé" {
+		t.Errorf("got %+v, want the entry sowé as it is written", suggestions)
+	}
+
+	suggestions = idx.Suggest("zzzzzz", 10)
+	if len(suggestions) != 0 {
+		t.Errorf("got %+v, want no suggestions", suggestions)
+	}
+}
+
+func TestSuggestSound(t *testing.T) {
+	idx := search.New()
+	// q is said as k and nn as n
+	idx.SetSound(strings.NewReplacer("q", "k", "nn", "n").Replace)
+	idx.Load(testKalans)
+
+	suggestions := idx.Suggest("qannso", 10)
+	if len(suggestions) < 1 || suggestions[0].Text != "kanso" || suggestions[0].Distance != 0 {
+		t.Errorf("got %+v, want kanso", suggestions)
+	}
+
+	// without a sound only the spelling is compared
+	suggestions = newIndex().Suggest("qannso", 10)
+	if len(suggestions) < 1 || suggestions[0].Text != "kanso" || suggestions[0].Distance != 2 {
+		t.Errorf("got %+v, want kanso two edits away", suggestions)
+	}
+}
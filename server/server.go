@@ -1,14 +1,13 @@
 package server
 
 import (
-	"context"
-	"database/sql"
 	"net/http"
+	"time"
 
-	"wilin.info/api/database/kalan"
-	"wilin.info/api/database/proposal"
-	"wilin.info/api/database/recovery"
-	"wilin.info/api/database/users"
+	"wilin.info/api/database"
+	"wilin.info/api/server/collation"
+	"wilin.info/api/server/morphology"
+	"wilin.info/api/server/phonology"
 	"wilin.info/api/server/router"
 	"wilin.info/api/server/services"
 
@@ -22,6 +21,22 @@ const TIME_FORMAT = "02-Jan-2006 15:04:05"
 
 const MANUAL_LOGGER_FORMAT = "[${level}] | ${short_file}:${line} |${message}"
 
+const DEFAULT_QUERY_TIMEOUT = time.Second * 5
+
+// QUERY_TIMEOUTS overrides the query timeout of routes that are
+// expected to take longer, such as reading the whole dictionary
+// or sending mail. Keys are the method and path of a route as it
+// is registered, so /verify does not cover /verify/:token
+var QUERY_TIMEOUTS = map[string]time.Duration{
+	"GET /kalan":           time.Second * 15,
+	"GET /kalan/paginated": time.Second * 10,
+	"GET /kalan/generate":  time.Second * 10,
+	"POST /gloss":          time.Second * 10,
+	"POST /signup":         time.Second * 30,
+	"POST /verify":         time.Second * 30,
+	"POST /recovery":       time.Second * 30,
+}
+
 func newLoggerConfig(format string, timeFormat string) middleware.LoggerConfig {
 	return middleware.LoggerConfig{
 		Format:           format,
@@ -29,7 +44,7 @@ func newLoggerConfig(format string, timeFormat string) middleware.LoggerConfig {
 	}
 }
 
-func New(db *sql.DB) *echo.Echo {
+func New(store database.Store, mailer services.Mailer, collator *collation.Collator, validator *phonology.Validator, analyzer *morphology.Morphology) *echo.Echo {
 	// initialize echo server
 	server := echo.New()
 	server.Logger.SetHeader(MANUAL_LOGGER_FORMAT)
@@ -43,17 +58,7 @@ func New(db *sql.DB) *echo.Echo {
 	server.Use(middleware.Recover())
 
 	// initialize router
-	kalanQueries := kalan.New(db)
-	usersQueries := users.New(db)
-	proposalQueries := proposal.New(db)
-	recoveryQueries := recovery.New(db)
-	router := router.New(
-		context.Background(),
-		kalanQueries,
-		usersQueries,
-		proposalQueries,
-		recoveryQueries,
-	)
+	router := router.New(store, mailer, collator, validator, analyzer)
 
 	// add preroute middleware
 	services.SetOrigins()
@@ -77,6 +82,7 @@ func New(db *sql.DB) *echo.Echo {
 		AllowCredentials: true,
 	}
 	server.Use(middleware.CORSWithConfig(corsConfig))
+	server.Use(router.QueryTimeout(DEFAULT_QUERY_TIMEOUT, QUERY_TIMEOUTS))
 	server.Use(router.ExtractUserID)
 
 	// add routes
@@ -92,6 +98,16 @@ func New(db *sql.DB) *echo.Echo {
 		router.GetKalanBySearch,
 		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
 	)
+	server.GET(
+		"/kalan/suggest",
+		router.GetKalanSuggestions,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
+	)
+	server.GET(
+		"/kalan/generate",
+		router.GenerateKalan,
+		router.VerifyPermissionsAll(services.PERMISSION_GENERATE_WORD),
+	)
 	server.GET(
 		"/kalan/:id",
 		router.GetKalanByID,
@@ -114,6 +130,108 @@ func New(db *sql.DB) *echo.Echo {
 		router.VerifyPermissionsAll(services.PERMISSION_DELETE_WORD),
 	)
 
+	server.GET(
+		"/kalan/:id/history",
+		router.GetKalanHistory,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD_HISTORY),
+	)
+	server.POST(
+		"/kalan/:id/revert/:rev",
+		router.RevertKalan,
+		router.VerifyPermissionsAll(services.PERMISSION_REVERT_WORD),
+	)
+	server.POST(
+		"/kalan/:id/restore",
+		router.RestoreKalan,
+		router.VerifyPermissionsAll(services.PERMISSION_REVERT_WORD),
+	)
+
+	server.GET(
+		"/kalan/:id/relations",
+		router.GetKalanRelations,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
+	)
+	server.POST(
+		"/kalan/:id/relations",
+		router.AddRelation,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_WORD),
+	)
+	server.DELETE(
+		"/kalan/:id/relations/:relation",
+		router.DeleteRelation,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_WORD),
+	)
+
+	server.POST(
+		"/kalan/:id/domains",
+		router.TagKalan,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_WORD),
+	)
+	server.DELETE(
+		"/kalan/:id/domains/:domain",
+		router.UntagKalan,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_WORD),
+	)
+
+	server.GET(
+		"/domains",
+		router.GetDomains,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
+	)
+	server.POST(
+		"/domains",
+		router.AddDomain,
+		router.VerifyPermissionsAll(services.PERMISSION_ADD_DOMAIN),
+	)
+	server.PUT(
+		"/domains",
+		router.UpdateDomain,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_DOMAIN),
+	)
+	server.DELETE(
+		"/domains/:id",
+		router.DeleteDomain,
+		router.VerifyPermissionsAll(services.PERMISSION_DELETE_DOMAIN),
+	)
+
+	server.GET(
+		"/example",
+		router.GetAllExamples,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_EXAMPLE),
+	)
+	server.GET(
+		"/example/:id",
+		router.GetExampleByID,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_EXAMPLE),
+	)
+	server.POST(
+		"/example",
+		router.AddExample,
+		router.VerifyPermissionsAll(services.PERMISSION_ADD_EXAMPLE),
+	)
+	server.PUT(
+		"/example",
+		router.UpdateExample,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_EXAMPLE),
+	)
+	server.DELETE(
+		"/example/:id",
+		router.DeleteExample,
+		router.VerifyPermissionsAll(services.PERMISSION_DELETE_EXAMPLE),
+	)
+
+	server.GET(
+		"/phonology/transcribe",
+		router.Transcribe,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
+	)
+
+	server.POST(
+		"/gloss",
+		router.GlossText,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_WORD),
+	)
+
 	server.GET(
 		"/proposal",
 		router.GetAllProposals,
@@ -139,6 +257,21 @@ func New(db *sql.DB) *echo.Echo {
 		router.DeleteProposal,
 		router.VerifyPermissionsAny(services.PERMISSION_DELETE_SELF_PROPOSAL, services.PERMISSION_DELETE_ALL_PROPOSAL),
 	)
+	server.POST(
+		"/proposal/:id/approve",
+		router.ApproveProposal,
+		router.VerifyPermissionsAll(services.PERMISSION_REVIEW_PROPOSAL),
+	)
+	server.POST(
+		"/proposal/:id/reject",
+		router.RejectProposal,
+		router.VerifyPermissionsAll(services.PERMISSION_REVIEW_PROPOSAL),
+	)
+	server.POST(
+		"/proposal/:id/withdraw",
+		router.WithdrawProposal,
+		router.VerifyPermissionsAll(services.PERMISSION_MODIFY_SELF_PROPOSAL),
+	)
 	server.GET(
 		"/proposal/me",
 		router.GetMyProposals,
@@ -149,6 +282,20 @@ func New(db *sql.DB) *echo.Echo {
 	server.POST("/login", router.HandleLogin)
 	server.GET("/me", router.GetMe)
 	server.POST("/refresh", router.HandleRefresh)
+	server.POST("/logout", router.Logout)
+	server.GET(
+		"/sessions",
+		router.GetSessions,
+		router.VerifyPermissionsAll(services.PERMISSION_VIEW_SELF_SESSION),
+	)
+	server.DELETE(
+		"/sessions/:id",
+		router.DeleteSession,
+		router.VerifyPermissionsAll(services.PERMISSION_DELETE_SELF_SESSION),
+	)
+
+	server.POST("/verify", router.ResendVerification)
+	server.POST("/verify/:token", router.VerifyEmail)
 
 	server.POST("/recovery", router.RequestRecovery)
 	server.POST("/recovery/:id", router.ChangePassword)
@@ -16,15 +16,27 @@ const BYCRYPT_COST = 13
 
 var SIGNING_ALG = jwt.SigningMethodHS256
 
+const (
+	TOKEN_TYPE_AUTH    = "authToken"
+	TOKEN_TYPE_REFRESH = "refreshToken"
+)
+
+const (
+	TOKEN_ISSUER   = "www.wilin.info"
+	TOKEN_AUDIENCE = "api.wilin.info"
+)
+
 var FAKE_PASSWORD string
 
 var (
 	ErrEmptySecret  = errors.New("empty secret")
 	ErrInvalidToken = errors.New("invalid token")
+	ErrWrongType    = errors.New("wrong token type")
 )
 
 type MyJWTClaims struct {
-	Type string `json:"type,omitempty"`
+	Type      string `json:"type,omitempty"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -45,6 +57,16 @@ func IsValidEmail(email string) bool {
 	return err == nil
 }
 
+// NormalizeEmail returns the bare address of email, without a display
+// name or angle brackets, which is the form addresses are stored in
+func NormalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
+
 func GeneratePasswordHash(password string) (string, error) {
 	hashBytes, err := bcrypt.GenerateFromPassword([]byte(password), BYCRYPT_COST)
 	if err != nil {
@@ -63,13 +85,22 @@ func FakeHashCompare() {
 }
 
 func GenerateToken(tokenType string, userId string, expireMinutes int) (string, error) {
+	return GenerateSessionToken(tokenType, userId, "", "", expireMinutes)
+}
+
+// GenerateSessionToken works like GenerateToken, but also ties the
+// token to a login session and gives it a unique id (jti)
+func GenerateSessionToken(tokenType string, userId string, sessionId string, tokenId string, expireMinutes int) (string, error) {
 	now := jwt.NewNumericDate(time.Now())
 	expiry := jwt.NewNumericDate(time.Now().Add(time.Minute * time.Duration(expireMinutes)))
 
 	claims := MyJWTClaims{
-		Type: tokenType,
+		Type:      tokenType,
+		SessionID: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "www.wilin.info",
+			ID:        tokenId,
+			Issuer:    TOKEN_ISSUER,
+			Audience:  jwt.ClaimStrings{TOKEN_AUDIENCE},
 			Subject:   userId,
 			IssuedAt:  now,
 			ExpiresAt: expiry,
@@ -86,7 +117,19 @@ func GenerateToken(tokenType string, userId string, expireMinutes int) (string,
 	return token.SignedString([]byte(secretKey))
 }
 
-func ParseToken(tokenString string) (*MyJWTClaims, error) {
+// ParseAuthToken parses a token sent to authenticate a request.
+// It fails for any token that is not an auth token
+func ParseAuthToken(tokenString string) (*MyJWTClaims, error) {
+	return parseToken(tokenString, TOKEN_TYPE_AUTH)
+}
+
+// ParseRefreshToken parses a token sent to get a new auth token.
+// It fails for any token that is not a refresh token
+func ParseRefreshToken(tokenString string) (*MyJWTClaims, error) {
+	return parseToken(tokenString, TOKEN_TYPE_REFRESH)
+}
+
+func parseToken(tokenString string, tokenType string) (*MyJWTClaims, error) {
 	secretKey := os.Getenv("SECRET_KEY")
 	if secretKey == "" {
 		return nil, ErrEmptySecret
@@ -98,18 +141,25 @@ func ParseToken(tokenString string) (*MyJWTClaims, error) {
 		tokenString,
 		claims,
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("SECRET_KEY")), nil
+			return []byte(secretKey), nil
 		},
 		jwt.WithValidMethods([]string{SIGNING_ALG.Alg()}),
+		jwt.WithIssuer(TOKEN_ISSUER),
+		jwt.WithAudience(TOKEN_AUDIENCE),
+		jwt.WithExpirationRequired(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing token: %v", err)
 	}
 
 	claims, ok := token.Claims.(*MyJWTClaims)
-	if ok && token.Valid {
-		return claims, nil
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType {
+		return nil, ErrWrongType
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
@@ -0,0 +1,148 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"wilin.info/api/server/services"
+)
+
+const TEST_SECRET = "test-secret"
+
+func signClaims(t *testing.T, method jwt.SigningMethod, key interface{}, claims services.MyJWTClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("could not sign token: %v", err)
+	}
+	return token
+}
+
+func newClaims(tokenType string, issuer string, audience string, expiry time.Duration) services.MyJWTClaims {
+	now := time.Now()
+	return services.MyJWTClaims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			Subject:   "1",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		},
+	}
+}
+
+type ParseTokenValue struct {
+	name          string
+	token         string
+	expectAuth    bool
+	expectRefresh bool
+}
+
+func TestParseTypedTokens(t *testing.T) {
+	t.Setenv("SECRET_KEY", TEST_SECRET)
+
+	authToken, err := services.GenerateToken(services.TOKEN_TYPE_AUTH, "1", 15)
+	if err != nil {
+		t.Fatalf("could not generate auth token: %v", err)
+	}
+	refreshToken, err := services.GenerateToken(services.TOKEN_TYPE_REFRESH, "1", 15)
+	if err != nil {
+		t.Fatalf("could not generate refresh token: %v", err)
+	}
+
+	secret := []byte(TEST_SECRET)
+	hour := time.Hour
+
+	parseTokenValues := []ParseTokenValue{
+		{"auth token", authToken, true, false},
+		{"refresh token", refreshToken, false, true},
+		{
+			"untyped token",
+			signClaims(t, jwt.SigningMethodHS256, secret, newClaims("", services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{
+			"unknown type",
+			signClaims(t, jwt.SigningMethodHS256, secret, newClaims("adminToken", services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{
+			"wrong issuer",
+			signClaims(t, jwt.SigningMethodHS256, secret, newClaims(services.TOKEN_TYPE_AUTH, "evil.example", services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{
+			"wrong audience",
+			signClaims(t, jwt.SigningMethodHS256, secret, newClaims(services.TOKEN_TYPE_REFRESH, services.TOKEN_ISSUER, "evil.example", hour)),
+			false,
+			false,
+		},
+		{
+			"expired auth token",
+			signClaims(t, jwt.SigningMethodHS256, secret, newClaims(services.TOKEN_TYPE_AUTH, services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, -hour)),
+			false,
+			false,
+		},
+		{
+			"wrong secret",
+			signClaims(t, jwt.SigningMethodHS256, []byte("other-secret"), newClaims(services.TOKEN_TYPE_AUTH, services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{
+			"wrong algorithm",
+			signClaims(t, jwt.SigningMethodHS512, secret, newClaims(services.TOKEN_TYPE_AUTH, services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{
+			"unsigned token",
+			signClaims(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, newClaims(services.TOKEN_TYPE_AUTH, services.TOKEN_ISSUER, services.TOKEN_AUDIENCE, hour)),
+			false,
+			false,
+		},
+		{"garbage", "not.a.token", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, test := range parseTokenValues {
+		_, err := services.ParseAuthToken(test.token)
+		if (err == nil) != test.expectAuth {
+			t.Errorf("%v: ParseAuthToken error = %v, want success %v", test.name, err, test.expectAuth)
+		}
+
+		_, err = services.ParseRefreshToken(test.token)
+		if (err == nil) != test.expectRefresh {
+			t.Errorf("%v: ParseRefreshToken error = %v, want success %v", test.name, err, test.expectRefresh)
+		}
+	}
+}
+
+func TestParseWrongTypeError(t *testing.T) {
+	t.Setenv("SECRET_KEY", TEST_SECRET)
+
+	refreshToken, err := services.GenerateToken(services.TOKEN_TYPE_REFRESH, "1", 15)
+	if err != nil {
+		t.Fatalf("could not generate refresh token: %v", err)
+	}
+
+	_, err = services.ParseAuthToken(refreshToken)
+	if !errors.Is(err, services.ErrWrongType) {
+		failTest(t, err, services.ErrWrongType)
+	}
+}
+
+func TestParseEmptySecret(t *testing.T) {
+	t.Setenv("SECRET_KEY", "")
+
+	_, err := services.ParseAuthToken("any.token.here")
+	if !errors.Is(err, services.ErrEmptySecret) {
+		failTest(t, err, services.ErrEmptySecret)
+	}
+}
@@ -16,12 +16,14 @@ const (
 	ROLE_GUEST Role = iota
 	ROLE_USER
 	ROLE_ADMIN
+	ROLE_UNVERIFIED
 )
 
 var roleToStrings = map[Role]string{
-	ROLE_GUEST: "",
-	ROLE_USER:  "user",
-	ROLE_ADMIN: "admin",
+	ROLE_GUEST:      "",
+	ROLE_USER:       "user",
+	ROLE_ADMIN:      "admin",
+	ROLE_UNVERIFIED: "unverified",
 }
 
 var stringToRoles = reverseMap(roleToStrings)
@@ -60,6 +62,16 @@ func NewRole(roleString string) Role {
 	return role
 }
 
+// NewUserRole returns the role of a user account. Users who
+// have not verified their email address get a restricted role
+func NewUserRole(roleString string, isVerified bool) Role {
+	role := NewRole(roleString)
+	if role == ROLE_USER && !isVerified {
+		return ROLE_UNVERIFIED
+	}
+	return role
+}
+
 type Permission int
 
 const (
@@ -74,6 +86,19 @@ const (
 	PERMISSION_MODIFY_SELF_PROPOSAL
 	PERMISSION_DELETE_ALL_PROPOSAL
 	PERMISSION_DELETE_SELF_PROPOSAL
+	PERMISSION_REVIEW_PROPOSAL
+	PERMISSION_VIEW_WORD_HISTORY
+	PERMISSION_REVERT_WORD
+	PERMISSION_GENERATE_WORD
+	PERMISSION_VIEW_EXAMPLE
+	PERMISSION_ADD_EXAMPLE
+	PERMISSION_MODIFY_EXAMPLE
+	PERMISSION_DELETE_EXAMPLE
+	PERMISSION_ADD_DOMAIN
+	PERMISSION_MODIFY_DOMAIN
+	PERMISSION_DELETE_DOMAIN
+	PERMISSION_VIEW_SELF_SESSION
+	PERMISSION_DELETE_SELF_SESSION
 )
 
 var permissionsMap = map[Role][]Permission{
@@ -89,6 +114,19 @@ var permissionsMap = map[Role][]Permission{
 		PERMISSION_MODIFY_SELF_PROPOSAL,
 		PERMISSION_DELETE_ALL_PROPOSAL,
 		PERMISSION_DELETE_SELF_PROPOSAL,
+		PERMISSION_REVIEW_PROPOSAL,
+		PERMISSION_VIEW_WORD_HISTORY,
+		PERMISSION_REVERT_WORD,
+		PERMISSION_GENERATE_WORD,
+		PERMISSION_VIEW_EXAMPLE,
+		PERMISSION_ADD_EXAMPLE,
+		PERMISSION_MODIFY_EXAMPLE,
+		PERMISSION_DELETE_EXAMPLE,
+		PERMISSION_ADD_DOMAIN,
+		PERMISSION_MODIFY_DOMAIN,
+		PERMISSION_DELETE_DOMAIN,
+		PERMISSION_VIEW_SELF_SESSION,
+		PERMISSION_DELETE_SELF_SESSION,
 	},
 	ROLE_USER: {
 		PERMISSION_VIEW_WORD,
@@ -96,8 +134,22 @@ var permissionsMap = map[Role][]Permission{
 		PERMISSION_VIEW_SELF_PROPOSAL,
 		PERMISSION_MODIFY_SELF_PROPOSAL,
 		PERMISSION_DELETE_SELF_PROPOSAL,
+		PERMISSION_GENERATE_WORD,
+		PERMISSION_VIEW_EXAMPLE,
+		PERMISSION_VIEW_SELF_SESSION,
+		PERMISSION_DELETE_SELF_SESSION,
+	},
+	ROLE_UNVERIFIED: {
+		PERMISSION_VIEW_WORD,
+		PERMISSION_VIEW_SELF_PROPOSAL,
+		PERMISSION_MODIFY_SELF_PROPOSAL,
+		PERMISSION_DELETE_SELF_PROPOSAL,
+		PERMISSION_VIEW_EXAMPLE,
+		PERMISSION_VIEW_SELF_SESSION,
+		PERMISSION_DELETE_SELF_SESSION,
 	},
 	ROLE_GUEST: {
 		PERMISSION_VIEW_WORD,
+		PERMISSION_VIEW_EXAMPLE,
 	},
 }
@@ -20,6 +20,7 @@ var RoleStringValues = []RoleStringValue{
 	{services.ROLE_GUEST, ""},
 	{services.ROLE_USER, "user"},
 	{services.ROLE_ADMIN, "admin"},
+	{services.ROLE_UNVERIFIED, "unverified"},
 }
 
 func TestRoleString(t *testing.T) {
@@ -42,14 +43,48 @@ var RoleCanValues = []RoleCanValue{
 	{services.ROLE_GUEST, services.PERMISSION_MODIFY_WORD, false},
 	{services.ROLE_GUEST, services.PERMISSION_DELETE_WORD, false},
 	{services.ROLE_GUEST, services.PERMISSION_VIEW_ALL_PROPOSAL, false},
+	{services.ROLE_GUEST, services.PERMISSION_REVIEW_PROPOSAL, false},
+	{services.ROLE_GUEST, services.PERMISSION_GENERATE_WORD, false},
+	{services.ROLE_GUEST, services.PERMISSION_VIEW_EXAMPLE, true},
+	{services.ROLE_GUEST, services.PERMISSION_ADD_EXAMPLE, false},
+	{services.ROLE_GUEST, services.PERMISSION_ADD_DOMAIN, false},
+	{services.ROLE_GUEST, services.PERMISSION_VIEW_SELF_SESSION, false},
+	{services.ROLE_GUEST, services.PERMISSION_DELETE_SELF_SESSION, false},
 	{services.ROLE_USER, services.PERMISSION_VIEW_SELF_PROPOSAL, true},
 	{services.ROLE_USER, services.PERMISSION_VIEW_ALL_PROPOSAL, false},
 	{services.ROLE_USER, services.PERMISSION_DELETE_WORD, false},
 	{services.ROLE_USER, services.PERMISSION_MODIFY_SELF_PROPOSAL, true},
+	{services.ROLE_USER, services.PERMISSION_REVIEW_PROPOSAL, false},
+	{services.ROLE_USER, services.PERMISSION_VIEW_WORD_HISTORY, false},
+	{services.ROLE_USER, services.PERMISSION_REVERT_WORD, false},
+	{services.ROLE_USER, services.PERMISSION_GENERATE_WORD, true},
+	{services.ROLE_USER, services.PERMISSION_VIEW_EXAMPLE, true},
+	{services.ROLE_USER, services.PERMISSION_MODIFY_EXAMPLE, false},
+	{services.ROLE_USER, services.PERMISSION_MODIFY_DOMAIN, false},
+	{services.ROLE_USER, services.PERMISSION_DELETE_DOMAIN, false},
+	{services.ROLE_USER, services.PERMISSION_DELETE_SELF_SESSION, true},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_VIEW_WORD, true},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_ADD_PROPOSAL, false},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_VIEW_SELF_PROPOSAL, true},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_REVIEW_PROPOSAL, false},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_GENERATE_WORD, false},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_VIEW_EXAMPLE, true},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_VIEW_SELF_SESSION, true},
+	{services.ROLE_UNVERIFIED, services.PERMISSION_DELETE_SELF_SESSION, true},
 	{services.ROLE_ADMIN, services.PERMISSION_DELETE_ALL_PROPOSAL, true},
 	{services.ROLE_ADMIN, services.PERMISSION_DELETE_WORD, true},
 	{services.ROLE_ADMIN, services.PERMISSION_MODIFY_WORD, true},
 	{services.ROLE_ADMIN, services.PERMISSION_VIEW_ALL_PROPOSAL, true},
+	{services.ROLE_ADMIN, services.PERMISSION_REVIEW_PROPOSAL, true},
+	{services.ROLE_ADMIN, services.PERMISSION_VIEW_WORD_HISTORY, true},
+	{services.ROLE_ADMIN, services.PERMISSION_REVERT_WORD, true},
+	{services.ROLE_ADMIN, services.PERMISSION_GENERATE_WORD, true},
+	{services.ROLE_ADMIN, services.PERMISSION_ADD_EXAMPLE, true},
+	{services.ROLE_ADMIN, services.PERMISSION_DELETE_EXAMPLE, true},
+	{services.ROLE_ADMIN, services.PERMISSION_ADD_DOMAIN, true},
+	{services.ROLE_ADMIN, services.PERMISSION_MODIFY_DOMAIN, true},
+	{services.ROLE_ADMIN, services.PERMISSION_DELETE_DOMAIN, true},
+	{services.ROLE_ADMIN, services.PERMISSION_VIEW_SELF_SESSION, true},
 }
 
 func TestRoleCan(t *testing.T) {
@@ -88,6 +123,30 @@ func TestRoleNew(t *testing.T) {
 	}
 }
 
+type UserRoleValue struct {
+	role       string
+	isVerified bool
+	expected   services.Role
+}
+
+var userRoleValues = []UserRoleValue{
+	{"user", true, services.ROLE_USER},
+	{"user", false, services.ROLE_UNVERIFIED},
+	{"admin", true, services.ROLE_ADMIN},
+	{"admin", false, services.ROLE_ADMIN},
+	{"", false, services.ROLE_GUEST},
+	{"nada", true, services.ROLE_GUEST},
+}
+
+func TestNewUserRole(t *testing.T) {
+	for _, test := range userRoleValues {
+		role := services.NewUserRole(test.role, test.isVerified)
+		if role != test.expected {
+			failTest(t, role, test.expected)
+		}
+	}
+}
+
 type RoleCanArrValue struct {
 	role        services.Role
 	permissions []services.Permission
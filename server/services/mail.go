@@ -0,0 +1,341 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"sync"
+	textTemplate "text/template"
+	"time"
+)
+
+const (
+	MAIL_DRIVER_SMTP   = "smtp"
+	MAIL_DRIVER_OUTBOX = "outbox"
+)
+
+const DEFAULT_SMTP_PORT = "587"
+const DEFAULT_SITE_URL = "https://www.wilin.info"
+
+var (
+	ErrNoMailFrom     = errors.New("no mail sender")
+	ErrNoSMTPHost     = errors.New("no smtp host")
+	ErrUnknownDriver  = errors.New("unknown mail driver")
+	ErrNoMailTemplate = errors.New("no mail template")
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	textTemplates = textTemplate.Must(textTemplate.ParseFS(templateFiles, "templates/*.txt.tmpl"))
+	htmlTemplates = htmlTemplate.Must(htmlTemplate.ParseFS(templateFiles, "templates/*.html.tmpl"))
+)
+
+// Message is an email with a plain-text body and an
+// optional HTML alternative
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer creates the mailer selected by the MAIL_DRIVER
+// environment variable. Mail is written to the outbox if
+// no driver is set
+func NewMailer() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case MAIL_DRIVER_SMTP:
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = DEFAULT_SMTP_PORT
+		}
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			port,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			from,
+		)
+	case MAIL_DRIVER_OUTBOX, "":
+		return NewOutboxMailer(os.Getenv("MAIL_OUTBOX"), from)
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
+
+// GetSiteURL returns the address of the frontend that
+// links in emails should point to
+func GetSiteURL() string {
+	siteURL := os.Getenv("SITE_URL")
+	if siteURL == "" {
+		return DEFAULT_SITE_URL
+	}
+	return siteURL
+}
+
+// RenderMessage fills in the plain-text and HTML templates with
+// the given name. The HTML part is left empty if there is no HTML
+// template for the message
+func RenderMessage(name string, to string, subject string, data any) (Message, error) {
+	msg := Message{To: to, Subject: subject}
+
+	textTmpl := textTemplates.Lookup(name + ".txt.tmpl")
+	if textTmpl == nil {
+		return msg, ErrNoMailTemplate
+	}
+
+	var text bytes.Buffer
+	err := textTmpl.Execute(&text, data)
+	if err != nil {
+		return msg, err
+	}
+	msg.Text = text.String()
+
+	htmlTmpl := htmlTemplates.Lookup(name + ".html.tmpl")
+	if htmlTmpl == nil {
+		return msg, nil
+	}
+
+	var html bytes.Buffer
+	err = htmlTmpl.Execute(&html, data)
+	if err != nil {
+		return msg, err
+	}
+	msg.HTML = html.String()
+
+	return msg, nil
+}
+
+// writeMessage writes msg to w in the MIME format expected by mail servers
+func writeMessage(w io.Writer, from string, msg Message) error {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	alternatives := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+
+	for _, alt := range alternatives {
+		if alt.content == "" {
+			continue
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", alt.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		part, err := parts.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		_, err = qp.Write([]byte(alt.content))
+		if err != nil {
+			return err
+		}
+		err = qp.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := parts.Close()
+	if err != nil {
+		return err
+	}
+
+	headers := []struct {
+		key   string
+		value string
+	}{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", parts.Boundary())},
+	}
+
+	for _, header := range headers {
+		_, err = fmt.Fprintf(w, "%s: %s\r\n", header.key, header.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprint(w, "\r\n")
+	if err != nil {
+		return err
+	}
+
+	_, err = body.WriteTo(w)
+	return err
+}
+
+// SMTPMailer delivers mail through an SMTP server, upgrading
+// the connection with STARTTLS whenever the server offers it
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) (*SMTPMailer, error) {
+	if host == "" {
+		return nil, ErrNoSMTPHost
+	}
+	if from == "" {
+		return nil, ErrNoMailFrom
+	}
+
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	// addresses stored before they were normalized may still carry
+	// a display name, which RCPT does not take
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, m.port))
+	if err != nil {
+		return err
+	}
+
+	// make sure a stuck server cannot outlive the request
+	deadline, ok := ctx.Deadline()
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: m.host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.username != "" {
+		auth := smtp.PlainAuth("", m.username, m.password, m.host)
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(m.from)
+	if err != nil {
+		return err
+	}
+
+	err = client.Rcpt(to.Address)
+	if err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	err = writeMessage(w, m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// OutboxMailer writes mail to a file or to stdout instead of
+// delivering it, which is useful during development
+type OutboxMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewOutboxMailer creates a mailer that appends every message to
+// the file at path. Messages are printed to stdout if path is
+// empty or "-"
+func NewOutboxMailer(path string, from string) (*OutboxMailer, error) {
+	if from == "" {
+		from = "no-reply@wilin.info"
+	}
+
+	if path == "" || path == "-" {
+		return NewWriterMailer(os.Stdout, from), nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterMailer(file, from), nil
+}
+
+// NewWriterMailer creates an outbox mailer that writes to w
+func NewWriterMailer(w io.Writer, from string) *OutboxMailer {
+	return &OutboxMailer{w: w, from: from}
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := writeMessage(m.w, m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(m.w, "\r\n")
+	return err
+}
@@ -0,0 +1,263 @@
+package services_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"wilin.info/api/server/services"
+)
+
+// fakeSMTPServer is a minimal in-process SMTP server that accepts
+// a single message and records what it was sent
+type fakeSMTPServer struct {
+	listener net.Listener
+	auth     string
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not start smtp server: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return server
+}
+
+func (s *fakeSMTPServer) port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			s.auth = string(decoded)
+			reply("235 authenticated")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = line[len("MAIL FROM:"):]
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.to = append(s.to, line[len("RCPT TO:"):])
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := startFakeSMTPServer(t)
+
+	mailer, err := services.NewSMTPMailer("127.0.0.1", server.port(), "wilin", "hunter2", "no-reply@wilin.info")
+	if err != nil {
+		t.Fatalf("could not create mailer: %v", err)
+	}
+
+	msg := services.Message{
+		To:      "jan@example.com",
+		Subject: "Hello there",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = mailer.Send(ctx, msg)
+	if err != nil {
+		t.Fatalf("could not send mail: %v", err)
+	}
+
+	<-server.done
+
+	if server.auth != "\x00wilin\x00hunter2" {
+		failTest(t, server.auth, "\x00wilin\x00hunter2")
+	}
+	if server.from != "<no-reply@wilin.info>" {
+		failTest(t, server.from, "<no-reply@wilin.info>")
+	}
+	if len(server.to) != 1 || server.to[0] != "<jan@example.com>" {
+		failTest(t, server.to, []string{"<jan@example.com>"})
+	}
+
+	expectedParts := []string{
+		"To: jan@example.com",
+		"Subject: Hello there",
+		"Content-Type: text/plain; charset=utf-8",
+		"plain body",
+		"Content-Type: text/html; charset=utf-8",
+		"<p>html body</p>",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(server.data, part) {
+			t.Errorf("message is missing %q:\n%v", part, server.data)
+		}
+	}
+}
+
+func TestSMTPMailerSendNamedAddress(t *testing.T) {
+	server := startFakeSMTPServer(t)
+
+	mailer, err := services.NewSMTPMailer("127.0.0.1", server.port(), "", "", "no-reply@wilin.info")
+	if err != nil {
+		t.Fatalf("could not create mailer: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = mailer.Send(ctx, services.Message{To: "Jan <jan@example.com>", Subject: "Hello there", Text: "plain body"})
+	if err != nil {
+		t.Fatalf("could not send mail: %v", err)
+	}
+
+	<-server.done
+
+	if len(server.to) != 1 || server.to[0] != "<jan@example.com>" {
+		failTest(t, server.to, []string{"<jan@example.com>"})
+	}
+}
+
+func TestSMTPMailerUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not reserve port: %v", err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	mailer, err := services.NewSMTPMailer("127.0.0.1", port, "", "", "no-reply@wilin.info")
+	if err != nil {
+		t.Fatalf("could not create mailer: %v", err)
+	}
+
+	err = mailer.Send(context.Background(), services.Message{To: "jan@example.com", Text: "hi"})
+	if err == nil {
+		t.Errorf("expected an error sending to a closed port")
+	}
+}
+
+type NewSMTPMailerValue struct {
+	host     string
+	from     string
+	expected error
+}
+
+var newSMTPMailerValues = []NewSMTPMailerValue{
+	{"smtp.example.com", "no-reply@wilin.info", nil},
+	{"", "no-reply@wilin.info", services.ErrNoSMTPHost},
+	{"smtp.example.com", "", services.ErrNoMailFrom},
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	for _, test := range newSMTPMailerValues {
+		_, err := services.NewSMTPMailer(test.host, "25", "", "", test.from)
+		if err != test.expected {
+			failTest(t, err, test.expected)
+		}
+	}
+}
+
+func TestOutboxMailerSend(t *testing.T) {
+	var outbox bytes.Buffer
+	mailer := services.NewWriterMailer(&outbox, "no-reply@wilin.info")
+
+	msg := services.Message{To: "jan@example.com", Subject: "Outbox", Text: "written to the outbox"}
+	err := mailer.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("could not send mail: %v", err)
+	}
+
+	for _, part := range []string{"From: no-reply@wilin.info", "To: jan@example.com", "written to the outbox"} {
+		if !strings.Contains(outbox.String(), part) {
+			t.Errorf("outbox is missing %q:\n%v", part, outbox.String())
+		}
+	}
+}
+
+func TestRenderRecoveryMessage(t *testing.T) {
+	data := struct {
+		Username      string
+		URL           string
+		ExpireMinutes int
+	}{"<jan>", "https://www.wilin.info/recovery/abc", 15}
+
+	msg, err := services.RenderMessage("recovery", "jan@example.com", "Reset", data)
+	if err != nil {
+		t.Fatalf("could not render message: %v", err)
+	}
+
+	if !strings.Contains(msg.Text, data.URL) || !strings.Contains(msg.Text, "<jan>") {
+		t.Errorf("text body is missing data:\n%v", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, data.URL) || !strings.Contains(msg.HTML, "&lt;jan&gt;") {
+		t.Errorf("html body is missing escaped data:\n%v", msg.HTML)
+	}
+}
+
+func TestRenderUnknownMessage(t *testing.T) {
+	_, err := services.RenderMessage("nothing", "jan@example.com", "Nothing", nil)
+	if err != services.ErrNoMailTemplate {
+		failTest(t, err, services.ErrNoMailTemplate)
+	}
+}
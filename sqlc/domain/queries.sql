@@ -0,0 +1,42 @@
+-- name: CreateDomain :execresult
+INSERT INTO
+    domains (parent_id, code, name)
+VALUES (?, ?, ?);
+
+-- name: ReadDomains :many
+SELECT * FROM domains ORDER BY id;
+
+-- name: ReadDomainByID :one
+SELECT * FROM domains WHERE id = ? LIMIT 1;
+
+-- name: ReadDomainsByKalanID :many
+SELECT domains.*
+FROM
+    domains
+    JOIN kalan_domains ON kalan_domains.domain_id = domains.id
+WHERE
+    kalan_domains.kalan_id = ?
+ORDER BY domains.id;
+
+-- name: UpdateDomain :execresult
+UPDATE domains
+SET
+    parent_id = ?,
+    code = ?,
+    name = ?
+WHERE
+    id = ?;
+
+-- name: DeleteDomain :execresult
+DELETE FROM domains WHERE id = ?;
+
+-- name: CreateKalanDomain :execresult
+INSERT INTO
+    kalan_domains (kalan_id, domain_id)
+VALUES (?, ?);
+
+-- name: ReadKalanDomains :many
+SELECT * FROM kalan_domains ORDER BY domain_id, kalan_id;
+
+-- name: DeleteKalanDomain :execresult
+DELETE FROM kalan_domains WHERE kalan_id = ? AND domain_id = ?;
\ No newline at end of file
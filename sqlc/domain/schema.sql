@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS domains (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    parent_id int,
+    code varchar(31) NOT NULL,
+    name varchar(255) NOT NULL,
+    FOREIGN KEY (parent_id) REFERENCES domains (id)
+);
+
+CREATE TABLE IF NOT EXISTS kalan_domains (
+    kalan_id int NOT NULL,
+    domain_id int NOT NULL,
+    PRIMARY KEY (kalan_id, domain_id),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE,
+    FOREIGN KEY (domain_id) REFERENCES domains (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,49 @@
+-- name: CreateExample :execresult
+INSERT INTO
+    examples (sentence, gloss, translation)
+VALUES (?, ?, ?);
+
+-- name: ReadExamples :many
+SELECT * FROM examples ORDER BY id;
+
+-- name: ReadExampleByID :one
+SELECT * FROM examples WHERE id = ? LIMIT 1;
+
+-- name: ReadExamplesByKalanID :many
+SELECT examples.*
+FROM
+    examples
+    JOIN kalan_examples ON kalan_examples.example_id = examples.id
+WHERE
+    kalan_examples.kalan_id = ?
+ORDER BY examples.id;
+
+-- name: UpdateExample :execresult
+UPDATE examples
+SET
+    sentence = ?,
+    gloss = ?,
+    translation = ?
+WHERE
+    id = ?;
+
+-- name: DeleteExample :execresult
+DELETE FROM examples WHERE id = ?;
+
+-- name: CreateKalanExample :execresult
+INSERT INTO
+    kalan_examples (kalan_id, example_id)
+VALUES (?, ?);
+
+-- name: ReadKalanExamples :many
+SELECT * FROM kalan_examples ORDER BY example_id, kalan_id;
+
+-- name: ReadKalanIDsByExampleID :many
+SELECT kalan_id
+FROM kalan_examples
+WHERE
+    example_id = ?
+ORDER BY kalan_id;
+
+-- name: DeleteKalanExamplesByExampleID :execresult
+DELETE FROM kalan_examples WHERE example_id = ?;
\ No newline at end of file
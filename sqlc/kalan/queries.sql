@@ -1,6 +1,9 @@
 -- name: CreateKalan :execresult
 INSERT INTO kalan (entry, pos, gloss, notes) VALUES (?, ?, ?, ?);
 
+-- name: CreateKalanWithID :execresult
+INSERT INTO kalan (id, entry, pos, gloss, notes) VALUES (?, ?, ?, ?, ?);
+
 -- name: ReadKalan :many
 SELECT * FROM kalan ORDER BY id;
 
@@ -10,57 +13,8 @@ SELECT * FROM kalan WHERE entry = ? LIMIT 1;
 -- name: ReadKalanById :one
 SELECT * FROM kalan WHERE id = ? LIMIT 1;
 
--- name: ReadKalanBySearch :many
-SELECT *
-FROM kalan
-WHERE (
-        sqlc.arg (isEntry) = True
-        AND entry LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isPos) = True
-        AND pos LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isGloss) = True
-        AND gloss LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isNotes) = True
-        AND notes LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-ORDER BY
-    CASE sqlc.arg (sort)
-        WHEN 'entry' THEN entry
-        WHEN 'pos' THEN pos
-        WHEN 'gloss' THEN gloss
-        WHEN 'notes' THEN notes
-        ELSE NULL
-    END,
-    id
-LIMIT ?
-OFFSET
-    ?;
-
--- name: ReadKalanSearchCount :one
-SELECT COUNT(*)
-FROM kalan
-WHERE (
-        sqlc.arg (isEntry) = True
-        AND entry LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isPos) = True
-        AND pos LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isGloss) = True
-        AND gloss LIKE CONCAT('%', sqlc.arg (search), '%')
-    )
-    OR (
-        sqlc.arg (isNotes) = True
-        AND notes LIKE CONCAT('%', sqlc.arg (search), '%')
-    );
+-- name: ReadKalanByIDs :many
+SELECT * FROM kalan WHERE id IN (sqlc.slice('ids')) ORDER BY id;
 
 -- name: ReadKalanCount :one
 SELECT COUNT(*) FROM kalan;
@@ -76,4 +30,63 @@ WHERE
     id = ?;
 
 -- name: DeleteKalan :execresult
-DELETE FROM kalan WHERE id = ?;
\ No newline at end of file
+DELETE FROM kalan WHERE id = ?;
+
+-- name: CreateSense :execresult
+INSERT INTO
+    kalan_senses (
+        kalan_id,
+        position,
+        gloss,
+        definition,
+        usage_label,
+        register
+    )
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ReadSenses :many
+SELECT * FROM kalan_senses ORDER BY kalan_id, position;
+
+-- name: ReadSensesByKalanID :many
+SELECT * FROM kalan_senses WHERE kalan_id = ? ORDER BY position;
+
+-- name: ReadSensesByKalanIDs :many
+SELECT *
+FROM kalan_senses
+WHERE
+    kalan_id IN (sqlc.slice('kalan_ids'))
+ORDER BY kalan_id, position;
+
+-- name: DeleteSensesByKalanID :execresult
+DELETE FROM kalan_senses WHERE kalan_id = ?;
+
+-- name: CreateRelation :execresult
+INSERT INTO
+    kalan_relations (kalan_id, related_id, kind)
+VALUES (?, ?, ?);
+
+-- name: ReadRelations :many
+SELECT * FROM kalan_relations ORDER BY id;
+
+-- name: ReadRelationsByKalanIDs :many
+SELECT *
+FROM kalan_relations
+WHERE
+    kalan_id IN (sqlc.slice('kalan_ids'))
+    OR related_id IN (sqlc.slice('related_ids'))
+ORDER BY id;
+
+-- name: ReadRelationByID :one
+SELECT * FROM kalan_relations WHERE id = ? LIMIT 1;
+
+-- name: ReadRelationByPair :one
+SELECT *
+FROM kalan_relations
+WHERE
+    kalan_id = ?
+    AND related_id = ?
+    AND kind = ?
+LIMIT 1;
+
+-- name: DeleteRelation :execresult
+DELETE FROM kalan_relations WHERE id = ?;
\ No newline at end of file
@@ -4,4 +4,26 @@ CREATE TABLE IF NOT EXISTS kalan (
     pos VARCHAR(255) NOT NULL,
     gloss VARCHAR(255) NOT NULL,
     notes VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS kalan_senses (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    kalan_id int NOT NULL,
+    position int NOT NULL,
+    gloss VARCHAR(255) NOT NULL,
+    definition TEXT NOT NULL,
+    usage_label VARCHAR(255) NOT NULL,
+    register VARCHAR(255) NOT NULL,
+    UNIQUE (kalan_id, position),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS kalan_relations (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    kalan_id int NOT NULL,
+    related_id int NOT NULL,
+    kind varchar(31) NOT NULL,
+    UNIQUE (kalan_id, related_id, kind),
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE CASCADE,
+    FOREIGN KEY (related_id) REFERENCES kalan (id) ON DELETE CASCADE
 );
\ No newline at end of file
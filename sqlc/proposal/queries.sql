@@ -5,24 +5,26 @@ INSERT INTO
         entry,
         pos,
         gloss,
-        notes
+        notes,
+        kind,
+        kalan_id
     )
-VALUES (?, ?, ?, ?, ?);
+VALUES (?, ?, ?, ?, ?, ?, ?);
 
 -- name: ReadAllProposalsWithUsername :many
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id;
 
 -- name: ReadProposalsByUserIDWithUsername :many
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id
 WHERE
     u.id = ?;
 
 -- name: ReadProposalByIDWithUsername :one
-SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes
+SELECT p.id, p.user_id, u.username, p.entry, p.pos, p.gloss, p.notes, p.status, p.reason, p.kind, p.kalan_id
 FROM proposals p
     JOIN users u ON u.id = p.user_id
 WHERE
@@ -38,7 +40,17 @@ SET
     gloss = ?,
     notes = ?
 WHERE
-    id = ?;
+    id = ?
+    AND status = 'pending';
+
+-- name: UpdateStatus :execresult
+UPDATE proposals
+SET
+    status = ?,
+    reason = ?
+WHERE
+    id = ?
+    AND status = 'pending';
 
 -- name: Delete :execresult
 DELETE FROM proposals WHERE id = ?;
\ No newline at end of file
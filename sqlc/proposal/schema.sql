@@ -5,5 +5,10 @@ CREATE TABLE IF NOT EXISTS proposals (
     pos varchar(255) NOT NULL,
     gloss varchar(255) NOT NULL,
     notes varchar(2047) NOT NULL,
-    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+    status varchar(31) NOT NULL DEFAULT 'pending',
+    reason varchar(2047) NOT NULL DEFAULT '',
+    kind varchar(31) NOT NULL DEFAULT 'new',
+    kalan_id int,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL,
+    FOREIGN KEY (kalan_id) REFERENCES kalan (id) ON DELETE SET NULL
 );
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: CreateRevision :execresult
+INSERT INTO
+    kalan_revisions (
+        kalan_id,
+        action,
+        entry,
+        pos,
+        gloss,
+        notes,
+        user_id,
+        senses,
+        links
+    )
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ReadRevisionsByKalanID :many
+SELECT * FROM kalan_revisions WHERE kalan_id = ? ORDER BY id DESC;
+
+-- name: ReadRevisionByID :one
+SELECT * FROM kalan_revisions WHERE id = ? LIMIT 1;
+
+-- name: ReadLatestRevisionByKalanID :one
+SELECT *
+FROM kalan_revisions
+WHERE
+    kalan_id = ?
+ORDER BY id DESC
+LIMIT 1;
\ No newline at end of file
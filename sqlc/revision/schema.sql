@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS kalan_revisions (
+    id int PRIMARY KEY AUTO_INCREMENT,
+    kalan_id int NOT NULL,
+    action varchar(31) NOT NULL,
+    entry VARCHAR(255) NOT NULL,
+    pos VARCHAR(255) NOT NULL,
+    gloss VARCHAR(255) NOT NULL,
+    notes VARCHAR(255) NOT NULL,
+    user_id int,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    senses TEXT,
+    links TEXT,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
\ No newline at end of file
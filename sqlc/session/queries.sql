@@ -0,0 +1,45 @@
+-- name: CreateSession :execresult
+INSERT INTO
+    sessions (
+        id,
+        user_id,
+        user_agent,
+        expired_at
+    )
+VALUES (?, ?, ?, ?);
+
+-- name: ReadSessionByID :one
+SELECT * FROM sessions WHERE id = ? LIMIT 1;
+
+-- name: ReadActiveSessionsByUserID :many
+SELECT *
+FROM sessions
+WHERE
+    user_id = ?
+    AND revoked = FALSE
+ORDER BY last_used_at DESC;
+
+-- name: TouchSession :execresult
+UPDATE sessions
+SET
+    last_used_at = CURRENT_TIMESTAMP,
+    expired_at = ?
+WHERE
+    id = ?;
+
+-- name: RevokeSession :execresult
+UPDATE sessions SET revoked = TRUE WHERE id = ?;
+
+-- name: RevokeSessionsByUserID :execresult
+UPDATE sessions SET revoked = TRUE WHERE user_id = ?;
+
+-- name: CreateRefreshToken :execresult
+INSERT INTO
+    refresh_tokens (id, session_id, expired_at)
+VALUES (?, ?, ?);
+
+-- name: ReadRefreshTokenByID :one
+SELECT * FROM refresh_tokens WHERE id = ? LIMIT 1;
+
+-- name: UseRefreshToken :execresult
+UPDATE refresh_tokens SET used = TRUE WHERE id = ? AND used = FALSE;
\ No newline at end of file
@@ -14,7 +14,8 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     username = ?
@@ -26,7 +27,8 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     email = ?
@@ -38,11 +40,15 @@ SELECT
     email,
     username,
     password,
-    role
+    role,
+    verified
 FROM users
 WHERE
     id = ?
 LIMIT 1;
 
 -- name: UpdatePassword :execresult
-UPDATE users SET password = ? WHERE id = ?;
\ No newline at end of file
+UPDATE users SET password = ? WHERE id = ?;
+
+-- name: VerifyUser :execresult
+UPDATE users SET verified = TRUE WHERE id = ?;
\ No newline at end of file
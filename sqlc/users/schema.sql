@@ -3,5 +3,6 @@ CREATE TABLE IF NOT EXISTS users (
     email varchar(127) UNIQUE NOT NULL,
     username varchar(31) UNIQUE NOT NULL,
     password varchar(255) NOT NULL,
-    role varchar(255) NOT NULL
+    role varchar(255) NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE
 );
\ No newline at end of file
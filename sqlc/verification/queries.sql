@@ -0,0 +1,11 @@
+-- name: Create :execresult
+INSERT INTO verifications (id, user_id, expired_at) VALUES (?, ?, ?);
+
+-- name: ReadByID :one
+SELECT * FROM verifications WHERE id = ? LIMIT 1;
+
+-- name: DeleteByID :execresult
+DELETE FROM verifications WHERE id = ?;
+
+-- name: DeleteByUserID :execresult
+DELETE FROM verifications WHERE user_id = ?;
\ No newline at end of file